@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"container/list"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	secretApi "github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+const maxCachedCryptographers = 128
+
+// maxConcurrentDerivations limits cipher keys derived by server at once.
+// Every derivation takes tens of megabytes of memory, so requests with new passphrases can't exhaust it.
+const maxConcurrentDerivations = 4
+
+// cryptographerCache keeps recently used cryptographers by keyed hash of passphrase.
+// Hash is keyed by random secret of the cache, so passphrases and keys derived from them can't be recovered from cache keys.
+// The least recently used cryptographer is evicted when the cache is full.
+// Keys are derived only when derivations semaphore has room, it can be shared by several caches.
+type cryptographerCache struct {
+	mu          sync.Mutex
+	params      crypto.KDFParams
+	size        int
+	secret      []byte
+	derivations chan struct{}
+	items  map[[sha256.Size]byte]*list.Element
+	// order keeps cache entries from the most to the least recently used
+	order *list.List
+}
+
+// cryptographerEntry is the element of cryptographer cache order.
+type cryptographerEntry struct {
+	id [sha256.Size]byte
+	cr secretApi.Cryptographer
+}
+
+func newCryptographerCache(params crypto.KDFParams, size int, derivations chan struct{}) (*cryptographerCache, error) {
+	secret := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+		return nil, fmt.Errorf("can't generate cache secret: %w", err)
+	}
+	return &cryptographerCache{
+		params:      params,
+		size:        size,
+		secret:      secret,
+		derivations: derivations,
+		items:       make(map[[sha256.Size]byte]*list.Element),
+		order:       list.New(),
+	}, nil
+}
+
+func (c *cryptographerCache) get(passphrase string) (secretApi.Cryptographer, error) {
+	var id [sha256.Size]byte
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(passphrase))
+	copy(id[:], mac.Sum(nil))
+	if cr, ok := c.cached(id); ok {
+		return cr, nil
+	}
+	c.derivations <- struct{}{}
+	defer func() { <-c.derivations }()
+	if cr, ok := c.cached(id); ok {
+		// another request has derived the same key while this one waited
+		return cr, nil
+	}
+	cr, err := crypto.NewCryptographerKDF([]byte(passphrase), c.params, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[id]; ok {
+		// another request has derived the same key meanwhile
+		c.order.MoveToFront(e)
+		return e.Value.(*cryptographerEntry).cr, nil
+	}
+	c.items[id] = c.order.PushFront(&cryptographerEntry{id: id, cr: cr})
+	for c.order.Len() > c.size {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.items, last.Value.(*cryptographerEntry).id)
+	}
+	return cr, nil
+}
+
+// cached returns cryptographer by cache key and marks it as the most recently used.
+func (c *cryptographerCache) cached(id [sha256.Size]byte) (secretApi.Cryptographer, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[id]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cryptographerEntry).cr, true
+}
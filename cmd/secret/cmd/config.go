@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// envStorage is the environment variable with storage URL, it's used if the flag isn't set.
+	envStorage = "GO_SECRET_STORAGE"
+	// envConfig is the environment variable with config file path, it's used if the flag isn't set.
+	envConfig = "GO_SECRET_CONFIG"
+)
+
+// config is the content of config file.
+// Methods are served by server in addition to the storage.
+//
+//    storage:
+//        url: redis://:password@localhost:6379/1
+//        migrations: file://scripts/migrations
+//        max_versions: 10
+//        sealed: false
+//    methods:
+//        team-a-redis:
+//            url: redis://:password@localhost:6379/2
+//            prefix: team-a/
+//            policy: team-a-policy.yaml
+//        ci-file:
+//            url: file:///var/lib/go-secret/ci.json
+type config struct {
+	Storage storageConfig           `yaml:"storage"`
+	Methods map[string]methodConfig `yaml:"methods"`
+}
+
+// storageConfig is the storage section of config file.
+type storageConfig struct {
+	URL         string `yaml:"url"`
+	Migrations  string `yaml:"migrations"`
+	MaxVersions *int   `yaml:"max_versions"`
+	Sealed      bool   `yaml:"sealed"`
+}
+
+// methodConfig is the section of config file with the method served by server.
+// Prefix is put before keys of the method, so methods can share the storage, see provider.KeyPrefix.
+// Policy is YAML file with actions granted on keys of the method, see --auth-policy flag.
+type methodConfig struct {
+	storageConfig `yaml:",inline"`
+	Prefix        string `yaml:"prefix"`
+	Policy        string `yaml:"policy"`
+}
+
+// loadConfig reads config file by path, unknown fields are errors.
+func loadConfig(path string) (config, error) {
+	var c config
+	f, err := os.Open(path)
+	if err != nil {
+		return c, fmt.Errorf("can't open config: %w", err)
+	}
+	defer f.Close()
+	d := yaml.NewDecoder(f)
+	d.KnownFields(true)
+	if err := d.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return c, fmt.Errorf("can't parse config %s: %w", path, err)
+	}
+	return c, nil
+}
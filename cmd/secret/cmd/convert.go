@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/io/storage"
+)
+
+const (
+	layoutPlain  = "plain"
+	layoutSealed = "sealed"
+)
+
+func (r *root) convertCmd() *cobra.Command {
+	var cipherKey string
+	var path string
+	var to string
+	var kdfCost int
+	var convertCmd = &cobra.Command{
+		Use:   "convert",
+		Short: "Convert file storage between plain and sealed layouts",
+		Long:  "it takes cipher key from user and encrypts the whole file storage with it or decrypts sealed file storage back to plain layout",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger := r.logger.Named("convert-cmd")
+			logger.Info("Start")
+			switch to {
+			case layoutSealed:
+				err := storage.SealFileVault(path, []byte(cipherKey), storage.SealKDFCost(kdfCost))
+				if err != nil {
+					return fmt.Errorf("can't convert storage: %w", err)
+				}
+			case layoutPlain:
+				if err := storage.UnsealFileVault(path, []byte(cipherKey)); err != nil {
+					return fmt.Errorf("can't convert storage: %w", err)
+				}
+			default:
+				return fmt.Errorf("unsupported layout %q", to)
+			}
+			logger.Info("ready convert storage to ", to)
+			return nil
+		},
+	}
+	convertCmd.Flags().StringVarP(&cipherKey, "cipher-key", "c", cipherKey, "cipher key for file encryption and decryption")
+	convertCmd.Flags().StringVarP(&path, "path", "p", "file.txt", "the place where the key/value are stored")
+	convertCmd.Flags().StringVar(&to, "to", layoutSealed, "target layout: sealed or plain")
+	convertCmd.Flags().IntVar(&kdfCost, "kdf-cost", crypto.DefaultScryptCost, "log2 of scrypt cost parameter for sealed file")
+
+	return convertCmd
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/go-itools-internship/go-secret/pkg/provider"
+)
+
+func (r *root) deleteCmd() *cobra.Command {
+	var key string
+	var cipherKey string
+	var force bool
+	var sf storageFlags
+	var cf cryptoFlags
+	var deleteCmd = &cobra.Command{
+		Use:   "delete",
+		Short: "Delete data by key from the specified storage",
+		Long:  "it takes key and cipher key from user and removes pair key-value from specified storage",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger := r.logger.Named("delete-cmd")
+			logger.Info("Start")
+			if !force {
+				cmd.Printf("Delete key %q? [y/N]: ", key)
+				answer, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+				if err != nil && !errors.Is(err, io.EOF) {
+					return fmt.Errorf("can't read confirmation: %w", err)
+				}
+				if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+					cmd.Println("Deletion canceled")
+					return nil
+				}
+			}
+			ds, closeFn, err := r.openDataSaver(cmd.Context(), sf, cipherKey, cf, logger)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			pr, err := provider.Open(ds, []byte(cipherKey), cf.options()...)
+			if err != nil {
+				return fmt.Errorf("can't open storage: %w", err)
+			}
+			logger.Info("prepare delete data by key: ", key)
+			if err := pr.DeleteDataCtx(cmd.Context(), []byte(key)); err != nil {
+				return fmt.Errorf("can't delete data by key: %w", err)
+			}
+			logger.Info("ready delete data by key: ", key)
+			return nil
+		},
+	}
+	deleteCmd.Flags().StringVarP(&key, "key", "k", key, "key for pair key-value")
+	deleteCmd.Flags().StringVarP(&cipherKey, "cipher-key", "c", cipherKey, "cipher key for data encryption and decryption")
+	deleteCmd.Flags().BoolVarP(&force, "force", "f", false, "delete without confirmation")
+	sf.register(deleteCmd)
+	cf.register(deleteCmd)
+
+	return deleteCmd
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/go-itools-internship/go-secret/pkg/provider"
+)
+
+func (r *root) describeCmd() *cobra.Command {
+	var key string
+	var cipherKey string
+	var sf storageFlags
+	var cf cryptoFlags
+	var describeCmd = &cobra.Command{
+		Use:   "describe",
+		Short: "Print metadata of the key",
+		Long:  "it takes key and cipher key from user and prints labels, description, owner and timestamps of the value as JSON",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger := r.logger.Named("describe-cmd")
+			logger.Info("Start")
+			ds, closeFn, err := r.openDataSaver(cmd.Context(), sf, cipherKey, cf, logger)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			pr, err := provider.Open(ds, []byte(cipherKey), cf.options()...)
+			if err != nil {
+				return fmt.Errorf("can't open storage: %w", err)
+			}
+			md, err := pr.GetMetadataCtx(cmd.Context(), []byte(key))
+			if err != nil {
+				return fmt.Errorf("can't get metadata by key: %w", err)
+			}
+			logger.Info("ready describe key: ", key)
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			if err := enc.Encode(md); err != nil {
+				return fmt.Errorf("can't write metadata: %w", err)
+			}
+			return nil
+		},
+	}
+	describeCmd.Flags().StringVarP(&key, "key", "k", key, "key for pair key-value")
+	describeCmd.Flags().StringVarP(&cipherKey, "cipher-key", "c", cipherKey, "cipher key for data encryption and decryption")
+	sf.register(describeCmd)
+	cf.register(describeCmd)
+
+	return describeCmd
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/go-itools-internship/go-secret/pkg/provider"
+	secretApi "github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func (r *root) getCmd() *cobra.Command {
+	var keys []string
+	var cipherKey string
+	var version int
+	var sf storageFlags
+	var cf cryptoFlags
+	var getCmd = &cobra.Command{
+		Use:   "get",
+		Short: "Get data from specified storage in decrypted form",
+		Long:  "it takes keys from user and get value in decrypted manner from specified storage. Several keys are printed as \"key<TAB>value\" lines",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger := r.logger.Named("get-cmd")
+			logger.Info("Start")
+			if len(keys) > 1 && version > 0 {
+				return errors.New("version can be used only with single key")
+			}
+			ds, closeFn, err := r.openDataSaver(cmd.Context(), sf, cipherKey, cf, logger)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			pr, err := provider.Open(ds, []byte(cipherKey), cf.options()...)
+			if err != nil {
+				return fmt.Errorf("can't open storage: %w", err)
+			}
+			if len(keys) > 1 {
+				return r.getBatch(cmd, pr, keys)
+			}
+			var key string
+			if len(keys) == 1 {
+				key = keys[0]
+			}
+			logger.Info("prepare by get data by key: ", key)
+			var data []byte
+			if version > 0 {
+				data, err = pr.GetVersionCtx(cmd.Context(), []byte(key), version)
+			} else {
+				data, err = pr.GetDataCtx(cmd.Context(), []byte(key))
+			}
+			if err != nil {
+				return fmt.Errorf("can't get data by key: %w", err)
+			}
+			logger.Info("ready get data by key: ", key)
+			cmd.Println(string(data))
+			return nil
+		},
+	}
+	getCmd.Flags().StringArrayVarP(&keys, "key", "k", nil, "key for pair key-value. Can be repeated to get several values at once")
+	getCmd.Flags().StringVarP(&cipherKey, "cipher-key", "c", cipherKey, "cipher key for data encryption and decryption")
+	getCmd.Flags().IntVar(&version, "version", 0, "number of the version to get, see history command. The latest version by default")
+	sf.register(getCmd)
+	cf.register(getCmd)
+
+	return getCmd
+}
+
+// getBatch prints values of several keys read at once.
+// Nothing is printed if any key fails.
+func (r *root) getBatch(cmd *cobra.Command, pr secretApi.BatchProvider, keys []string) error {
+	logger := r.logger.Named("get-cmd")
+	byteKeys := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		byteKeys = append(byteKeys, []byte(key))
+	}
+	logger.Infof("prepare get data by keys: %d", len(keys))
+	results, err := pr.GetDataBatchCtx(cmd.Context(), byteKeys)
+	if err != nil {
+		return fmt.Errorf("can't get data: %w", err)
+	}
+	for i, res := range results {
+		if res.Err != nil {
+			return fmt.Errorf("can't get data by key %s: %w", keys[i], res.Err)
+		}
+	}
+	logger.Infof("ready get data by keys: %d", len(keys))
+	for i, res := range results {
+		cmd.Printf("%s\t%s\n", keys[i], res.Value)
+	}
+	return nil
+}
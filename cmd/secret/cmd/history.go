@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/go-itools-internship/go-secret/pkg/provider"
+)
+
+func (r *root) historyCmd() *cobra.Command {
+	var key string
+	var cipherKey string
+	var output string
+	var sf storageFlags
+	var cf cryptoFlags
+	var historyCmd = &cobra.Command{
+		Use:   "history",
+		Short: "List versions of the key",
+		Long:  "it takes key and cipher key from user and prints versions of the value from the oldest to the latest",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger := r.logger.Named("history-cmd")
+			logger.Info("Start")
+			if output != outputText && output != outputJSON {
+				return fmt.Errorf("unsupported output format %q", output)
+			}
+			ds, closeFn, err := r.openDataSaver(cmd.Context(), sf, cipherKey, cf, logger)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			pr, err := provider.Open(ds, []byte(cipherKey), cf.options()...)
+			if err != nil {
+				return fmt.Errorf("can't open storage: %w", err)
+			}
+			versions, err := pr.VersionsCtx(cmd.Context(), []byte(key))
+			if err != nil {
+				return fmt.Errorf("can't get versions by key: %w", err)
+			}
+			logger.Infof("ready history of key %s: %d", key, len(versions))
+
+			if output == outputJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				if err := enc.Encode(versions); err != nil {
+					return fmt.Errorf("can't write versions: %w", err)
+				}
+				return nil
+			}
+			for _, v := range versions {
+				created := "-"
+				if !v.CreatedAt.IsZero() {
+					created = v.CreatedAt.Format(time.RFC3339)
+				}
+				cmd.Printf("%d\t%s\n", v.Number, created)
+			}
+			return nil
+		},
+	}
+	historyCmd.Flags().StringVarP(&key, "key", "k", key, "key for pair key-value")
+	historyCmd.Flags().StringVarP(&cipherKey, "cipher-key", "c", cipherKey, "cipher key for data encryption and decryption")
+	historyCmd.Flags().StringVarP(&output, "output", "o", outputText, "output format: text or json")
+	sf.register(historyCmd)
+	cf.register(historyCmd)
+
+	return historyCmd
+}
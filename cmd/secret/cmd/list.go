@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/go-itools-internship/go-secret/pkg/provider"
+	secretApi "github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func (r *root) listCmd() *cobra.Command {
+	var cipherKey string
+	var prefix string
+	var labels []string
+	var output string
+	var sf storageFlags
+	var cf cryptoFlags
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List names of all keys that can be decrypted with cipher key",
+		Long:  "it takes cipher key from user and prints all key names from specified storage which can be decrypted with it",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger := r.logger.Named("list-cmd")
+			logger.Info("Start")
+			if output != outputText && output != outputJSON {
+				return fmt.Errorf("unsupported output format %q", output)
+			}
+			labelFilter, err := secretApi.ParseLabels(labels)
+			if err != nil {
+				return err
+			}
+			ds, closeFn, err := r.openDataSaver(cmd.Context(), sf, cipherKey, cf, logger)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			pr, err := provider.Open(ds, []byte(cipherKey), cf.options()...)
+			if err != nil {
+				return fmt.Errorf("can't open storage: %w", err)
+			}
+			keys, err := pr.ListKeysByLabelsCtx(cmd.Context(), labelFilter)
+			if err != nil {
+				return fmt.Errorf("can't list keys: %w", err)
+			}
+			names := make([]string, 0, len(keys))
+			for _, k := range keys {
+				if strings.HasPrefix(string(k), prefix) {
+					names = append(names, string(k))
+				}
+			}
+			sort.Strings(names)
+			logger.Infof("ready list keys: %d", len(names))
+
+			if output == outputJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				if err := enc.Encode(names); err != nil {
+					return fmt.Errorf("can't write keys: %w", err)
+				}
+				return nil
+			}
+			for _, name := range names {
+				cmd.Println(name)
+			}
+			return nil
+		},
+	}
+	listCmd.Flags().StringVarP(&cipherKey, "cipher-key", "c", cipherKey, "cipher key for data encryption and decryption")
+	listCmd.Flags().StringVar(&prefix, "prefix", "", "print only keys which start with prefix")
+	listCmd.Flags().StringArrayVar(&labels, "label", nil, "print only keys with the label, \"name=value\" or \"name\" for any value. Can be repeated to match all labels")
+	listCmd.Flags().StringVarP(&output, "output", "o", outputText, "output format: text or json")
+	sf.register(listCmd)
+	cf.register(listCmd)
+
+	return listCmd
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/go-itools-internship/go-secret/pkg/provider"
+)
+
+func (r *root) migrateCmd() *cobra.Command {
+	var cipherKey string
+	var sf storageFlags
+	var cf cryptoFlags
+	var migrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "Re-encrypt data saved by previous versions with random nonces",
+		Long:  "it takes cipher key from user and re-encrypts all pairs key-value saved with nonce derived from the cipher key",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger := r.logger.Named("migrate-cmd")
+			logger.Info("Start")
+			ds, closeFn, err := r.openDataSaver(cmd.Context(), sf, cipherKey, cf, logger)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			pr, err := provider.Open(ds, []byte(cipherKey), cf.options()...)
+			if err != nil {
+				return fmt.Errorf("can't open storage: %w", err)
+			}
+			n, err := pr.MigrateCtx(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("can't migrate data: %w", err)
+			}
+			logger.Info("ready migrate data")
+			cmd.Printf("Migrated %d entries\n", n)
+			return nil
+		},
+	}
+	migrateCmd.Flags().StringVarP(&cipherKey, "cipher-key", "c", cipherKey, "cipher key for data encryption and decryption")
+	sf.register(migrateCmd)
+	cf.register(migrateCmd)
+
+	return migrateCmd
+}
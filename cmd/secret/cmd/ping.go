@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/go-itools-internship/go-secret/pkg/tlsconfig"
+)
+
+func (r *root) serverPingCmd() *cobra.Command {
+	var url string
+	var port string
+	var route string
+	var timeout time.Duration
+	var caFile, clientCert, clientKey string
+	var serverPingCmd = &cobra.Command{
+		Use:   "ping",
+		Short: "Check a health check route endpoint",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := http.Client{
+				Timeout: timeout,
+			}
+			if caFile != "" || clientCert != "" || clientKey != "" {
+				config, err := tlsconfig.ClientConfig(caFile, clientCert, clientKey)
+				if err != nil {
+					return err
+				}
+				client.Transport = &http.Transport{TLSClientConfig: config}
+			}
+			resp, err := client.Get(fmt.Sprintf("%s:%s%s", url, port, route))
+			if err != nil {
+				return fmt.Errorf("server response error: %w", err)
+			}
+			defer func() {
+				if err := resp.Body.Close(); err != nil {
+					fmt.Println("server: can't close request body: ", err.Error())
+				}
+			}()
+
+			if resp.StatusCode != http.StatusOK {
+				responseBody, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					return fmt.Errorf("server: can't get response body %w", err)
+				}
+				return fmt.Errorf("server response is not expected: body %q, wrong status code %d", responseBody, resp.StatusCode)
+			}
+			return nil
+		},
+	}
+	serverPingCmd.Flags().StringVarP(&port, "port", "p", "8880", "port to connect. Address shouldn't have port. Default: '8880")
+	serverPingCmd.Flags().StringVarP(&route, "route", "r", "/ping", "health check route. Default: '/ping'")
+	serverPingCmd.Flags().StringVarP(&url, "url", "u", "http://localhost", "url for server checking. Url shouldn't contain port. Default: 'http://localhost'")
+	serverPingCmd.Flags().DurationVarP(&timeout, "timeout", "t", 15*time.Second, "max request time to make a request. Default: '15 seconds'")
+	serverPingCmd.Flags().StringVar(&caFile, "ca", "", "file with PEM encoded CA certificates to verify https server. System CAs are used by default")
+	serverPingCmd.Flags().StringVar(&clientCert, "client-cert", "", "file with PEM encoded client certificate for server with mutual TLS")
+	serverPingCmd.Flags().StringVar(&clientKey, "client-key", "", "file with PEM encoded key of client certificate")
+	return serverPingCmd
+}
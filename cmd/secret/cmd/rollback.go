@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/go-itools-internship/go-secret/pkg/provider"
+)
+
+func (r *root) rollbackCmd() *cobra.Command {
+	var key string
+	var cipherKey string
+	var version int
+	var sf storageFlags
+	var cf cryptoFlags
+	var rollbackCmd = &cobra.Command{
+		Use:   "rollback",
+		Short: "Restore previous version of the key",
+		Long:  "it takes key, cipher key and version from user and saves value of the version as the new latest version",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger := r.logger.Named("rollback-cmd")
+			logger.Info("Start")
+			if version <= 0 {
+				return fmt.Errorf("version should be positive, got %d", version)
+			}
+			ds, closeFn, err := r.openDataSaver(cmd.Context(), sf, cipherKey, cf, logger)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			pr, err := provider.Open(ds, []byte(cipherKey), cf.options()...)
+			if err != nil {
+				return fmt.Errorf("can't open storage: %w", err)
+			}
+			logger.Infof("prepare rollback key %s to version %d", key, version)
+			if err := pr.RollbackCtx(cmd.Context(), []byte(key), version); err != nil {
+				return fmt.Errorf("can't rollback key: %w", err)
+			}
+			logger.Infof("ready rollback key %s to version %d", key, version)
+			return nil
+		},
+	}
+	rollbackCmd.Flags().StringVarP(&key, "key", "k", key, "key for pair key-value")
+	rollbackCmd.Flags().StringVarP(&cipherKey, "cipher-key", "c", cipherKey, "cipher key for data encryption and decryption")
+	rollbackCmd.Flags().IntVar(&version, "version", 0, "number of the version to restore, see history command")
+	sf.register(rollbackCmd)
+	cf.register(rollbackCmd)
+
+	return rollbackCmd
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+)
+
+func TestCryptographerCache(t *testing.T) {
+	cache, err := newCryptographerCache(crypto.LegacyParams(), 2, make(chan struct{}, 1))
+	require.NoError(t, err)
+	get := func(passphrase string) interface{} {
+		cr, err := cache.get(passphrase)
+		require.NoError(t, err)
+		return cr
+	}
+
+	a, b := get("a"), get("b")
+	require.True(t, a == get("a"), "cached cryptographer should be returned")
+	get("c")
+	require.EqualValues(t, 2, cache.order.Len())
+	require.True(t, a == get("a"), "recently used cryptographer should be kept")
+	require.False(t, b == get("b"), "least recently used cryptographer should be evicted")
+
+	_, ok := cache.items[sha256.Sum256([]byte("a"))]
+	require.False(t, ok, "plain hash of passphrase shouldn't be the cache key")
+}
+
+func TestCryptographerCache_Derivations(t *testing.T) {
+	derivations := make(chan struct{}, 1)
+	cache, err := newCryptographerCache(crypto.LegacyParams(), 2, derivations)
+	require.NoError(t, err)
+	_, err = cache.get("a")
+	require.NoError(t, err)
+	require.Len(t, derivations, 0, "semaphore should be released after derivation")
+
+	// semaphore is full, as if another key is derived
+	derivations <- struct{}{}
+	_, err = cache.get("a")
+	require.NoError(t, err, "cached cryptographer shouldn't wait for semaphore")
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := cache.get("b")
+		done <- err
+	}()
+	select {
+	case <-done:
+		t.Fatal("new key shouldn't be derived while semaphore is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	<-derivations
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("new key should be derived when semaphore has room")
+	}
+	require.Len(t, derivations, 0)
+}
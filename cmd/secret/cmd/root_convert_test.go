@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-itools-internship/go-secret/pkg/io/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoot_Convert(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		defer func() {
+			require.NoError(t, os.Remove(path))
+		}()
+
+		r := New()
+		r.cmd.SetArgs([]string{"set", "--key", key, "--value", "test value", "--cipher-key", "ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+
+		r.cmd.SetArgs([]string{"convert", "--to", "sealed", "--cipher-key", "ck", "--path", path, "--kdf-cost", "4"})
+		require.NoError(t, r.Execute(ctx))
+		sealed, err := storage.IsSealedFile(path)
+		require.NoError(t, err)
+		require.True(t, sealed)
+
+		var b bytes.Buffer
+		r.cmd.SetOut(&b)
+		r.cmd.SetArgs([]string{"get", "--key", key, "--cipher-key", "ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+		require.EqualValues(t, "test value\n", b.String())
+
+		r.cmd.SetArgs([]string{"convert", "--to", "plain", "--cipher-key", "ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+		sealed, err = storage.IsSealedFile(path)
+		require.NoError(t, err)
+		require.False(t, sealed)
+
+		// repeated flags of the same command append values, so the next get is done by new command
+		b.Reset()
+		r = New()
+		r.cmd.SetOut(&b)
+		r.cmd.SetArgs([]string{"get", "--key", key, "--cipher-key", "ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+		require.EqualValues(t, "test value\n", b.String())
+	})
+	t.Run("error if layout is unsupported", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		r := New()
+		r.cmd.SetArgs([]string{"convert", "--to", "zip", "--cipher-key", "ck", "--path", path})
+		err := r.Execute(ctx)
+		require.Error(t, err)
+		require.EqualValues(t, `unsupported layout "zip"`, err.Error())
+	})
+}
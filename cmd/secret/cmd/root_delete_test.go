@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoot_Delete(t *testing.T) {
+	t.Run("success with force", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		defer func() {
+			require.NoError(t, os.Remove(path))
+		}()
+
+		r := New()
+		r.cmd.SetArgs([]string{"set", "--key", key, "--value", "test value", "--cipher-key", "ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+
+		r.cmd.SetArgs([]string{"delete", "--key", key, "--cipher-key", "ck", "--path", path, "--force"})
+		require.NoError(t, r.Execute(ctx))
+
+		r.cmd.SetArgs([]string{"get", "--key", key, "--cipher-key", "ck", "--path", path})
+		err := r.Execute(ctx)
+		require.Error(t, err)
+	})
+	t.Run("success after confirmation", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		defer func() {
+			require.NoError(t, os.Remove(path))
+		}()
+
+		r := New()
+		r.cmd.SetArgs([]string{"set", "--key", key, "--value", "test value", "--cipher-key", "ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+
+		var b bytes.Buffer
+		r.cmd.SetOut(&b)
+		r.cmd.SetIn(bytes.NewBufferString("y\n"))
+		r.cmd.SetArgs([]string{"delete", "--key", key, "--cipher-key", "ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+		require.EqualValues(t, `Delete key "key-value"? [y/N]: `, b.String())
+
+		r.cmd.SetArgs([]string{"list", "--cipher-key", "ck", "--path", path})
+		b.Reset()
+		require.NoError(t, r.Execute(ctx))
+		require.Empty(t, b.String())
+	})
+	t.Run("canceled without confirmation", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		defer func() {
+			require.NoError(t, os.Remove(path))
+		}()
+
+		r := New()
+		r.cmd.SetArgs([]string{"set", "--key", key, "--value", "test value", "--cipher-key", "ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+
+		var b bytes.Buffer
+		r.cmd.SetOut(&b)
+		r.cmd.SetIn(bytes.NewBufferString("n\n"))
+		r.cmd.SetArgs([]string{"delete", "--key", key, "--cipher-key", "ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+		require.Contains(t, b.String(), "Deletion canceled")
+
+		b.Reset()
+		r.cmd.SetArgs([]string{"get", "--key", key, "--cipher-key", "ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+		require.EqualValues(t, "test value\n", b.String())
+	})
+	t.Run("error if key not found", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		defer func() {
+			require.NoError(t, os.Remove(path))
+		}()
+
+		r := New()
+		r.cmd.SetArgs([]string{"delete", "--key", key, "--cipher-key", "ck", "--path", path, "--force"})
+		err := r.Execute(ctx)
+		require.Error(t, err)
+		require.EqualValues(t, "can't delete data by key: provider, DeleteData method: delete error: filevault: cannot delete data: key not found", err.Error())
+	})
+}
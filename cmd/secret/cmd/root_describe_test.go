@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	secretApi "github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func TestRoot_Describe(t *testing.T) {
+	run := func(ctx context.Context, t *testing.T, args ...string) (string, error) {
+		var b bytes.Buffer
+		r := New()
+		r.cmd.SetOut(&b)
+		r.cmd.SetArgs(append(args, "--cipher-key", "ck", "--path", path))
+		err := r.Execute(ctx)
+		return b.String(), err
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		defer func() {
+			require.NoError(t, os.Remove(path))
+		}()
+
+		_, err := run(ctx, t, "set", "--key", "db-password", "--value", "value", "--label", "env=prod,team=a", "--owner", "team-a")
+		require.NoError(t, err)
+		_, err = run(ctx, t, "set", "--key", "api-token", "--value", "value", "--label", "env=dev")
+		require.NoError(t, err)
+		// metadata is kept if flags aren't set, only changed fields are replaced
+		_, err = run(ctx, t, "set", "--key", "db-password", "--value", "new value", "--description", "database password")
+		require.NoError(t, err)
+
+		out, err := run(ctx, t, "describe", "--key", "db-password")
+		require.NoError(t, err)
+		var md secretApi.Metadata
+		require.NoError(t, json.Unmarshal([]byte(out), &md))
+		require.EqualValues(t, map[string]string{"env": "prod", "team": "a"}, md.Labels)
+		require.EqualValues(t, "database password", md.Description)
+		require.EqualValues(t, "team-a", md.Owner)
+		require.False(t, md.CreatedAt.IsZero())
+
+		out, err = run(ctx, t, "list", "--label", "env=prod")
+		require.NoError(t, err)
+		require.EqualValues(t, "db-password\n", out)
+		out, err = run(ctx, t, "list", "--label", "env", "--label", "team")
+		require.NoError(t, err)
+		require.EqualValues(t, "db-password\n", out)
+		out, err = run(ctx, t, "list", "--label", "env")
+		require.NoError(t, err)
+		require.EqualValues(t, "api-token\ndb-password\n", out)
+	})
+	t.Run("value isn't changed if metadata can't be saved", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		// log storage doesn't keep metadata
+		storageURL := "log://" + filepath.Join(t.TempDir(), "secrets.log")
+		runLog := func(args ...string) (string, error) {
+			var b bytes.Buffer
+			r := New()
+			r.cmd.SetOut(&b)
+			r.cmd.SetArgs(append(args, "--cipher-key", "ck", "--path", storageURL))
+			err := r.Execute(ctx)
+			return b.String(), err
+		}
+
+		_, err := runLog("set", "--key", "db-password", "--value", "value")
+		require.NoError(t, err)
+		_, err = runLog("set", "--key", "db-password", "--value", "new value", "--owner", "team-a")
+		require.Error(t, err)
+		require.True(t, errors.Is(err, secretApi.ErrMetadataNotSupported), err.Error())
+		out, err := runLog("get", "--key", "db-password")
+		require.NoError(t, err)
+		require.EqualValues(t, "value\n", out)
+	})
+	t.Run("error if label name is empty", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		_, err := run(ctx, t, "list", "--label", "=prod")
+		require.Error(t, err)
+		require.EqualValues(t, "label name can't be empty", err.Error())
+	})
+}
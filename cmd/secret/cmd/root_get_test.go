@@ -4,16 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"testing"
 	"time"
 
+	secretApi "github.com/go-itools-internship/go-secret/pkg/secret"
 	"github.com/stretchr/testify/require"
 )
 
 func TestRoot_Get(t *testing.T) {
-	value := "2Tvspu/QQhsxTAgQah+xcC3VhifWUjlZHKJYgYIabyiTK4Bx6Zo="
 	t.Run("success", func(t *testing.T) {
 		file, err := os.Create(path)
 		require.NoError(t, err)
@@ -32,9 +33,13 @@ func TestRoot_Get(t *testing.T) {
 			require.NoError(t, file.Close())
 		}()
 
+		var b bytes.Buffer
+		r.cmd.SetOut(&b)
+
 		r.cmd.SetArgs([]string{"get", "--key", key, "--cipher-key", "ck", "--path", path})
 		executeErr := r.Execute(ctx)
 		require.NoError(t, executeErr)
+		require.EqualValues(t, "test value\n", b.String())
 
 		testFile, err := os.Open(path)
 		require.NoError(t, err)
@@ -45,13 +50,17 @@ func TestRoot_Get(t *testing.T) {
 
 		fileData := make(map[string]string)
 		require.NoError(t, json.NewDecoder(testFile).Decode(&fileData))
+		delete(fileData, storageHeaderKey)
+		delete(fileData, storageVersionsKey)
+		delete(fileData, storageMetadataKey)
 		var got string
 		require.Len(t, fileData, 1)
 		for _, value := range fileData {
 			got = value
 			break // we iterate one time to get first value
 		}
-		require.EqualValues(t, value, got)
+		require.NotEmpty(t, got)
+		require.NotContains(t, got, "test value")
 	})
 	t.Run("error after get file command with wrong ck", func(t *testing.T) {
 		file, err := os.Create(path)
@@ -78,7 +87,8 @@ func TestRoot_Get(t *testing.T) {
 		err = r.Execute(ctx)
 		require.Error(t, err)
 		out := b.String()
-		require.EqualValues(t, "can't get data by key: provider, GetData method: read data error: filevault: cannot read data: not found", err.Error())
+		require.True(t, errors.Is(err, secretApi.ErrDecrypt))
+		require.EqualValues(t, "can't get data by key: provider, GetData method: read data error: can't decrypt data: no data is encrypted with the cipher key", err.Error())
 		require.Empty(t, out)
 	})
 	t.Run("success after get redis command", func(t *testing.T) {
@@ -143,9 +153,9 @@ func TestRoot_Get(t *testing.T) {
 
 		r.cmd.SetArgs([]string{"get", "--key", key, "--cipher-key", "wrong-ck", "--redis-url", redisURL})
 		err := r.Execute(ctx)
-		require.NoError(t, err)
-		out := b.String()
-		require.EqualValues(t, "\n", out)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, secretApi.ErrNotFound))
+		require.Empty(t, b.String())
 	})
 	t.Run("error after get postgres command with wrong ck", func(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
@@ -175,3 +185,43 @@ func TestRoot_Get(t *testing.T) {
 		require.EqualValues(t, "can't get data by key: provider, GetData method: read data error: postgres: key not found", err.Error())
 	})
 }
+
+func TestRoot_GetBatch(t *testing.T) {
+	run := func(ctx context.Context, t *testing.T, args ...string) (string, error) {
+		var b bytes.Buffer
+		r := New()
+		r.cmd.SetOut(&b)
+		r.cmd.SetArgs(append(args, "--cipher-key", "ck", "--path", path))
+		err := r.Execute(ctx)
+		return b.String(), err
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		defer func() {
+			require.NoError(t, os.Remove(path))
+		}()
+		for _, k := range []string{"first", "second"} {
+			_, err := run(ctx, t, "set", "--key", k, "--value", k+" value")
+			require.NoError(t, err)
+		}
+
+		out, err := run(ctx, t, "get", "-k", "second", "-k", "first")
+		require.NoError(t, err)
+		require.EqualValues(t, "second\tsecond value\nfirst\tfirst value\n", out)
+
+		out, err = run(ctx, t, "get", "-k", "second", "-k", "missing")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "can't get data by key missing")
+		require.Empty(t, out)
+	})
+	t.Run("error if version is set for several keys", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		_, err := run(ctx, t, "get", "-k", "first", "-k", "second", "--version", "1")
+		require.Error(t, err)
+		require.EqualValues(t, "version can be used only with single key", err.Error())
+	})
+}
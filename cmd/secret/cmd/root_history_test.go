@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoot_History(t *testing.T) {
+	run := func(ctx context.Context, t *testing.T, args ...string) (string, error) {
+		var b bytes.Buffer
+		r := New()
+		r.cmd.SetOut(&b)
+		r.cmd.SetArgs(append(args, "--cipher-key", "ck", "--path", path))
+		err := r.Execute(ctx)
+		return b.String(), err
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		defer func() {
+			require.NoError(t, os.Remove(path))
+		}()
+
+		for _, v := range []string{"value 1", "value 2", "value 3"} {
+			_, err := run(ctx, t, "set", "--key", key, "--value", v)
+			require.NoError(t, err)
+		}
+
+		out, err := run(ctx, t, "history", "--key", key)
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		require.Len(t, lines, 3)
+		require.True(t, strings.HasPrefix(lines[0], "1\t"))
+		require.True(t, strings.HasPrefix(lines[2], "3\t"))
+
+		out, err = run(ctx, t, "get", "--key", key, "--version", "1")
+		require.NoError(t, err)
+		require.EqualValues(t, "value 1\n", out)
+
+		_, err = run(ctx, t, "rollback", "--key", key, "--version", "1")
+		require.NoError(t, err)
+		out, err = run(ctx, t, "get", "--key", key)
+		require.NoError(t, err)
+		require.EqualValues(t, "value 1\n", out)
+
+		out, err = run(ctx, t, "history", "--key", key, "--output", "json")
+		require.NoError(t, err)
+		var versions []struct {
+			Version int `json:"version"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &versions))
+		require.Len(t, versions, 4)
+		require.EqualValues(t, 4, versions[3].Version)
+	})
+	t.Run("retention", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		defer func() {
+			require.NoError(t, os.Remove(path))
+		}()
+
+		for _, v := range []string{"value 1", "value 2", "value 3"} {
+			_, err := run(ctx, t, "set", "--key", key, "--value", v, "--max-versions", "2")
+			require.NoError(t, err)
+		}
+		out, err := run(ctx, t, "history", "--key", key)
+		require.NoError(t, err)
+		require.Len(t, strings.Split(strings.TrimSpace(out), "\n"), 2)
+
+		_, err = run(ctx, t, "rollback", "--key", key, "--version", "1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+	})
+	t.Run("error if version isn't positive", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		_, err := run(ctx, t, "rollback", "--key", key)
+		require.Error(t, err)
+		require.EqualValues(t, "version should be positive, got 0", err.Error())
+	})
+}
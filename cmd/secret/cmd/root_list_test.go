@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoot_List(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		defer func() {
+			require.NoError(t, os.Remove(path))
+		}()
+
+		r := New()
+		for _, k := range []string{"second-key", "first-key"} {
+			r.cmd.SetArgs([]string{"set", "--key", k, "--value", "test value", "--cipher-key", "ck", "--path", path})
+			require.NoError(t, r.Execute(ctx))
+		}
+		r.cmd.SetArgs([]string{"set", "--key", "other-key", "--value", "test value", "--cipher-key", "other-ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+
+		var b bytes.Buffer
+		r.cmd.SetOut(&b)
+		r.cmd.SetArgs([]string{"list", "--cipher-key", "ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+		require.EqualValues(t, "first-key\nsecond-key\n", b.String())
+	})
+	t.Run("success with prefix and json output", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		defer func() {
+			require.NoError(t, os.Remove(path))
+		}()
+
+		r := New()
+		for _, k := range []string{"db-password", "db-user", "api-token"} {
+			r.cmd.SetArgs([]string{"set", "--key", k, "--value", "test value", "--cipher-key", "ck", "--path", path})
+			require.NoError(t, r.Execute(ctx))
+		}
+
+		var b bytes.Buffer
+		r.cmd.SetOut(&b)
+		r.cmd.SetArgs([]string{"list", "--cipher-key", "ck", "--path", path, "--prefix", "db-", "--output", "json"})
+		require.NoError(t, r.Execute(ctx))
+		require.EqualValues(t, `["db-password","db-user"]`+"\n", b.String())
+	})
+	t.Run("error if output format is unsupported", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		r := New()
+		r.cmd.SetArgs([]string{"list", "--cipher-key", "ck", "--path", path, "--output", "xml"})
+		err := r.Execute(ctx)
+		require.Error(t, err)
+		require.EqualValues(t, `unsupported output format "xml"`, err.Error())
+	})
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/io/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoot_Migrate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		defer func() {
+			require.NoError(t, os.Remove(path))
+		}()
+
+		// save entry the way previous versions did: key and value encrypted with nonce from hashed cipher key
+		ds, err := storage.NewFileVault(path)
+		require.NoError(t, err)
+		nonce := sha256.Sum256([]byte("ck"))
+		legacy := crypto.NewCryptographer([]byte("ck"), crypto.LoopReader(nonce[:]))
+		encodedKey, err := legacy.Encode([]byte(key))
+		require.NoError(t, err)
+		encodedValue, err := legacy.Encode([]byte("test value"))
+		require.NoError(t, err)
+		require.NoError(t, ds.SaveData(encodedKey, encodedValue))
+
+		r := New()
+		var b bytes.Buffer
+		r.cmd.SetOut(&b)
+		r.cmd.SetArgs([]string{"migrate", "--cipher-key", "ck", "--path", path, "--legacy-kdf"})
+		require.NoError(t, r.Execute(ctx))
+		require.EqualValues(t, "Migrated 1 entries\n", b.String())
+
+		b.Reset()
+		r.cmd.SetArgs([]string{"get", "--key", key, "--cipher-key", "ck", "--path", path, "--legacy-kdf"})
+		require.NoError(t, r.Execute(ctx))
+		require.EqualValues(t, "test value\n", b.String())
+
+		b.Reset()
+		r.cmd.SetArgs([]string{"migrate", "--cipher-key", "ck", "--path", path, "--legacy-kdf"})
+		require.NoError(t, r.Execute(ctx))
+		require.EqualValues(t, "Migrated 0 entries\n", b.String())
+	})
+	t.Run("error without legacy kdf", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		defer func() {
+			require.NoError(t, os.Remove(path))
+		}()
+
+		ds, err := storage.NewFileVault(path)
+		require.NoError(t, err)
+		require.NoError(t, ds.SaveData([]byte("legacy key"), []byte("legacy value")))
+
+		r := New()
+		r.cmd.SetArgs([]string{"migrate", "--cipher-key", "ck", "--path", path})
+		err = r.Execute(ctx)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "use legacy kdf to open vault created by previous version")
+	})
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	secretApi "github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func TestRoot_Rotate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		defer func() {
+			require.NoError(t, os.Remove(path))
+		}()
+
+		r := New()
+		r.cmd.SetArgs([]string{"set", "--key", key, "--value", "test value", "--cipher-key", "old-ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+
+		var b bytes.Buffer
+		r.cmd.SetOut(&b)
+		r.cmd.SetArgs([]string{"rotate", "--old-cipher-key", "old-ck", "--new-cipher-key", "new-ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+		require.EqualValues(t, "Rotated 1 entries\n", b.String())
+
+		b.Reset()
+		r.cmd.SetArgs([]string{"get", "--key", key, "--cipher-key", "new-ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+		require.EqualValues(t, "test value\n", b.String())
+
+		b.Reset()
+		r.cmd.SetArgs([]string{"list", "--cipher-key", "old-ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+		require.Empty(t, b.String())
+	})
+	t.Run("versions are kept", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		defer func() {
+			require.NoError(t, os.Remove(path))
+		}()
+
+		r := New()
+		for _, v := range []string{"value 1", "value 2"} {
+			r.cmd.SetArgs([]string{"set", "--key", key, "--value", v, "--cipher-key", "old-ck", "--path", path})
+			require.NoError(t, r.Execute(ctx))
+		}
+		var b bytes.Buffer
+		r.cmd.SetOut(&b)
+		r.cmd.SetArgs([]string{"history", "--key", key, "--cipher-key", "old-ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+		history := b.String()
+
+		r.cmd.SetArgs([]string{"rotate", "--old-cipher-key", "old-ck", "--new-cipher-key", "new-ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+
+		b.Reset()
+		r.cmd.SetArgs([]string{"history", "--key", key, "--cipher-key", "new-ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+		require.EqualValues(t, history, b.String())
+
+		b.Reset()
+		r.cmd.SetArgs([]string{"get", "--key", key, "--version", "1", "--cipher-key", "new-ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+		require.EqualValues(t, "value 1\n", b.String())
+	})
+	t.Run("sealed file", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		sealedPath := filepath.Join(t.TempDir(), "sealed.json")
+
+		r := New()
+		r.cmd.SetArgs([]string{"set", "--key", key, "--value", "test value", "--cipher-key", "old-ck", "--path", sealedPath, "--sealed", "--kdf-cost", "4"})
+		require.NoError(t, r.Execute(ctx))
+
+		var b bytes.Buffer
+		r.cmd.SetOut(&b)
+		r.cmd.SetArgs([]string{"rotate", "--old-cipher-key", "old-ck", "--new-cipher-key", "new-ck", "--path", sealedPath})
+		require.NoError(t, r.Execute(ctx))
+		require.EqualValues(t, "Rotated 1 entries\n", b.String())
+
+		b.Reset()
+		r.cmd.SetArgs([]string{"get", "--key", key, "--cipher-key", "new-ck", "--path", sealedPath})
+		require.NoError(t, r.Execute(ctx))
+		require.EqualValues(t, "test value\n", b.String())
+
+		r.cmd.SetArgs([]string{"get", "--key", key, "--cipher-key", "old-ck", "--path", sealedPath})
+		require.True(t, errors.Is(r.Execute(ctx), secretApi.ErrDecrypt))
+
+		// repeated rotation opens the file resealed with the new cipher key
+		b.Reset()
+		r.cmd.SetArgs([]string{"rotate", "--old-cipher-key", "old-ck", "--new-cipher-key", "new-ck", "--path", sealedPath})
+		require.NoError(t, r.Execute(ctx))
+		require.EqualValues(t, "Rotated 0 entries\n", b.String())
+	})
+	t.Run("error without new cipher key", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		r := New()
+		r.cmd.SetArgs([]string{"rotate", "--old-cipher-key", "old-ck", "--path", path})
+		err := r.Execute(ctx)
+		require.Error(t, err)
+		require.EqualValues(t, "old and new cipher keys should be set", err.Error())
+	})
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	api "github.com/go-itools-internship/go-secret/pkg/http"
+)
+
+func TestRoot_ServerAuth(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	defer func() {
+		require.NoError(t, os.Remove(path))
+	}()
+	dir := t.TempDir()
+	tokens := filepath.Join(dir, "tokens")
+	require.NoError(t, ioutil.WriteFile(tokens, []byte("team-a token-a\nteam-b token-b\n"), 0600))
+	policy := filepath.Join(dir, "policy.yaml")
+	require.NoError(t, ioutil.WriteFile(policy, []byte("identities:\n  team-a:\n    - prefix: team-a/\n      actions: [read, write]\n"), 0600))
+
+	port, err := GetFreePort()
+	require.NoError(t, err)
+	r := New()
+	r.cmd.SetArgs([]string{"server", "--path", path, "--port", strconv.Itoa(port), "--auth-tokens", tokens, "--auth-policy", policy})
+	go func() {
+		if err := r.Execute(ctx); err != nil {
+			fmt.Println(err)
+		}
+	}()
+	time.Sleep(2 * time.Second)
+
+	client := http.Client{Timeout: time.Second}
+	do := func(method, route, token string) int {
+		req, err := http.NewRequest(method, fmt.Sprintf("http://localhost:%d%s", port, route), bytes.NewBufferString(`{"value":"test-value-1"}`))
+		require.NoError(t, err)
+		req.Header.Set(api.ParamCipherKey, expectedSipherKey)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp.StatusCode
+	}
+
+	require.EqualValues(t, http.StatusOK, do(http.MethodGet, "/ping", ""))
+	require.EqualValues(t, http.StatusUnauthorized, do(http.MethodPut, "/v1/secrets/local/team-a/key", ""))
+	require.EqualValues(t, http.StatusNoContent, do(http.MethodPut, "/v1/secrets/local/team-a/key", "token-a"))
+	require.EqualValues(t, http.StatusOK, do(http.MethodGet, "/v1/secrets/local/team-a/key", "token-a"))
+	require.EqualValues(t, http.StatusForbidden, do(http.MethodGet, "/v1/secrets/local/team-a/key", "token-b"))
+	require.EqualValues(t, http.StatusForbidden, do(http.MethodDelete, "/v1/secrets/local/team-a/key", "token-a"))
+	require.EqualValues(t, http.StatusUnauthorized, do(http.MethodGet, "/?key=team-a%2Fkey&method=local", ""))
+	require.EqualValues(t, http.StatusOK, do(http.MethodGet, "/?key=team-a%2Fkey&method=local", "token-a"))
+}
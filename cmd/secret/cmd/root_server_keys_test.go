@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	api "github.com/go-itools-internship/go-secret/pkg/http"
+)
+
+func TestRoot_ServerKeys(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	dir := t.TempDir()
+	keys := filepath.Join(dir, "keys")
+	require.NoError(t, ioutil.WriteFile(keys, []byte("payments payments passphrase\nbilling billing passphrase\n"), 0600))
+	storagePath := filepath.Join(dir, "file.txt")
+	tokens := filepath.Join(dir, "tokens")
+	require.NoError(t, ioutil.WriteFile(tokens, []byte("team-a token-a\nteam-b token-b\n"), 0600))
+	policy := filepath.Join(dir, "policy.yaml")
+	require.NoError(t, ioutil.WriteFile(policy, []byte(`
+identities:
+  team-a:
+    - prefix: ""
+      actions: [read, write]
+  team-b:
+    - prefix: ""
+      actions: [read, write]
+keys:
+  team-a: [payments, billing, unknown]
+  team-b: [billing]
+`), 0600))
+
+	t.Run("error when server keys are used without authentication", func(t *testing.T) {
+		r := New()
+		r.cmd.SetArgs([]string{"server", "--path", storagePath, "--key-file", keys})
+		err := r.Execute(ctx)
+		require.EqualError(t, err, "server keys require at least one authentication method")
+	})
+
+	port, err := GetFreePort()
+	require.NoError(t, err)
+	r := New()
+	r.cmd.SetArgs([]string{"server", "--path", storagePath, "--port", strconv.Itoa(port), "--key-file", keys, "--default-key-id", "payments", "--auth-tokens", tokens, "--auth-policy", policy})
+	go func() {
+		if err := r.Execute(ctx); err != nil {
+			fmt.Println(err)
+		}
+	}()
+	time.Sleep(2 * time.Second)
+
+	client := http.Client{Timeout: time.Second}
+	doAs := func(token, method, route string, header http.Header, body string) (int, string) {
+		req, err := http.NewRequest(method, fmt.Sprintf("http://localhost:%d%s", port, route), bytes.NewBufferString(body))
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, resp.Body.Close())
+		}()
+		respBody, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(respBody)
+	}
+	do := func(method, route string, header http.Header, body string) (int, string) {
+		return doAs("token-a", method, route, header, body)
+	}
+	keyID := func(id string) http.Header {
+		return http.Header{http.CanonicalHeaderKey(api.ParamKeyID): []string{id}}
+	}
+
+	status, _ := do(http.MethodPut, "/v1/secrets/local/key", nil, `{"value":"test-value-1"}`)
+	require.EqualValues(t, http.StatusNoContent, status)
+
+	status, body := do(http.MethodGet, "/v1/secrets/local/key", keyID("payments"), "")
+	require.EqualValues(t, http.StatusOK, status)
+	var getBody struct {
+		Value string `json:"value"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &getBody))
+	require.EqualValues(t, "test-value-1", getBody.Value)
+
+	// data encrypted with another server key can't be read
+	status, _ = do(http.MethodGet, "/v1/secrets/local/key", keyID("billing"), "")
+	require.EqualValues(t, http.StatusUnauthorized, status)
+
+	status, _ = do(http.MethodGet, "/v1/secrets/local/key", keyID("unknown"), "")
+	require.EqualValues(t, http.StatusBadRequest, status)
+
+	status, _ = do(http.MethodGet, "/v1/secrets/local/key", http.Header{http.CanonicalHeaderKey(api.ParamCipherKey): []string{"payments passphrase"}}, "")
+	require.EqualValues(t, http.StatusBadRequest, status)
+
+	// server keys are used only by authenticated callers with granted key ids
+	status, _ = doAs("", http.MethodGet, "/v1/secrets/local/key", nil, "")
+	require.EqualValues(t, http.StatusUnauthorized, status)
+	status, _ = doAs("token-b", http.MethodGet, "/v1/secrets/local/key", keyID("payments"), "")
+	require.EqualValues(t, http.StatusForbidden, status)
+	status, _ = doAs("token-b", http.MethodGet, "/v1/secrets/local/key", nil, "")
+	require.EqualValues(t, http.StatusForbidden, status)
+}
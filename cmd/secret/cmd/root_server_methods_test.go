@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	api "github.com/go-itools-internship/go-secret/pkg/http"
+	"github.com/go-itools-internship/go-secret/pkg/io/storage"
+)
+
+func TestRoot_ServerMethods(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	dir := t.TempDir()
+	tokens := filepath.Join(dir, "tokens")
+	require.NoError(t, ioutil.WriteFile(tokens, []byte("team-a token-a\n"), 0600))
+	policy := filepath.Join(dir, "policy.yaml")
+	require.NoError(t, ioutil.WriteFile(policy, []byte("identities:\n  team-a:\n    - prefix: db/\n      actions: [read, write, list]\n"), 0600))
+	shared := filepath.Join(dir, "shared.json")
+	config := filepath.Join(dir, "config.yaml")
+	require.NoError(t, ioutil.WriteFile(config, []byte(fmt.Sprintf(`methods:
+  team-a-file:
+    url: file://%[1]s
+    prefix: team-a/
+    policy: %[2]s
+  shared-file:
+    url: file://%[1]s
+  ci-file:
+    url: file://%[3]s
+    max_versions: 1
+`, shared, policy, filepath.Join(dir, "ci.json"))), 0600))
+
+	port, err := GetFreePort()
+	require.NoError(t, err)
+	r := New()
+	r.cmd.SetArgs([]string{"server", "--port", strconv.Itoa(port), "--config", config, "--auth-tokens", tokens})
+	go func() {
+		if err := r.Execute(ctx); err != nil {
+			fmt.Println(err)
+		}
+	}()
+	time.Sleep(2 * time.Second)
+
+	client := http.Client{Timeout: time.Second}
+	do := func(method, route, body string) (int, string) {
+		req, err := http.NewRequest(method, fmt.Sprintf("http://localhost:%d%s", port, route), bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set(api.ParamCipherKey, "ck")
+		req.Header.Set("Authorization", "Bearer token-a")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, resp.Body.Close())
+		}()
+		respBody, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(respBody)
+	}
+
+	status, body := do(http.MethodGet, api.MethodsRoute, "")
+	require.EqualValues(t, http.StatusOK, status)
+	require.JSONEq(t, `{"methods":["ci-file","shared-file","team-a-file"]}`, body)
+
+	status, _ = do(http.MethodPut, "/v1/secrets/team-a-file/db/password", `{"value":"test-value-1"}`)
+	require.EqualValues(t, http.StatusNoContent, status)
+	status, _ = do(http.MethodPut, "/v1/secrets/team-a-file/other", `{"value":"test-value-1"}`)
+	require.EqualValues(t, http.StatusForbidden, status)
+
+	// method without policy sees keys of other methods in the same storage with their prefixes
+	status, body = do(http.MethodGet, "/v1/secrets/shared-file", "")
+	require.EqualValues(t, http.StatusOK, status)
+	require.JSONEq(t, `{"keys":["team-a/db/password"]}`, body)
+
+	status, _ = do(http.MethodGet, "/v1/secrets/local/key", "")
+	require.EqualValues(t, http.StatusNotFound, status)
+	_, err = os.Stat("file.txt")
+	require.True(t, os.IsNotExist(err), "local method shouldn't be created without path flag")
+}
+
+func TestRoot_ServerMemory(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	port, err := GetFreePort()
+	require.NoError(t, err)
+	r := New()
+	r.cmd.SetArgs([]string{"server", "--port", strconv.Itoa(port), "--memory"})
+	go func() {
+		if err := r.Execute(ctx); err != nil {
+			fmt.Println(err)
+		}
+	}()
+	time.Sleep(2 * time.Second)
+
+	client := http.Client{Timeout: time.Second}
+	do := func(method, route, body string) (int, string) {
+		req, err := http.NewRequest(method, fmt.Sprintf("http://localhost:%d%s", port, route), bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set(api.ParamCipherKey, "ck")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, resp.Body.Close())
+		}()
+		respBody, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(respBody)
+	}
+
+	status, body := do(http.MethodGet, api.MethodsRoute, "")
+	require.EqualValues(t, http.StatusOK, status)
+	require.JSONEq(t, `{"methods":["memory"]}`, body)
+
+	status, _ = do(http.MethodPut, "/v1/secrets/memory/db/password", `{"value":"test-value-1"}`)
+	require.EqualValues(t, http.StatusNoContent, status)
+	status, body = do(http.MethodGet, "/v1/secrets/memory/db/password", "")
+	require.EqualValues(t, http.StatusOK, status)
+	require.Contains(t, body, "test-value-1")
+	_, err = os.Stat("file.txt")
+	require.True(t, os.IsNotExist(err), "local method shouldn't be created with memory flag")
+}
+
+func TestRoot_ServerSealed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sealed.json")
+	r := New()
+	r.cmd.SetArgs([]string{"set", "--key", "key", "--value", "value", "--cipher-key", "ck", "--path", path, "--sealed", "--kdf-cost", "4"})
+	require.NoError(t, r.Execute(ctx))
+
+	t.Run("error if sealed file is served", func(t *testing.T) {
+		port, err := GetFreePort()
+		require.NoError(t, err)
+		r := New()
+		r.cmd.SetArgs([]string{"server", "--port", strconv.Itoa(port), "--path", path})
+		err = r.Execute(ctx)
+		require.True(t, errors.Is(err, storage.ErrPassphraseRequired))
+		require.Contains(t, err.Error(), "method local: sealed file can't be served")
+	})
+	t.Run("error if method is configured sealed", func(t *testing.T) {
+		config := filepath.Join(dir, "config.yaml")
+		require.NoError(t, ioutil.WriteFile(config, []byte(fmt.Sprintf("methods:\n  vault:\n    url: file://%s\n    sealed: true\n", filepath.Join(dir, "new.json"))), 0600))
+		port, err := GetFreePort()
+		require.NoError(t, err)
+		r := New()
+		r.cmd.SetArgs([]string{"server", "--port", strconv.Itoa(port), "--config", config})
+		err = r.Execute(ctx)
+		require.True(t, errors.Is(err, storage.ErrPassphraseRequired))
+		require.Contains(t, err.Error(), "method vault: sealed file can't be served")
+		_, err = os.Stat(filepath.Join(dir, "new.json"))
+		require.True(t, os.IsNotExist(err), "sealed file shouldn't be created with empty passphrase")
+	})
+}
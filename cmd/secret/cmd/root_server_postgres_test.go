@@ -165,7 +165,7 @@ func TestRoot_Server_Postgres(t *testing.T) {
 			require.Contains(t, string(data), "cannot get data by key")
 			require.NoError(t, resp.Body.Close())
 		})
-		t.Run("expect status 500 if set local method and try get by remote postgres method", func(t *testing.T) {
+		t.Run("expect status 404 if set local method and try get by remote postgres method", func(t *testing.T) {
 			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 			defer cancel()
 			defer func() {
@@ -211,7 +211,7 @@ func TestRoot_Server_Postgres(t *testing.T) {
 
 			resp, err = client.Do(req)
 			require.NoError(t, err)
-			require.EqualValues(t, http.StatusInternalServerError, resp.StatusCode)
+			require.EqualValues(t, http.StatusNotFound, resp.StatusCode)
 			require.NoError(t, resp.Body.Close())
 		})
 		t.Run("expect postgres get method error if wrong cipher key", func(t *testing.T) {
@@ -267,7 +267,7 @@ func TestRoot_Server_Postgres(t *testing.T) {
 			respBody, err = ioutil.ReadAll(resp.Body)
 			require.NoError(t, err)
 			require.Contains(t, string(respBody), "postgres: key not found")
-			require.EqualValues(t, http.StatusInternalServerError, resp.StatusCode, string(respBody))
+			require.EqualValues(t, http.StatusNotFound, resp.StatusCode, string(respBody))
 			require.NoError(t, resp.Body.Close())
 		})
 	})
@@ -224,12 +224,10 @@ func TestRoot_Server_Redis(t *testing.T) {
 
 			resp, err = client.Do(req)
 			require.NoError(t, err)
-			var requestBody struct {
-				Value string `json:"value"`
-			}
-			require.NoError(t, json.NewDecoder(resp.Body).Decode(&requestBody))
-			require.EqualValues(t, http.StatusOK, resp.StatusCode)
-			require.EqualValues(t, "", requestBody.Value)
+			respBody, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.EqualValues(t, http.StatusNotFound, resp.StatusCode)
+			require.Contains(t, string(respBody), "storage: key not found")
 			require.NoError(t, resp.Body.Close())
 		})
 	})
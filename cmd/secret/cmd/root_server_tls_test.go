@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/tlsconfig/tlstest"
+)
+
+func TestRoot_ServerTLS(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	dir := t.TempDir()
+	ca := tlstest.NewCA(t, "test-ca")
+	caFile := tlstest.WriteFile(t, dir, "ca.pem", ca.PEM)
+	certPEM, keyPEM := ca.Issue(t, "server")
+	certFile := tlstest.WriteFile(t, dir, "server.pem", certPEM)
+	keyFile := tlstest.WriteFile(t, dir, "server-key.pem", keyPEM)
+	clientCertPEM, clientKeyPEM := ca.Issue(t, "team-a")
+	clientCertFile := tlstest.WriteFile(t, dir, "client.pem", clientCertPEM)
+	clientKeyFile := tlstest.WriteFile(t, dir, "client-key.pem", clientKeyPEM)
+
+	port, err := GetFreePort()
+	require.NoError(t, err)
+	r := New()
+	r.cmd.SetArgs([]string{"server", "--path", dir + "/file.txt", "--port", strconv.Itoa(port),
+		"--tls-cert", certFile, "--tls-key", keyFile, "--client-ca", caFile})
+	go func() {
+		if err := r.Execute(ctx); err != nil {
+			fmt.Println(err)
+		}
+	}()
+	time.Sleep(2 * time.Second)
+
+	ping := func(args ...string) error {
+		r := New()
+		r.cmd.SetArgs(append([]string{"server", "ping", "--url", "https://localhost", "--port", strconv.Itoa(port), "--timeout", "1s"}, args...))
+		return r.Execute(ctx)
+	}
+
+	t.Run("success with client certificate", func(t *testing.T) {
+		require.NoError(t, ping("--ca", caFile, "--client-cert", clientCertFile, "--client-key", clientKeyFile))
+	})
+	t.Run("error without client certificate", func(t *testing.T) {
+		require.Error(t, ping("--ca", caFile))
+	})
+	t.Run("error with unknown ca", func(t *testing.T) {
+		require.Error(t, ping("--client-cert", clientCertFile, "--client-key", clientKeyFile))
+	})
+	t.Run("error with plain http", func(t *testing.T) {
+		r := New()
+		r.cmd.SetArgs([]string{"server", "ping", "--url", "http://localhost", "--port", strconv.Itoa(port)})
+		require.Error(t, r.Execute(ctx))
+	})
+}
+
+func TestRoot_ServerTLSFlags(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	dir := t.TempDir()
+
+	r := New()
+	r.cmd.SetArgs([]string{"server", "--path", dir + "/file.txt", "--client-ca", "ca.pem"})
+	err := r.Execute(ctx)
+	require.EqualError(t, err, "client ca requires tls certificate and key")
+
+	r = New()
+	r.cmd.SetArgs([]string{"server", "--path", dir + "/file.txt", "--tls-cert", "cert.pem"})
+	err = r.Execute(ctx)
+	require.EqualError(t, err, "tlsconfig: both certificate and key files should be set")
+
+	r = New()
+	r.cmd.SetArgs([]string{"server", "--path", dir + "/file.txt", "--tls-cert", "cert.pem", "--tls-key", "key.pem", "--auth-client-cert"})
+	err = r.Execute(ctx)
+	require.EqualError(t, err, "auth client cert requires client ca")
+}
@@ -4,13 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
 	"github.com/go-itools-internship/go-secret/pkg/io/storage"
+	"github.com/go-itools-internship/go-secret/pkg/provider"
 
 	"github.com/jmoiron/sqlx"
 
@@ -38,6 +42,9 @@ func TestRoot_Set(t *testing.T) {
 
 		fileData := make(map[string]string)
 		require.NoError(t, json.NewDecoder(testFile).Decode(&fileData))
+		delete(fileData, storageHeaderKey)
+		delete(fileData, storageVersionsKey)
+		delete(fileData, storageMetadataKey)
 
 		var got string
 		require.Len(t, fileData, 1)
@@ -49,7 +56,6 @@ func TestRoot_Set(t *testing.T) {
 	})
 	t.Run("expect set data only redis storage", func(t *testing.T) {
 		key := "12345"
-		cipherKey := "d93beca6efd0421b314c08102f48f13038410738dbd2050c04fc89265a10024ba4"
 		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 		defer cancel()
 
@@ -60,10 +66,13 @@ func TestRoot_Set(t *testing.T) {
 		require.NoError(t, err)
 
 		rdb := redis.NewClient(&redis.Options{Addr: redisURL, Password: "", DB: 0})
+		defer disconnectRDB(rdb, r.logger.Named("test"))
 
-		val, err := rdb.Get(ctx, cipherKey).Result()
+		pr, err := provider.Open(storage.NewRedisVault(rdb), []byte("ck"))
 		require.NoError(t, err)
-		require.NotEmpty(t, val)
+		val, err := pr.GetData([]byte(key))
+		require.NoError(t, err)
+		require.EqualValues(t, "test value", string(val))
 	})
 	t.Run("expect success set data postgres storage if get key error", func(t *testing.T) {
 		key := "12345"
@@ -120,7 +129,58 @@ func TestRoot_Set(t *testing.T) {
 
 		fileData := make(map[string]string)
 		require.NoError(t, json.NewDecoder(testFile).Decode(&fileData))
+		delete(fileData, storageHeaderKey)
+		delete(fileData, storageVersionsKey)
+		delete(fileData, storageMetadataKey)
 		require.NotEmpty(t, fileData)
 		require.Len(t, fileData, 2)
 	})
+	t.Run("expect value expires after ttl", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		defer func() {
+			require.NoError(t, os.Remove(path))
+		}()
+
+		r := New()
+		r.cmd.SetArgs([]string{"set", "--key", key, "--value", "test value", "--cipher-key", "ck", "--path", path, "--ttl", "1s"})
+		require.NoError(t, r.Execute(ctx))
+		r = New()
+		r.cmd.SetArgs([]string{"get", "--key", key, "--cipher-key", "ck", "--path", path})
+		require.NoError(t, r.Execute(ctx))
+
+		time.Sleep(1100 * time.Millisecond)
+		r = New()
+		r.cmd.SetArgs([]string{"get", "--key", key, "--cipher-key", "ck", "--path", path})
+		err := r.Execute(ctx)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "key not found")
+	})
+	t.Run("error if ttl and expires-at are used together", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		r := New()
+		r.cmd.SetArgs([]string{"set", "--key", key, "--value", "test value", "--cipher-key", "ck", "--path", path, "--ttl", "1h", "--expires-at", "2100-01-01T00:00:00Z"})
+		err := r.Execute(ctx)
+		require.Error(t, err)
+		require.EqualValues(t, "ttl and expires-at can't be used together", err.Error())
+	})
+	t.Run("sealed file keeps kdf cost", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		sealedPath := filepath.Join(t.TempDir(), "sealed.json")
+
+		r := New()
+		r.cmd.SetArgs([]string{"set", "--key", key, "--value", "test value", "--cipher-key", "ck", "--path", sealedPath, "--sealed", "--kdf-cost", "10"})
+		require.NoError(t, r.Execute(ctx))
+
+		data, err := ioutil.ReadFile(sealedPath)
+		require.NoError(t, err)
+		var doc struct {
+			KDF crypto.KDFParams `json:"kdf"`
+		}
+		require.NoError(t, json.Unmarshal(data, &doc))
+		require.EqualValues(t, 1<<10, doc.KDF.N)
+	})
 }
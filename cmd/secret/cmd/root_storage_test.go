@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoot_Storage(t *testing.T) {
+	run := func(ctx context.Context, args ...string) (string, error) {
+		var b bytes.Buffer
+		r := New()
+		r.cmd.SetOut(&b)
+		r.cmd.SetArgs(append(args, "--cipher-key", "ck"))
+		err := r.Execute(ctx)
+		return b.String(), err
+	}
+
+	t.Run("storage url from flag, environment and config", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		dir := t.TempDir()
+		storagePath := filepath.Join(dir, "secrets.json")
+		storageURL := "file://" + storagePath
+
+		_, err := run(ctx, "set", "--key", key, "--value", "test value", "--storage", storageURL)
+		require.NoError(t, err)
+
+		require.NoError(t, os.Setenv(envStorage, storageURL))
+		out, err := run(ctx, "get", "--key", key)
+		require.NoError(t, os.Unsetenv(envStorage))
+		require.NoError(t, err)
+		require.EqualValues(t, "test value\n", out)
+
+		config := filepath.Join(dir, "config.yaml")
+		require.NoError(t, ioutil.WriteFile(config, []byte("storage:\n  url: "+storageURL+"\n  max_versions: 1\n"), 0600))
+		_, err = run(ctx, "set", "--key", key, "--value", "new value", "--config", config)
+		require.NoError(t, err)
+		out, err = run(ctx, "history", "--key", key, "--config", config)
+		require.NoError(t, err)
+		require.Regexp(t, "^2\t[^\n]+\n$", out, "only the latest version should be kept")
+
+		_, err = os.Stat(path)
+		require.True(t, os.IsNotExist(err), "default file storage shouldn't be created")
+	})
+	t.Run("flag has priority over config", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		dir := t.TempDir()
+		config := filepath.Join(dir, "config.yaml")
+		require.NoError(t, ioutil.WriteFile(config, []byte("storage:\n  url: file://"+filepath.Join(dir, "config.json")+"\n"), 0600))
+
+		_, err := run(ctx, "set", "--key", key, "--value", "test value", "--config", config, "--storage", "file://"+filepath.Join(dir, "flag.json"))
+		require.NoError(t, err)
+		_, err = os.Stat(filepath.Join(dir, "flag.json"))
+		require.NoError(t, err)
+		_, err = os.Stat(filepath.Join(dir, "config.json"))
+		require.True(t, os.IsNotExist(err))
+	})
+	t.Run("log storage by path", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		storageURL := "log://" + filepath.Join(t.TempDir(), "secrets.log")
+
+		_, err := run(ctx, "set", "--key", key, "--value", "test value", "--path", storageURL)
+		require.NoError(t, err)
+		out, err := run(ctx, "get", "--key", key, "--path", storageURL)
+		require.NoError(t, err)
+		require.EqualValues(t, "test value\n", out)
+		out, err = run(ctx, "list", "--path", storageURL)
+		require.NoError(t, err)
+		require.EqualValues(t, key+"\n", out)
+	})
+	t.Run("error if scheme is unknown", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		_, err := run(ctx, "get", "--key", key, "--storage", "mongodb://localhost")
+		require.Error(t, err)
+		require.EqualValues(t, `can't open storage: storage: unknown storage scheme "mongodb"`, err.Error())
+	})
+	t.Run("error if config has unknown field", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		config := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, ioutil.WriteFile(config, []byte("storage:\n  address: localhost\n"), 0600))
+
+		_, err := run(ctx, "get", "--key", key, "--config", config)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "can't parse config")
+	})
+}
@@ -3,6 +3,8 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -14,7 +16,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
 
 	api "github.com/go-itools-internship/go-secret/pkg/http"
 	"github.com/stretchr/testify/require"
@@ -29,6 +34,15 @@ const (
 	migration         = "file://../../../scripts/migrations"
 )
 
+// storageHeaderKey is the file storage key of the header with key derivation params
+var storageHeaderKey = hex.EncodeToString([]byte("go-secret:header"))
+
+// storageVersionsKey is the file storage entry with previous versions of values
+const storageVersionsKey = "versions"
+
+// storageMetadataKey is the file storage entry with metadata of values
+const storageMetadataKey = "metadata"
+
 func TestRoot_Server(t *testing.T) {
 	t.Run("set by key", func(t *testing.T) {
 		t.Run("expect set method success", func(t *testing.T) {
@@ -152,7 +166,7 @@ func TestRoot_Server(t *testing.T) {
 			require.NoError(t, err)
 			_, err = ioutil.ReadAll(resp.Body)
 			require.NoError(t, err)
-			require.EqualValues(t, http.StatusInternalServerError, resp.StatusCode)
+			require.EqualValues(t, http.StatusUnauthorized, resp.StatusCode)
 			require.NoError(t, resp.Body.Close())
 		})
 		t.Run("get method with error, when url not found error", func(t *testing.T) {
@@ -201,12 +215,65 @@ func TestRoot_Server(t *testing.T) {
 
 			resp, err := client.Do(req)
 			require.NoError(t, err)
-			require.EqualValues(t, http.StatusInternalServerError, resp.StatusCode)
+			require.EqualValues(t, http.StatusNotFound, resp.StatusCode)
 			require.NoError(t, resp.Body.Close())
 		})
 	})
 }
 
+func TestRoot_ServerRoutes(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	port := createAndExecuteCliCommand(ctx)
+	defer func() {
+		require.NoError(t, os.Remove(path))
+	}()
+	client := http.Client{Timeout: time.Second}
+	do := func(method, route, body string) *http.Response {
+		req := httptest.NewRequest(method, "http://localhost:"+port+route, bytes.NewBufferString(body))
+		req.Header.Set(api.ParamCipherKey, expectedSipherKey)
+		req.RequestURI = ""
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := do(http.MethodPut, "/v1/secrets/local/team%2Fkey", `{"value":"test-value-1"}`)
+	require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+	require.NoError(t, resp.Body.Close())
+
+	resp = do(http.MethodGet, "/v1/secrets/local/team%2Fkey", "")
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	var getBody struct {
+		Value string `json:"value"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&getBody))
+	require.EqualValues(t, "test-value-1", getBody.Value)
+	require.NoError(t, resp.Body.Close())
+
+	resp = do(http.MethodGet, "/v1/secrets/local", "")
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	var listBody struct {
+		Keys []string `json:"keys"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listBody))
+	require.EqualValues(t, []string{"team/key"}, listBody.Keys)
+	require.NoError(t, resp.Body.Close())
+
+	resp = do(http.MethodGet, "/?key=team%2Fkey&method=local", "")
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, "true", resp.Header.Get(api.DeprecationHeader))
+	require.NoError(t, resp.Body.Close())
+
+	resp = do(http.MethodDelete, "/v1/secrets/local/team%2Fkey", "")
+	require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+	require.NoError(t, resp.Body.Close())
+
+	resp = do(http.MethodHead, "/v1/secrets/local/team%2Fkey", "")
+	require.EqualValues(t, http.StatusNotFound, resp.StatusCode)
+	require.NoError(t, resp.Body.Close())
+}
+
 func TestRoot_ServerPing(t *testing.T) {
 	route := "/ping"
 	t.Run("success", func(t *testing.T) {
@@ -338,3 +405,23 @@ func migrateDown(t *testing.T) error {
 	t.Log("root-test: migrate down")
 	return nil
 }
+
+func disconnectPDB(pdb *sqlx.DB, logger *zap.SugaredLogger) {
+	logger = logger.Named("disconnect")
+	err := pdb.Close()
+	if err != nil {
+		logger.Warnf("can't disconnect postgres db, error=%v", err)
+		return
+	}
+	logger.Info("pdb disconnect")
+}
+
+func disconnectRDB(rdb *redis.Client, logger *zap.SugaredLogger) {
+	logger = logger.Named("disconnect")
+	err := rdb.Close()
+	if err != nil {
+		logger.Warnf("can't disconnect redis db, error=%v", err)
+		return
+	}
+	logger.Info("rdb disconnected")
+}
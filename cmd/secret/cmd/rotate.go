@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/go-itools-internship/go-secret/pkg/provider"
+	secretApi "github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func (r *root) rotateCmd() *cobra.Command {
+	var oldCipherKey string
+	var newCipherKey string
+	var sf storageFlags
+	var cf cryptoFlags
+	var rotateCmd = &cobra.Command{
+		Use:   "rotate",
+		Short: "Re-encrypt all data of the old cipher key with the new cipher key",
+		Long: "it takes old and new cipher keys from user and re-encrypts all pairs key-value of the old cipher key in specified storage. " +
+			"Previous versions are re-encrypted with their numbers and creation times, storage which keeps versions, but can't save them, isn't rotated. " +
+			"Sealed file is resealed with the new cipher key. " +
+			"Interrupted rotation is resumed by running the command again with the same cipher keys",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger := r.logger.Named("rotate-cmd")
+			logger.Info("Start")
+			if oldCipherKey == "" || newCipherKey == "" {
+				return errors.New("old and new cipher keys should be set")
+			}
+			ds, closeFn, err := r.openDataSaver(cmd.Context(), sf, oldCipherKey, cf, logger)
+			if errors.Is(err, secretApi.ErrDecrypt) {
+				// sealed file could be resealed with the new cipher key before rotation was interrupted
+				ds, closeFn, err = r.openDataSaver(cmd.Context(), sf, newCipherKey, cf, logger)
+			}
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			n, err := provider.RotateCtx(cmd.Context(), ds, []byte(oldCipherKey), []byte(newCipherKey), cf.options()...)
+			if err != nil {
+				return fmt.Errorf("can't rotate cipher key: %w", err)
+			}
+			logger.Info("ready rotate cipher key")
+			cmd.Printf("Rotated %d entries\n", n)
+			return nil
+		},
+	}
+	rotateCmd.Flags().StringVar(&oldCipherKey, "old-cipher-key", "", "cipher key the data is encrypted with now")
+	rotateCmd.Flags().StringVar(&newCipherKey, "new-cipher-key", "", "cipher key the data will be encrypted with")
+	sf.register(rotateCmd)
+	cf.register(rotateCmd)
+
+	return rotateCmd
+}
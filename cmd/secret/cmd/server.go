@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/go-itools-internship/go-secret/pkg/auth"
+	api "github.com/go-itools-internship/go-secret/pkg/http"
+	"github.com/go-itools-internship/go-secret/pkg/io/storage"
+	"github.com/go-itools-internship/go-secret/pkg/provider"
+	secretApi "github.com/go-itools-internship/go-secret/pkg/secret"
+	"github.com/go-itools-internship/go-secret/pkg/tlsconfig"
+)
+
+type chiLogger struct {
+	sugar *zap.SugaredLogger
+}
+
+func (c *chiLogger) Print(v ...interface{}) {
+	c.sugar.Info(v...)
+}
+
+func (r *root) serverCmd() *cobra.Command {
+	var path string
+	var memory bool
+	var port string
+	var reapInterval time.Duration
+	var bf backendFlags
+	var cf cryptoFlags
+	var af authFlags
+	var tf tlsFlags
+	var kf keyringFlags
+	var serverCmd = &cobra.Command{
+		Use:   "server",
+		Short: "Run server runner mode to start the app as a daemon",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger := r.logger.Named("server")
+			store := make(map[string]api.MethodFactoryFunc)
+			reapers := make(map[string]secretApi.Reaper)
+			derivations := make(chan struct{}, maxConcurrentDerivations)
+			logger.Info("Start")
+			c, err := bf.loadConfig()
+			if err != nil {
+				return err
+			}
+			methods, err := serverMethods(cmd, bf, c, path, memory)
+			if err != nil {
+				return err
+			}
+			authenticator, handlerOpts, err := af.build()
+			if err != nil {
+				return err
+			}
+			if af.clientCert && tf.clientCA == "" {
+				// client certificates are verified only with client ca
+				return errors.New("auth client cert requires client ca")
+			}
+			for method, mc := range methods {
+				ds, closeFn, err := openStorage(cmd.Context(), mc.URL, bf.options(mc.storageConfig), logger)
+				if errors.Is(err, storage.ErrPassphraseRequired) {
+					return fmt.Errorf("method %s: sealed file can't be served, convert it to plain layout: %w", method, err)
+				}
+				if err != nil {
+					return fmt.Errorf("method %s: %w", method, err)
+				}
+				defer closeFn()
+				var opts []provider.Option
+				if mc.Prefix != "" {
+					opts = append(opts, provider.KeyPrefix(mc.Prefix))
+				}
+				store[method], err = r.methodFactory(ds, cf, derivations, logger, opts...)
+				if err != nil {
+					return err
+				}
+				if reaper, ok := ds.(secretApi.Reaper); ok {
+					reapers[method] = reaper
+				}
+				if mc.Policy != "" {
+					if authenticator == nil {
+						return fmt.Errorf("auth policy of method %s requires at least one authentication method", method)
+					}
+					policy, err := auth.LoadPolicy(mc.Policy)
+					if err != nil {
+						return err
+					}
+					handlerOpts = append(handlerOpts, api.MethodAuthorizer(method, policy))
+				}
+			}
+
+			reloader, err := tf.reloader()
+			if err != nil {
+				return err
+			}
+			keyOpts, err := kf.build(authenticator != nil)
+			if err != nil {
+				return err
+			}
+			handlerOpts = append(handlerOpts, keyOpts...)
+			handler := api.NewMethods(store, logger.Named("handler"), handlerOpts...)
+			router := chi.NewRouter()
+			srv := &http.Server{Addr: ":" + port, Handler: router}
+			router.Use(middleware.RequestID, middleware.Heartbeat("/ping"), middleware.RequestLogger(&middleware.DefaultLogFormatter{
+				Logger: &chiLogger{logger.Named("api")},
+			}))
+			secured := router.With()
+			if authenticator != nil {
+				secured = router.With(handler.Authenticate(authenticator))
+			}
+			secured.Mount(api.RoutePrefix, handler.Routes())
+			secured.Get(api.MethodsRoute, handler.ListMethods)
+			secured.Post(api.BatchGetRoute, handler.BatchGet)
+			secured.Post(api.BatchSetRoute, handler.BatchSet)
+			// root routes are kept for callers of previous versions
+			secured.With(api.Deprecated).Post("/", handler.SetByKey)
+			secured.With(api.Deprecated).Get("/", handler.GetByKey)
+
+			done := make(chan os.Signal, 1)
+			shutdownCh := make(chan struct{})
+			signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+
+			if reapInterval > 0 && len(reapers) > 0 {
+				reapCtx, stopReap := context.WithCancel(cmd.Context())
+				defer stopReap()
+				go reapExpired(reapCtx, reapInterval, reapers, logger.Named("reaper"))
+			}
+
+			if reloader != nil {
+				srv.TLSConfig = reloader.Config()
+				hup := make(chan os.Signal, 1)
+				signal.Notify(hup, syscall.SIGHUP)
+				defer signal.Stop(hup)
+				reloadCtx, stopReload := context.WithCancel(cmd.Context())
+				defer stopReload()
+				go reloadOnSignal(reloadCtx, hup, reloader, logger)
+			}
+
+			go func() {
+				logger.Infof("listening ")
+				var err error
+				if reloader != nil {
+					err = srv.ListenAndServeTLS("", "")
+				} else {
+					err = srv.ListenAndServe()
+				}
+				if err != nil && !errors.Is(err, http.ErrServerClosed) {
+					logger.Errorf("connection error: %s", err)
+				}
+			}()
+			logger.Info("listener started")
+
+			select {
+			case <-done:
+				logger.Info("listener stopped")
+			case <-cmd.Context().Done():
+				logger.Info("listener stopped with context")
+			}
+
+			go func(ctx context.Context) {
+				defer close(shutdownCh)
+				ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+				logger.Infof("ctx-shutdown-pdb after connection %v", ctx)
+				defer cancel()
+				err := srv.Shutdown(ctx)
+				if err != nil {
+					logger.Error("listener shutdown failed", err)
+				}
+			}(context.Background())
+			<-shutdownCh
+			logger.Info("exit")
+
+			return nil
+		},
+	}
+	serverCmd.Flags().StringVarP(&path, "path", "p", "file.txt", "the place where the key/value of local method will be stored/got. Storage url is accepted as well. Ignored by default if config has methods")
+	serverCmd.Flags().StringVarP(&port, "port", "t", "8888", "localhost address")
+	serverCmd.Flags().BoolVar(&memory, "memory", false, "serve \"memory\" method, which keeps data in memory until the server stops. File of local method isn't used unless path is set")
+	serverCmd.Flags().DurationVar(&reapInterval, "reap-interval", time.Minute, "how often expired values are removed from postgres and file storages. Expired values are never returned anyway. Reaper is disabled if it isn't positive")
+	bf.register(serverCmd)
+	cf.register(serverCmd)
+	af.register(serverCmd)
+	tf.register(serverCmd)
+	kf.register(serverCmd)
+	serverCmd.AddCommand(r.serverPingCmd())
+	return serverCmd
+}
+
+// serverMethods returns methods served by server: "remote" for the storage, "local" for file by path,
+// "memory" for memory storage and methods of config.
+// File by path is used with memory storage or config methods only if path flag is set.
+func serverMethods(cmd *cobra.Command, bf backendFlags, c config, path string, memory bool) (map[string]methodConfig, error) {
+	methods := make(map[string]methodConfig)
+	storageURL, _ := bf.resolve(c, "")
+	if storageURL != "" {
+		methods["remote"] = methodConfig{storageConfig: storageConfig{URL: storageURL, Migrations: c.Storage.Migrations, MaxVersions: c.Storage.MaxVersions}}
+	}
+	if memory {
+		methods["memory"] = methodConfig{storageConfig: storageConfig{URL: "memory://", MaxVersions: c.Storage.MaxVersions}}
+	}
+	if path != "" && (len(c.Methods) == 0 && !memory || cmd.Flags().Changed("path")) {
+		methods["local"] = methodConfig{storageConfig: storageConfig{URL: pathURL(path), MaxVersions: c.Storage.MaxVersions}}
+	}
+	for method, mc := range c.Methods {
+		if _, ok := methods[method]; ok {
+			return nil, fmt.Errorf("method %s is configured twice", method)
+		}
+		if mc.URL == "" {
+			return nil, fmt.Errorf("method %s has no storage url", method)
+		}
+		methods[method] = mc
+	}
+	return methods, nil
+}
+
+// reapExpired removes expired values from storages every interval until context is done.
+func reapExpired(ctx context.Context, interval time.Duration, reapers map[string]secretApi.Reaper, logger *zap.SugaredLogger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for method, reaper := range reapers {
+				n, err := reaper.ReapCtx(ctx)
+				if err != nil {
+					logger.Errorf("can't remove expired values of %s method: %s", method, err)
+					continue
+				}
+				if n > 0 {
+					logger.Infof("removed expired values of %s method: %d", method, n)
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reloadOnSignal reloads certificates on every signal until context is done.
+// Server keeps previous certificates if new ones can't be loaded.
+func reloadOnSignal(ctx context.Context, signals <-chan os.Signal, reloader *tlsconfig.Reloader, logger *zap.SugaredLogger) {
+	for {
+		select {
+		case <-signals:
+			if err := reloader.Reload(); err != nil {
+				logger.Errorf("can't reload tls certificates: %s", err)
+				continue
+			}
+			logger.Info("tls certificates reloaded")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// methodFactory creates provider factory for server handlers.
+// Cipher keys derived from passphrases are cached, because key derivation is slow by design.
+// Derivations semaphore limits keys derived at once by all methods.
+// Options are added to the options of crypto flags.
+func (r *root) methodFactory(ds secretApi.DataSaver, cf cryptoFlags, derivations chan struct{}, logger *zap.SugaredLogger, opts ...provider.Option) (api.MethodFactoryFunc, error) {
+	params, err := provider.KDF(ds, cf.options()...)
+	if err != nil {
+		return nil, fmt.Errorf("can't read storage header: %w", err)
+	}
+	cache, err := newCryptographerCache(params, maxCachedCryptographers, derivations)
+	if err != nil {
+		return nil, err
+	}
+	return func(cipher string) (secretApi.Provider, func()) {
+		cr, err := cache.get(cipher)
+		if err != nil {
+			logger.Errorf("can't create cryptographer: %s", err)
+			return nil, nil
+		}
+		return provider.NewProvider(cr, ds, append(cf.options(), opts...)...), nil
+	}, nil
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/go-itools-internship/go-secret/pkg/provider"
+	secretApi "github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func (r *root) setCmd() *cobra.Command {
+	var key string
+	var cipherKey string
+	var value string
+	var ttl time.Duration
+	var expiresAt string
+	var labels map[string]string
+	var description string
+	var owner string
+	var sf storageFlags
+	var cf cryptoFlags
+	var setCmd = &cobra.Command{
+		Use:   "set",
+		Short: "Saves data to the specified storage in encrypted form",
+		Long:  "it takes keys and a value from user and saves value in encrypted manner in specified storage",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger := r.logger.Named("set-cmd")
+			logger.Info("Start")
+			expiry, err := expiryTime(ttl, expiresAt)
+			if err != nil {
+				return err
+			}
+			ds, closeFn, err := r.openDataSaver(cmd.Context(), sf, cipherKey, cf, logger)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			pr, err := provider.Open(ds, []byte(cipherKey), cf.options()...)
+			if err != nil {
+				return fmt.Errorf("can't open storage: %w", err)
+			}
+			flags := cmd.Flags()
+			if !flags.Changed("label") && !flags.Changed("description") && !flags.Changed("owner") {
+				logger.Info("prepare get data by key: ", key)
+				err = pr.SetDataWithExpiryCtx(cmd.Context(), []byte(key), []byte(value), expiry)
+				logger.Info("ready get data by key: ", key)
+				if err != nil {
+					return fmt.Errorf("can't set data %w", err)
+				}
+				return nil
+			}
+			// metadata fields, which aren't set, are kept, new value doesn't have metadata yet
+			md, err := pr.GetMetadataCtx(cmd.Context(), []byte(key))
+			if err != nil && !errors.Is(err, secretApi.ErrNotFound) {
+				return fmt.Errorf("can't get metadata by key: %w", err)
+			}
+			if flags.Changed("label") {
+				md.Labels = labels
+			}
+			if flags.Changed("description") {
+				md.Description = description
+			}
+			if flags.Changed("owner") {
+				md.Owner = owner
+			}
+			// value and metadata are set at once, so the value isn't changed if metadata can't be set
+			if _, err := pr.SetDataWithMetadataCtx(cmd.Context(), []byte(key), []byte(value), md, expiry, secretApi.AnyRevision); err != nil {
+				return fmt.Errorf("can't set data with metadata: %w", err)
+			}
+			return nil
+		},
+	}
+	setCmd.Flags().StringVarP(&value, "value", "v", value, "value to be encrypted")
+	setCmd.Flags().StringVarP(&key, "key", "k", key, "key for pair key-value")
+	setCmd.Flags().StringVarP(&cipherKey, "cipher-key", "c", cipherKey, "cipher key for data encryption and decryption")
+	setCmd.Flags().DurationVar(&ttl, "ttl", 0, "time after which the value expires, for example 24h. The value never expires by default")
+	setCmd.Flags().StringVar(&expiresAt, "expires-at", "", "time in RFC 3339 format when the value expires, for example 2021-06-01T00:00:00Z")
+	setCmd.Flags().StringToStringVar(&labels, "label", nil, "labels of the value, for example env=prod,team=a. Replace all labels of the value if set")
+	setCmd.Flags().StringVar(&description, "description", "", "description of the value")
+	setCmd.Flags().StringVar(&owner, "owner", "", "owner of the value")
+	sf.register(setCmd)
+	cf.register(setCmd)
+
+	return setCmd
+}
+
+// expiryTime returns expiration time of the value set with ttl or absolute time.
+// Zero time is returned if neither is set, so the value never expires.
+func expiryTime(ttl time.Duration, expiresAt string) (time.Time, error) {
+	switch {
+	case ttl != 0 && expiresAt != "":
+		return time.Time{}, errors.New("ttl and expires-at can't be used together")
+	case ttl < 0:
+		return time.Time{}, fmt.Errorf("ttl should be positive, got %s", ttl)
+	case ttl > 0:
+		return time.Now().Add(ttl), nil
+	case expiresAt != "":
+		t, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("can't parse expires-at: %w", err)
+		}
+		if !t.After(time.Now()) {
+			return time.Time{}, fmt.Errorf("expires-at should be in the future, got %s", expiresAt)
+		}
+		return t, nil
+	}
+	return time.Time{}, nil
+}
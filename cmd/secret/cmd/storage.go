@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/go-itools-internship/go-secret/pkg/io/storage"
+	secretApi "github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// pathURL returns storage URL of path flag, which is file path or storage URL.
+func pathURL(path string) string {
+	if strings.Contains(path, "://") {
+		return path
+	}
+	return storage.FileURL(path)
+}
+
+// openDataSaver opens the storage chosen by flags.
+// Sealed file is opened with the cipher key, new sealed file derives it with the kdf cost of crypto flags.
+// Returns close function that should be called when the storage isn't needed anymore.
+func (r *root) openDataSaver(ctx context.Context, sf storageFlags, cipherKey string, cf cryptoFlags, logger *zap.SugaredLogger) (secretApi.DataSaver, func(), error) {
+	c, err := sf.loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	storageURL, opts := sf.resolve(c, pathURL(sf.path))
+	opts.Passphrase = []byte(cipherKey)
+	opts.Sealed = opts.Sealed || sf.sealed
+	opts.KDFCost = cf.kdfCost
+	return openStorage(ctx, storageURL, opts, logger)
+}
+
+// openStorage opens the storage by URL.
+// Returns close function that logs errors of closing.
+func openStorage(ctx context.Context, storageURL string, opts storage.BackendOptions, logger *zap.SugaredLogger) (secretApi.DataSaver, func(), error) {
+	ds, closeFn, err := storage.Open(ctx, storageURL, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't open storage: %w", err)
+	}
+	return ds, func() {
+		if err := closeFn(); err != nil {
+			logger.Named("disconnect").Warnf("can't close storage, error=%v", err)
+		}
+	}, nil
+}
@@ -0,0 +1,71 @@
+/*
+Package auth provides authentication of API callers and authorization of their access to keys.
+
+Callers are authenticated by one of authenticators: static bearer tokens, HMAC-signed requests
+or TLS client certificates. Authenticated identity is saved into request context,
+so policy can check which actions on which key prefixes are granted to the identity.
+*/
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrNoCredentials is returned by authenticator if request doesn't have credentials of its kind.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrUnauthenticated is returned if caller can't be authenticated.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is returned if action isn't granted to the caller.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Authenticator authenticates caller of the request.
+type Authenticator interface {
+	// Authenticate returns identity of the caller.
+	// Returns ErrNoCredentials if request doesn't have credentials of this kind,
+	// so next authenticator of the chain can be tried.
+	Authenticate(r *http.Request) (string, error)
+}
+
+// AuthenticatorFunc is the adapter to use function as Authenticator.
+type AuthenticatorFunc func(r *http.Request) (string, error)
+
+// Authenticate calls f(r).
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (string, error) {
+	return f(r)
+}
+
+// Chain returns authenticator that tries authenticators in order
+// until one of them finds credentials in the request.
+func Chain(authenticators ...Authenticator) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (string, error) {
+		for _, a := range authenticators {
+			identity, err := a.Authenticate(r)
+			if errors.Is(err, ErrNoCredentials) {
+				continue
+			}
+			if err != nil {
+				return "", fmt.Errorf("%w: %s", ErrUnauthenticated, err)
+			}
+			return identity, nil
+		}
+		return "", fmt.Errorf("%w: %s", ErrUnauthenticated, ErrNoCredentials)
+	})
+}
+
+type identityKey struct{}
+
+// WithIdentity returns context with identity of the caller.
+func WithIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns identity of the caller saved by WithIdentity.
+func IdentityFromContext(ctx context.Context) (string, bool) {
+	identity, ok := ctx.Value(identityKey{}).(string)
+	return identity, ok
+}
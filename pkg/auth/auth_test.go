@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain(t *testing.T) {
+	none := AuthenticatorFunc(func(r *http.Request) (string, error) {
+		return "", ErrNoCredentials
+	})
+	found := AuthenticatorFunc(func(r *http.Request) (string, error) {
+		return "team-a", nil
+	})
+	invalid := AuthenticatorFunc(func(r *http.Request) (string, error) {
+		return "", errors.New("test error")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	t.Run("success", func(t *testing.T) {
+		identity, err := Chain(none, found, invalid).Authenticate(req)
+		require.NoError(t, err)
+		require.EqualValues(t, "team-a", identity)
+	})
+	t.Run("error when credentials are invalid", func(t *testing.T) {
+		_, err := Chain(none, invalid, found).Authenticate(req)
+		require.True(t, errors.Is(err, ErrUnauthenticated))
+		require.Contains(t, err.Error(), "test error")
+	})
+	t.Run("error when there are no credentials", func(t *testing.T) {
+		_, err := Chain(none).Authenticate(req)
+		require.True(t, errors.Is(err, ErrUnauthenticated))
+		require.Contains(t, err.Error(), ErrNoCredentials.Error())
+	})
+}
+
+func TestIdentityFromContext(t *testing.T) {
+	_, ok := IdentityFromContext(context.Background())
+	require.False(t, ok)
+
+	identity, ok := IdentityFromContext(WithIdentity(context.Background(), "team-a"))
+	require.True(t, ok)
+	require.EqualValues(t, "team-a", identity)
+}
+
+func TestNewClientCert(t *testing.T) {
+	a := NewClientCert()
+	t.Run("success", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{
+			{Subject: pkix.Name{CommonName: "team-a"}},
+		}}}
+		identity, err := a.Authenticate(req)
+		require.NoError(t, err)
+		require.EqualValues(t, "team-a", identity)
+	})
+	t.Run("no credentials without verified certificate", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		_, err := a.Authenticate(req)
+		require.True(t, errors.Is(err, ErrNoCredentials))
+
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+			{Subject: pkix.Name{CommonName: "team-a"}},
+		}}
+		_, err = a.Authenticate(req)
+		require.True(t, errors.Is(err, ErrNoCredentials))
+	})
+	t.Run("error without common name", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+		_, err := a.Authenticate(req)
+		require.Error(t, err)
+		require.False(t, errors.Is(err, ErrNoCredentials))
+	})
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "auth")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+)
+
+// NewClientCert creates authenticator of TLS client certificates.
+// Identity is the common name of the certificate subject.
+// Only certificates verified by the server are accepted,
+// so server should be configured with client CA pool.
+func NewClientCert() Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (string, error) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			return "", ErrNoCredentials
+		}
+		cn := r.TLS.VerifiedChains[0][0].Subject.CommonName
+		if cn == "" {
+			return "", errors.New("client certificate doesn't have common name")
+		}
+		return cn, nil
+	})
+}
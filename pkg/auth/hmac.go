@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// HMACScheme is the scheme of Authorization header with signed request.
+	HMACScheme = "GSEC-HMAC-SHA256"
+
+	// maxSignedBodySize limits body read to check the signature.
+	maxSignedBodySize = 10 << 20
+)
+
+// signedHeaders are headers covered by the signature.
+// They are cipher key and key id headers of pkg/http, which select the key to encrypt and decrypt values,
+// and precondition headers, so replayed writes can't drop or change the expected revision.
+var signedHeaders = []string{"cipher", "key-id", "if-match", "if-none-match"}
+
+type hmacKeys struct {
+	keys    map[string][]byte
+	maxSkew time.Duration
+	now     func() time.Time
+}
+
+// HMACOption configures HMAC authenticator.
+type HMACOption func(h *hmacKeys)
+
+// HMACMaxSkew sets max difference between time of the signature and time of the server. Default: 5 minutes.
+// Older signatures are rejected, so captured requests can't be replayed later.
+func HMACMaxSkew(d time.Duration) HMACOption {
+	return func(h *hmacKeys) {
+		h.maxSkew = d
+	}
+}
+
+// HMACClock sets function to get time of the server. Default: time.Now.
+func HMACClock(now func() time.Time) HMACOption {
+	return func(h *hmacKeys) {
+		h.now = now
+	}
+}
+
+// NewHMAC creates authenticator of requests signed by SignRequest.
+// Accepts shared secret keys by identity.
+//
+// Example of header:
+//
+//    Authorization: GSEC-HMAC-SHA256 Credential=team-a, Timestamp=1622548800, Signature=5d41...
+func NewHMAC(keysByIdentity map[string]string, opts ...HMACOption) (Authenticator, error) {
+	h := &hmacKeys{
+		keys:    make(map[string][]byte, len(keysByIdentity)),
+		maxSkew: 5 * time.Minute,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	for identity, key := range keysByIdentity {
+		if key == "" {
+			return nil, fmt.Errorf("auth: empty hmac key of %q", identity)
+		}
+		h.keys[identity] = []byte(key)
+	}
+	return h, nil
+}
+
+// LoadHMAC creates authenticator of signed requests from the file.
+// File has "identity key" pair per line, empty lines and lines started with "#" are skipped.
+func LoadHMAC(path string, opts ...HMACOption) (Authenticator, error) {
+	keysByIdentity, err := loadCredentials(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewHMAC(keysByIdentity, opts...)
+}
+
+func (h *hmacKeys) Authenticate(r *http.Request) (string, error) {
+	credentials, ok := authorization(r, HMACScheme)
+	if !ok {
+		return "", ErrNoCredentials
+	}
+	params := parseParams(credentials)
+	identity := params["Credential"]
+	key, ok := h.keys[identity]
+	if !ok {
+		return "", fmt.Errorf("unknown hmac credential %q", identity)
+	}
+	unix, err := strconv.ParseInt(params["Timestamp"], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse hmac timestamp: %w", err)
+	}
+	skew := h.now().Sub(time.Unix(unix, 0))
+	if skew > h.maxSkew || -skew > h.maxSkew {
+		return "", errors.New("hmac timestamp is out of allowed range")
+	}
+	signature, err := hex.DecodeString(params["Signature"])
+	if err != nil {
+		return "", fmt.Errorf("cannot decode hmac signature: %w", err)
+	}
+	expected, err := sign(r, key, unix)
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal(signature, expected) {
+		return "", errors.New("hmac signature mismatch")
+	}
+	return identity, nil
+}
+
+// SignRequest signs the request with shared secret key of the identity.
+// Signature covers method, path, query, cipher key, key id and precondition headers, timestamp and body, so none of them can be changed.
+// Headers and body should be set before signing.
+func SignRequest(r *http.Request, identity, key string, now time.Time) error {
+	unix := now.Unix()
+	signature, err := sign(r, []byte(key), unix)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s, Timestamp=%d, Signature=%s",
+		HMACScheme, identity, unix, hex.EncodeToString(signature)))
+	return nil
+}
+
+// sign calculates signature of the request.
+// Body is read and replaced with the copy, so it can be read again.
+func sign(r *http.Request, key []byte, unix int64) ([]byte, error) {
+	bodyHash := sha256.New()
+	if r.Body != nil && r.Body != http.NoBody {
+		data, err := ioutil.ReadAll(io.LimitReader(r.Body, maxSignedBodySize+1))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read body to sign: %w", err)
+		}
+		if len(data) > maxSignedBodySize {
+			return nil, errors.New("body is too large to sign")
+		}
+		if err := r.Body.Close(); err != nil {
+			return nil, fmt.Errorf("cannot close body to sign: %w", err)
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(data))
+		bodyHash.Write(data)
+	}
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n", r.Method, r.URL.EscapedPath(), r.URL.RawQuery)
+	for _, name := range signedHeaders {
+		fmt.Fprintf(mac, "%s\n", strings.Join(r.Header.Values(name), ","))
+	}
+	fmt.Fprintf(mac, "%d\n%x", unix, bodyHash.Sum(nil))
+	return mac.Sum(nil), nil
+}
+
+// parseParams parses comma separated "name=value" params.
+func parseParams(s string) map[string]string {
+	params := make(map[string]string)
+	for _, p := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		if len(kv) == 2 {
+			params[kv[0]] = kv[1]
+		}
+	}
+	return params
+}
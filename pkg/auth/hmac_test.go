@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHMAC(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	a, err := NewHMAC(map[string]string{"team-a": "secret-a"}, HMACClock(func() time.Time { return now }))
+	require.NoError(t, err)
+	newRequest := func() *http.Request {
+		return httptest.NewRequest(http.MethodPut, "/v1/secrets/local/team-a%2Fkey?x=1", bytes.NewBufferString(`{"value":"v"}`))
+	}
+
+	t.Run("success", func(t *testing.T) {
+		req := newRequest()
+		require.NoError(t, SignRequest(req, "team-a", "secret-a", now.Add(-time.Minute)))
+
+		identity, err := a.Authenticate(req)
+		require.NoError(t, err)
+		require.EqualValues(t, "team-a", identity)
+
+		body, err := ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+		require.EqualValues(t, `{"value":"v"}`, string(body))
+	})
+	t.Run("error when body is changed", func(t *testing.T) {
+		req := newRequest()
+		require.NoError(t, SignRequest(req, "team-a", "secret-a", now))
+		req.Body = ioutil.NopCloser(bytes.NewBufferString(`{"value":"changed"}`))
+
+		_, err := a.Authenticate(req)
+		require.EqualError(t, err, "hmac signature mismatch")
+	})
+	t.Run("error when path is changed", func(t *testing.T) {
+		req := newRequest()
+		require.NoError(t, SignRequest(req, "team-a", "secret-a", now))
+		req.URL.Path = "/v1/secrets/local/team-b/key"
+		req.URL.RawPath = ""
+
+		_, err := a.Authenticate(req)
+		require.EqualError(t, err, "hmac signature mismatch")
+	})
+	t.Run("error when cipher key, key id or precondition header is changed", func(t *testing.T) {
+		for _, name := range []string{"cipher", "key-id", "If-Match", "If-None-Match"} {
+			req := newRequest()
+			req.Header.Set(name, "signed")
+			require.NoError(t, SignRequest(req, "team-a", "secret-a", now))
+			req.Header.Set(name, "changed")
+
+			_, err := a.Authenticate(req)
+			require.EqualError(t, err, "hmac signature mismatch", name)
+
+			req.Header.Del(name)
+			_, err = a.Authenticate(req)
+			require.EqualError(t, err, "hmac signature mismatch", name)
+		}
+	})
+	t.Run("error when key is wrong", func(t *testing.T) {
+		req := newRequest()
+		require.NoError(t, SignRequest(req, "team-a", "secret-b", now))
+
+		_, err := a.Authenticate(req)
+		require.EqualError(t, err, "hmac signature mismatch")
+	})
+	t.Run("error when signature is too old", func(t *testing.T) {
+		req := newRequest()
+		require.NoError(t, SignRequest(req, "team-a", "secret-a", now.Add(-time.Hour)))
+
+		_, err := a.Authenticate(req)
+		require.EqualError(t, err, "hmac timestamp is out of allowed range")
+	})
+	t.Run("error when credential is unknown", func(t *testing.T) {
+		req := newRequest()
+		require.NoError(t, SignRequest(req, "team-b", "secret-a", now))
+
+		_, err := a.Authenticate(req)
+		require.EqualError(t, err, `unknown hmac credential "team-b"`)
+	})
+	t.Run("no credentials without signature", func(t *testing.T) {
+		req := newRequest()
+		req.Header.Set("Authorization", "Bearer token")
+
+		_, err := a.Authenticate(req)
+		require.True(t, errors.Is(err, ErrNoCredentials))
+	})
+}
+
+func TestLoadHMAC(t *testing.T) {
+	a, err := LoadHMAC(writeFile(t, "team-a secret-a\n"))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/secrets/local", nil)
+	require.NoError(t, SignRequest(req, "team-a", "secret-a", time.Now()))
+	identity, err := a.Authenticate(req)
+	require.NoError(t, err)
+	require.EqualValues(t, "team-a", identity)
+}
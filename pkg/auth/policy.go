@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Action is the kind of access to the key.
+type Action string
+
+// Actions granted by policy.
+const (
+	ActionRead   Action = "read"
+	ActionWrite  Action = "write"
+	ActionList   Action = "list"
+	ActionDelete Action = "delete"
+)
+
+// Authorizer checks that caller from the context can do action with the key.
+type Authorizer interface {
+	// Authorize returns ErrForbidden if action isn't granted
+	// and ErrUnauthenticated if context doesn't have identity.
+	Authorize(ctx context.Context, action Action, key string) error
+}
+
+// ActionAuthorizer checks that caller from the context can do action with some keys.
+// It's checked before work, which is needed for any key, like cipher key derivation of list requests.
+type ActionAuthorizer interface {
+	// AuthorizeAction returns ErrForbidden if action isn't granted on any key
+	// and ErrUnauthenticated if context doesn't have identity.
+	AuthorizeAction(ctx context.Context, action Action) error
+}
+
+// KeyAuthorizer checks that caller from the context can use server key by id, see keyring package.
+type KeyAuthorizer interface {
+	// AuthorizeKey returns ErrForbidden if key isn't granted
+	// and ErrUnauthenticated if context doesn't have identity.
+	AuthorizeKey(ctx context.Context, id string) error
+}
+
+// Rule grants actions on keys started with the prefix.
+// Empty prefix matches all keys.
+type Rule struct {
+	Prefix  string   `yaml:"prefix"`
+	Actions []Action `yaml:"actions"`
+}
+
+// Policy grants actions on key prefixes and server keys by id per identity.
+// Everything that isn't granted is forbidden.
+//
+// Example of policy file:
+//
+//    identities:
+//      team-a:
+//        - prefix: "team-a/"
+//          actions: [read, write, list, delete]
+//      ci:
+//        - prefix: "team-a/deploy/"
+//          actions: [read]
+//    keys:
+//      team-a: [payments]
+//      ci: [payments, billing]
+type Policy struct {
+	Identities map[string][]Rule `yaml:"identities"`
+	// Keys are ids of server keys granted per identity
+	Keys map[string][]string `yaml:"keys"`
+}
+
+// LoadPolicy reads policy from YAML file.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth: cannot read policy file: %w", err)
+	}
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("auth: cannot parse policy file: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate checks that policy has known actions only.
+func (p *Policy) Validate() error {
+	for identity, rules := range p.Identities {
+		for _, rule := range rules {
+			for _, action := range rule.Actions {
+				switch action {
+				case ActionRead, ActionWrite, ActionList, ActionDelete:
+				default:
+					return fmt.Errorf("auth: policy of %q: unknown action %q", identity, action)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Allowed reports whether identity can do action with the key.
+func (p *Policy) Allowed(identity string, action Action, key string) bool {
+	for _, rule := range p.Identities[identity] {
+		if !strings.HasPrefix(key, rule.Prefix) {
+			continue
+		}
+		for _, a := range rule.Actions {
+			if a == action {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AllowedAction reports whether identity can do action with any key.
+func (p *Policy) AllowedAction(identity string, action Action) bool {
+	for _, rule := range p.Identities[identity] {
+		for _, a := range rule.Actions {
+			if a == action {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AllowedKey reports whether identity can use server key by id.
+func (p *Policy) AllowedKey(identity, id string) bool {
+	for _, k := range p.Keys[identity] {
+		if k == id {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizeKey checks that identity from the context can use server key by id.
+func (p *Policy) AuthorizeKey(ctx context.Context, id string) error {
+	identity, ok := IdentityFromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if !p.AllowedKey(identity, id) {
+		return fmt.Errorf("%w: %s can't use key %q", ErrForbidden, identity, id)
+	}
+	return nil
+}
+
+// AuthorizeAction checks that identity from the context can do action with any key.
+func (p *Policy) AuthorizeAction(ctx context.Context, action Action) error {
+	identity, ok := IdentityFromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if !p.AllowedAction(identity, action) {
+		return fmt.Errorf("%w: %s can't %s any key", ErrForbidden, identity, action)
+	}
+	return nil
+}
+
+// Authorize checks that identity from the context can do action with the key.
+func (p *Policy) Authorize(ctx context.Context, action Action, key string) error {
+	identity, ok := IdentityFromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if !p.Allowed(identity, action, key) {
+		return fmt.Errorf("%w: %s can't %s %q", ErrForbidden, identity, action, key)
+	}
+	return nil
+}
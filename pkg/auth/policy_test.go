@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `
+identities:
+  team-a:
+    - prefix: "team-a/"
+      actions: [read, write, list, delete]
+  ci:
+    - prefix: "team-a/deploy/"
+      actions: [read]
+    - prefix: ""
+      actions: [list]
+keys:
+  ci: [payments]
+`
+
+func TestLoadPolicy(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		p, err := LoadPolicy(writeFile(t, testPolicy))
+		require.NoError(t, err)
+
+		require.True(t, p.Allowed("team-a", ActionWrite, "team-a/key"))
+		require.False(t, p.Allowed("team-a", ActionRead, "team-b/key"))
+		require.True(t, p.Allowed("ci", ActionRead, "team-a/deploy/token"))
+		require.False(t, p.Allowed("ci", ActionWrite, "team-a/deploy/token"))
+		require.False(t, p.Allowed("ci", ActionRead, "team-a/key"))
+		require.True(t, p.Allowed("ci", ActionList, "team-b/key"))
+		require.False(t, p.Allowed("unknown", ActionList, "team-b/key"))
+		require.True(t, p.AllowedKey("ci", "payments"))
+		require.False(t, p.AllowedKey("team-a", "payments"))
+	})
+	t.Run("error when action is unknown", func(t *testing.T) {
+		_, err := LoadPolicy(writeFile(t, "identities:\n  team-a:\n    - prefix: a\n      actions: [admin]\n"))
+		require.EqualError(t, err, `auth: policy of "team-a": unknown action "admin"`)
+	})
+	t.Run("error when file is invalid", func(t *testing.T) {
+		_, err := LoadPolicy(writeFile(t, "identities: ["))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cannot parse policy file")
+	})
+}
+
+func TestPolicy_Authorize(t *testing.T) {
+	p := &Policy{Identities: map[string][]Rule{
+		"team-a": {{Prefix: "team-a/", Actions: []Action{ActionRead}}},
+	}}
+
+	ctx := WithIdentity(context.Background(), "team-a")
+	require.NoError(t, p.Authorize(ctx, ActionRead, "team-a/key"))
+
+	err := p.Authorize(ctx, ActionDelete, "team-a/key")
+	require.True(t, errors.Is(err, ErrForbidden))
+	require.EqualError(t, err, `forbidden: team-a can't delete "team-a/key"`)
+
+	err = p.Authorize(context.Background(), ActionRead, "team-a/key")
+	require.True(t, errors.Is(err, ErrUnauthenticated))
+}
+
+func TestPolicy_AuthorizeAction(t *testing.T) {
+	p := &Policy{Identities: map[string][]Rule{
+		"team-a": {{Prefix: "team-a/", Actions: []Action{ActionRead, ActionList}}},
+	}}
+
+	ctx := WithIdentity(context.Background(), "team-a")
+	require.NoError(t, p.AuthorizeAction(ctx, ActionList))
+
+	err := p.AuthorizeAction(ctx, ActionWrite)
+	require.True(t, errors.Is(err, ErrForbidden))
+	require.EqualError(t, err, `forbidden: team-a can't write any key`)
+
+	err = p.AuthorizeAction(WithIdentity(context.Background(), "team-b"), ActionList)
+	require.True(t, errors.Is(err, ErrForbidden))
+
+	err = p.AuthorizeAction(context.Background(), ActionList)
+	require.True(t, errors.Is(err, ErrUnauthenticated))
+}
+
+func TestPolicy_AuthorizeKey(t *testing.T) {
+	p := &Policy{Keys: map[string][]string{"team-a": {"payments"}}}
+
+	ctx := WithIdentity(context.Background(), "team-a")
+	require.NoError(t, p.AuthorizeKey(ctx, "payments"))
+
+	err := p.AuthorizeKey(ctx, "billing")
+	require.True(t, errors.Is(err, ErrForbidden))
+	require.EqualError(t, err, `forbidden: team-a can't use key "billing"`)
+
+	err = p.AuthorizeKey(WithIdentity(context.Background(), "team-b"), "payments")
+	require.True(t, errors.Is(err, ErrForbidden))
+
+	err = p.AuthorizeKey(context.Background(), "payments")
+	require.True(t, errors.Is(err, ErrUnauthenticated))
+}
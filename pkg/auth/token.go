@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// BearerScheme is the scheme of Authorization header with static token.
+const BearerScheme = "Bearer"
+
+type tokens struct {
+	// identities by sha256 of token, so tokens are compared in constant time
+	identities map[[sha256.Size]byte]string
+}
+
+// NewTokens creates authenticator of static bearer tokens.
+// Accepts tokens by identity.
+//
+// Example of header:
+//
+//    Authorization: Bearer 1234-5678
+func NewTokens(tokensByIdentity map[string]string) (Authenticator, error) {
+	t := &tokens{identities: make(map[[sha256.Size]byte]string, len(tokensByIdentity))}
+	for identity, token := range tokensByIdentity {
+		if token == "" {
+			return nil, fmt.Errorf("auth: empty token of %q", identity)
+		}
+		sum := sha256.Sum256([]byte(token))
+		if _, ok := t.identities[sum]; ok {
+			return nil, fmt.Errorf("auth: token of %q is used by another identity", identity)
+		}
+		t.identities[sum] = identity
+	}
+	return t, nil
+}
+
+// LoadTokens creates authenticator of static bearer tokens from the file.
+// File has "identity token" pair per line, empty lines and lines started with "#" are skipped.
+func LoadTokens(path string) (Authenticator, error) {
+	tokensByIdentity, err := loadCredentials(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewTokens(tokensByIdentity)
+}
+
+func (t *tokens) Authenticate(r *http.Request) (string, error) {
+	token, ok := authorization(r, BearerScheme)
+	if !ok {
+		return "", ErrNoCredentials
+	}
+	sum := sha256.Sum256([]byte(token))
+	for s, identity := range t.identities {
+		if subtle.ConstantTimeCompare(s[:], sum[:]) == 1 {
+			return identity, nil
+		}
+	}
+	return "", errors.New("unknown bearer token")
+}
+
+// authorization returns credentials of Authorization header with the scheme.
+func authorization(r *http.Request, scheme string) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if len(header) <= len(scheme) || !strings.EqualFold(header[:len(scheme)], scheme) || header[len(scheme)] != ' ' {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(scheme)+1:]), true
+}
+
+// loadCredentials reads "identity secret" pairs from the file.
+func loadCredentials(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth: cannot open credentials file: %w", err)
+	}
+	defer f.Close()
+
+	credentials := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("auth: credentials file line %d: expected \"identity secret\"", n)
+		}
+		if _, ok := credentials[fields[0]]; ok {
+			return nil, fmt.Errorf("auth: credentials file line %d: duplicated identity %q", n, fields[0])
+		}
+		credentials[fields[0]] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("auth: cannot read credentials file: %w", err)
+	}
+	return credentials, nil
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTokens(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		a, err := LoadTokens(writeFile(t, "# teams\nteam-a token-a\n\nteam-b token-b\n"))
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer token-b")
+		identity, err := a.Authenticate(req)
+		require.NoError(t, err)
+		require.EqualValues(t, "team-b", identity)
+	})
+	t.Run("error when token is unknown", func(t *testing.T) {
+		a, err := LoadTokens(writeFile(t, "team-a token-a\n"))
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer token-b")
+		_, err = a.Authenticate(req)
+		require.Error(t, err)
+		require.False(t, errors.Is(err, ErrNoCredentials))
+	})
+	t.Run("no credentials without bearer token", func(t *testing.T) {
+		a, err := LoadTokens(writeFile(t, "team-a token-a\n"))
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		_, err = a.Authenticate(req)
+		require.True(t, errors.Is(err, ErrNoCredentials))
+
+		req.Header.Set("Authorization", "Bearertoken-a")
+		_, err = a.Authenticate(req)
+		require.True(t, errors.Is(err, ErrNoCredentials))
+	})
+	t.Run("error when line is invalid", func(t *testing.T) {
+		_, err := LoadTokens(writeFile(t, "team-a\n"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "line 1")
+	})
+	t.Run("error when token is shared", func(t *testing.T) {
+		_, err := LoadTokens(writeFile(t, "team-a token\nteam-b token\n"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is used by another identity")
+	})
+	t.Run("error when file doesn't exist", func(t *testing.T) {
+		_, err := LoadTokens("not-existing-file")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	api "github.com/go-itools-internship/go-secret/pkg/http"
+)
+
+// batchResult is the result of batch request for the key.
+type batchResult struct {
+	Key   string         `json:"key"`
+	Value string         `json:"value"`
+	Error *api.ErrorBody `json:"error"`
+}
+
+// GetBatch gets data from server by several keys of the method with single request.
+// Values of keys, which were got, are returned even if other keys failed, error lists failed keys.
+func (c *client) GetBatch(ctx context.Context, keys []string, method, cipherKey string) (map[string]string, error) {
+	results, err := c.batch(ctx, api.BatchGetRoute, map[string]interface{}{
+		"method": method,
+		"keys":   keys,
+	}, cipherKey)
+	if err != nil {
+		return nil, fmt.Errorf("secret client: can't get data: %w", err)
+	}
+	values := make(map[string]string, len(results))
+	for _, r := range results {
+		if r.Error == nil {
+			values[r.Key] = r.Value
+		}
+	}
+	return values, batchError("can't get data", results)
+}
+
+// SetBatch sets data to server by several keys of the method with single request.
+// Error lists keys, which weren't set.
+func (c *client) SetBatch(ctx context.Context, values map[string]string, method, cipherKey string) error {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	items := make([]map[string]string, 0, len(keys))
+	for _, k := range keys {
+		items = append(items, map[string]string{"key": k, "value": values[k]})
+	}
+	results, err := c.batch(ctx, api.BatchSetRoute, map[string]interface{}{
+		"method": method,
+		"items":  items,
+	}, cipherKey)
+	if err != nil {
+		return fmt.Errorf("secret client: can't set data: %w", err)
+	}
+	return batchError("can't set data", results)
+}
+
+// batch sends batch request to the route and returns results of keys.
+func (c *client) batch(ctx context.Context, route string, requestBody interface{}, cipherKey string) ([]batchResult, error) {
+	logger := c.logger.Named("batch")
+	postBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("can't marshal body %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.url, "/")+route, bytes.NewBuffer(postBody))
+	if err != nil {
+		return nil, fmt.Errorf("can't create request %w", err)
+	}
+	c.setKeyHeaders(req, cipherKey)
+
+	resp, err := c.options.c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("can't do request %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logger.Warnf("secret client: cannot close request body: %s", err.Error())
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		responseBody, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("can't get response body %w", err)
+		}
+		return nil, fmt.Errorf("body: %q, status code: %d", responseBody, resp.StatusCode)
+	}
+	var responseBody struct {
+		Results []batchResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&responseBody); err != nil {
+		return nil, fmt.Errorf("cannot decode body: %w", err)
+	}
+	return responseBody.Results, nil
+}
+
+// batchError returns error with failed keys of results or nil if there are no such keys.
+func batchError(message string, results []batchResult) error {
+	var failed []string
+	for _, r := range results {
+		if r.Error != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", r.Key, r.Error.Code))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return errors.New("secret client: " + message + " by keys: " + strings.Join(failed, ", "))
+}
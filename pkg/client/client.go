@@ -4,6 +4,8 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -23,7 +25,10 @@ type client struct {
 
 // options client
 type options struct {
-	c *http.Client
+	c         *http.Client
+	rootCAs   *x509.CertPool
+	clientCrt *tls.Certificate
+	keyID     string
 }
 
 var defaultOptions = options{
@@ -39,6 +44,28 @@ func Client(c *http.Client) Option {
 	}
 }
 
+// CA sets pool of CAs to verify server certificate. System CAs are used by default.
+func CA(pool *x509.CertPool) Option {
+	return func(options *options) {
+		options.rootCAs = pool
+	}
+}
+
+// ClientCert sets client certificate for servers with mutual TLS.
+func ClientCert(cert tls.Certificate) Option {
+	return func(options *options) {
+		options.clientCrt = &cert
+	}
+}
+
+// KeyID sets id of the key held by server.
+// Server holding the keys doesn't accept cipher keys, so empty cipher key should be passed to methods.
+func KeyID(id string) Option {
+	return func(options *options) {
+		options.keyID = id
+	}
+}
+
 // New function initializes a structure that provides client accessing functions.
 //
 // Accepts url where client will be work with server and client options.
@@ -47,6 +74,9 @@ func New(url string, logger *zap.SugaredLogger, opts ...Option) *client {
 	for _, opt := range opts {
 		opt(&options)
 	}
+	if options.rootCAs != nil || options.clientCrt != nil {
+		options.c = withTLS(options.c, options.rootCAs, options.clientCrt)
+	}
 	newClient := &client{options: options, url: url, logger: logger}
 	return newClient
 }
@@ -65,7 +95,7 @@ func (c *client) GetByKey(ctx context.Context, key, method, cipherKey string) (s
 	if err != nil {
 		return "", fmt.Errorf("secret client: can't create request %w", err)
 	}
-	req.Header.Set(api.ParamCipherKey, cipherKey)
+	c.setKeyHeaders(req, cipherKey)
 	query := req.URL.Query()
 	query.Set(api.ParamGetterKey, key)
 	query.Set(api.ParamMethodKey, method)
@@ -113,7 +143,7 @@ func (c *client) SetByKey(ctx context.Context, getterKey, value, method, cipherK
 	if err != nil {
 		return fmt.Errorf("secret client: can't create request %w", err)
 	}
-	req.Header.Set(api.ParamCipherKey, cipherKey)
+	c.setKeyHeaders(req, cipherKey)
 
 	resp, err := c.options.c.Do(req)
 	if err != nil {
@@ -133,3 +163,35 @@ func (c *client) SetByKey(ctx context.Context, getterKey, value, method, cipherK
 	}
 	return nil
 }
+
+// setKeyHeaders sets cipher key or key id of the request.
+func (c *client) setKeyHeaders(req *http.Request, cipherKey string) {
+	if cipherKey != "" {
+		req.Header.Set(api.ParamCipherKey, cipherKey)
+	}
+	if c.options.keyID != "" {
+		req.Header.Set(api.ParamKeyID, c.options.keyID)
+	}
+}
+
+// withTLS returns copy of http client, which uses CAs and client certificate.
+// Transport of the client is cloned, so the original client isn't changed.
+func withTLS(c *http.Client, rootCAs *x509.CertPool, clientCrt *tls.Certificate) *http.Client {
+	transport, ok := c.Transport.(*http.Transport)
+	if !ok || transport == nil {
+		transport = http.DefaultTransport.(*http.Transport)
+	}
+	transport = transport.Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if rootCAs != nil {
+		transport.TLSClientConfig.RootCAs = rootCAs
+	}
+	if clientCrt != nil {
+		transport.TLSClientConfig.Certificates = []tls.Certificate{*clientCrt}
+	}
+	copied := *c
+	copied.Transport = transport
+	return &copied
+}
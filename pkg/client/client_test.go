@@ -2,6 +2,8 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -12,6 +14,7 @@ import (
 	"go.uber.org/zap"
 
 	api "github.com/go-itools-internship/go-secret/pkg/http"
+	"github.com/go-itools-internship/go-secret/pkg/tlsconfig/tlstest"
 
 	"github.com/stretchr/testify/require"
 )
@@ -159,6 +162,62 @@ func TestClient_GetByKey(t *testing.T) {
 	})
 }
 
+func TestClient_KeyID(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.EqualValues(t, "payments", r.Header.Get(api.ParamKeyID))
+		require.NotContains(t, r.Header, http.CanonicalHeaderKey(api.ParamCipherKey))
+		_, err := w.Write([]byte(`{"value":"test-value"}`))
+		require.NoError(t, err)
+	}))
+	defer s.Close()
+
+	c := New(s.URL, createSugarLogger(), KeyID("payments"))
+	value, err := c.GetByKey(context.Background(), "key", "cloud", "")
+	require.NoError(t, err)
+	require.EqualValues(t, "test-value", value)
+}
+
+func TestClient_TLS(t *testing.T) {
+	ca := tlstest.NewCA(t, "test-ca")
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(ca.PEM))
+	serverCert, err := tls.X509KeyPair(ca.Issue(t, "server"))
+	require.NoError(t, err)
+	clientCert, err := tls.X509KeyPair(ca.Issue(t, "team-a"))
+	require.NoError(t, err)
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.EqualValues(t, "team-a", r.TLS.PeerCertificates[0].Subject.CommonName)
+		_, err := w.Write([]byte(`{"value":"test-value"}`))
+		require.NoError(t, err)
+	}))
+	s.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	s.StartTLS()
+	defer s.Close()
+
+	t.Run("success", func(t *testing.T) {
+		c := New(s.URL, createSugarLogger(), CA(pool), ClientCert(clientCert))
+		value, err := c.GetByKey(context.Background(), "key", "cloud", "c-key")
+		require.NoError(t, err)
+		require.EqualValues(t, "test-value", value)
+		require.Nil(t, defaultOptions.c.Transport, "default client shouldn't be changed")
+	})
+	t.Run("error without client certificate", func(t *testing.T) {
+		c := New(s.URL, createSugarLogger(), CA(pool))
+		_, err := c.GetByKey(context.Background(), "key", "cloud", "c-key")
+		require.Error(t, err)
+	})
+	t.Run("error with unknown ca", func(t *testing.T) {
+		c := New(s.URL, createSugarLogger(), ClientCert(clientCert))
+		_, err := c.GetByKey(context.Background(), "key", "cloud", "c-key")
+		require.Error(t, err)
+	})
+}
+
 func createSugarLogger() *zap.SugaredLogger {
 	logger, err := zap.NewProduction()
 	if err != nil {
@@ -167,3 +226,66 @@ func createSugarLogger() *zap.SugaredLogger {
 	sugar := logger.Sugar()
 	return sugar
 }
+
+func TestClient_Batch(t *testing.T) {
+	cipherKey := "c-key"
+	t.Run("get", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.EqualValues(t, api.BatchGetRoute, r.URL.Path)
+			require.EqualValues(t, http.MethodPost, r.Method)
+			require.EqualValues(t, cipherKey, r.Header.Get(api.ParamCipherKey))
+			var requestBody struct {
+				Method string   `json:"method"`
+				Keys   []string `json:"keys"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&requestBody))
+			require.EqualValues(t, "cloud", requestBody.Method)
+			require.EqualValues(t, []string{"first", "missing"}, requestBody.Keys)
+			_, err := w.Write([]byte(`{"results":[{"key":"first","value":"value 1"},{"key":"missing","error":{"code":"not_found","message":"not found"}}]}`))
+			require.NoError(t, err)
+		}))
+		defer s.Close()
+
+		c := New(s.URL, createSugarLogger())
+		values, err := c.GetBatch(context.Background(), []string{"first", "missing"}, "cloud", cipherKey)
+		require.Error(t, err)
+		require.EqualValues(t, "secret client: can't get data by keys: missing: not_found", err.Error())
+		require.EqualValues(t, map[string]string{"first": "value 1"}, values)
+	})
+	t.Run("set", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.EqualValues(t, api.BatchSetRoute, r.URL.Path)
+			var requestBody struct {
+				Method string `json:"method"`
+				Items  []struct {
+					Key   string `json:"key"`
+					Value string `json:"value"`
+				} `json:"items"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&requestBody))
+			require.Len(t, requestBody.Items, 2)
+			require.EqualValues(t, "first", requestBody.Items[0].Key)
+			require.EqualValues(t, "value 1", requestBody.Items[0].Value)
+			_, err := w.Write([]byte(`{"results":[{"key":"first"},{"key":"second"}]}`))
+			require.NoError(t, err)
+		}))
+		defer s.Close()
+
+		c := New(s.URL+"/", createSugarLogger())
+		err := c.SetBatch(context.Background(), map[string]string{"second": "value 2", "first": "value 1"}, "cloud", cipherKey)
+		require.NoError(t, err)
+	})
+	t.Run("error if wrong status code", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, err := w.Write([]byte("test bad request body"))
+			require.NoError(t, err)
+		}))
+		defer s.Close()
+
+		c := New(s.URL, createSugarLogger())
+		_, err := c.GetBatch(context.Background(), []string{"first"}, "cloud", cipherKey)
+		require.Error(t, err)
+		require.EqualValues(t, "secret client: can't get data: body: \"test bad request body\", status code: 400", err.Error())
+	})
+}
@@ -1,81 +1,210 @@
 /*
-	Package crypto provides functions to encode and decode data
-	Using aes crypto.
-	Package crypto contain custom implementation of io Reader.
-	Using for read data many times without changes.
+Package crypto provides functions to encode and decode data
+Using aes crypto.
+Values are encrypted with random nonce, key names are encrypted deterministically
+with synthetic nonce, so they can be found and listed later.
+Encrypted data is wrapped into self-describing envelope, see envelope.go.
+Package crypto contain custom implementation of io Reader.
+Using for read data many times without changes.
 */
 package crypto
 
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
 )
 
+// keyNonceLabel is used to derive the key for synthetic nonces of key names,
+// so the same secret isn't used both as AES key and as HMAC key.
+const keyNonceLabel = "go-secret key name nonce"
+
+// keyIDLabel is used to derive public identifier of the key.
+const keyIDLabel = "go-secret key id"
+
 type cryptographer struct {
 	key         []byte
+	nonceKey    []byte
+	keyID       []byte
 	nonceReader io.Reader
 }
 
+// NewCryptographer creates cryptographer for the key.
+// Key is hashed with sha256 without salt, so use NewCryptographerKDF for human passwords.
+// Nonce reader is used to encode values and should be crypto/rand.Reader
+// everywhere except tests, because nonce must never be repeated for one key.
 func NewCryptographer(key []byte, nonceReader io.Reader) *cryptographer {
 	h := sha256.New()
 	h.Write(key)
 	key32 := make([]byte, 32)
 	copy(key32, h.Sum(nil))
+	return newCryptographer(key32, nonceReader)
+}
+
+func newCryptographer(key32 []byte, nonceReader io.Reader) *cryptographer {
+	nonceMac := hmac.New(sha256.New, key32)
+	nonceMac.Write([]byte(keyNonceLabel))
+	idMac := hmac.New(sha256.New, key32)
+	idMac.Write([]byte(keyIDLabel))
 	return &cryptographer{
 		key:         key32,
+		nonceKey:    nonceMac.Sum(nil),
+		keyID:       idMac.Sum(nil)[:keyIDSize],
 		nonceReader: nonceReader,
 	}
 }
 
+// Encode encrypts value with random nonce and wraps it into the envelope.
 func (c *cryptographer) Encode(value []byte) ([]byte, error) {
-	// Create a new Cipher Block from the key
-	block, err := aes.NewCipher(c.key)
+	aesGCM, err := c.aead()
 	if err != nil {
-		return nil, fmt.Errorf("cryptographer, encode method: invalid key: %w", err)
-	}
-
-	aesGCM, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, fmt.Errorf("cryptographer, encode method: invalid size: %w", err)
+		return nil, fmt.Errorf("cryptographer, encode method: %w", err)
 	}
 	// Create a nonce. Nonce should be from GCM
 	nonce := make([]byte, aesGCM.NonceSize())
-
 	if _, err = io.ReadFull(c.nonceReader, nonce); err != nil {
 		return nil, fmt.Errorf("cryptographer, encode method: unexpected data: %w", err)
 	}
-	// Encrypt the data using aesGCM.Seal
-	// Since we don't want to save the nonce somewhere else in this case,
-	// we add it as a prefix to the encrypted data. The first nonce argument in Seal is the prefix.
-	ciphertext := aesGCM.Seal(nonce, nonce, value, nil)
-
-	return ciphertext, nil
+	return c.seal(aesGCM, SuiteAES256GCM, nonce, value), nil
 }
 
+// Decode decrypts value encrypted with Encode.
+// Values encrypted by previous versions without envelope are decrypted as well.
 func (c *cryptographer) Decode(encodedValue []byte) ([]byte, error) {
 	if encodedValue == nil {
 		return nil, nil
 	}
+	aesGCM, err := c.aead()
+	if err != nil {
+		return nil, fmt.Errorf("cryptographer, decode method: %w", err)
+	}
+	e, ok, err := parseEnvelope(encodedValue, aesGCM.NonceSize())
+	if !ok {
+		plaintext, lErr := openLegacy(aesGCM, encodedValue)
+		if lErr != nil {
+			return nil, fmt.Errorf("cryptographer, decode method: %w", lErr)
+		}
+		return plaintext, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cryptographer, decode method: %w", err)
+	}
+	if e.suite != SuiteAES256GCM {
+		return nil, fmt.Errorf("cryptographer, decode method: unexpected cipher suite %d", e.suite)
+	}
+	plaintext, err := c.open(aesGCM, e)
+	if err != nil {
+		return nil, fmt.Errorf("cryptographer, decode method: %w", err)
+	}
+	return plaintext, nil
+}
+
+// EncodeKey encrypts key name deterministically, so equal names give equal results.
+// Nonce is HMAC-SHA256 of the name (synthetic IV), so different names never share nonce.
+func (c *cryptographer) EncodeKey(key []byte) ([]byte, error) {
+	aesGCM, err := c.aead()
+	if err != nil {
+		return nil, fmt.Errorf("cryptographer, encode key method: %w", err)
+	}
+	nonce := c.keyNonce(key)[:aesGCM.NonceSize()]
+	return c.seal(aesGCM, SuiteAES256GCMSynthetic, nonce, key), nil
+}
+
+// DecodeKey decrypts key name encrypted with EncodeKey.
+// Returns error if key name wasn't encrypted by EncodeKey with the same cipher key.
+// Key names encrypted by previous versions without envelope are decrypted as well.
+func (c *cryptographer) DecodeKey(encodedKey []byte) ([]byte, error) {
+	aesGCM, err := c.aead()
+	if err != nil {
+		return nil, fmt.Errorf("cryptographer, decode key method: %w", err)
+	}
+	var key, nonce []byte
+	e, ok, err := parseEnvelope(encodedKey, aesGCM.NonceSize())
+	switch {
+	case !ok:
+		if key, err = openLegacy(aesGCM, encodedKey); err != nil {
+			return nil, fmt.Errorf("cryptographer, decode key method: %w", err)
+		}
+		nonce = encodedKey[:aesGCM.NonceSize()]
+	case err != nil:
+		return nil, fmt.Errorf("cryptographer, decode key method: %w", err)
+	case e.suite != SuiteAES256GCMSynthetic:
+		return nil, fmt.Errorf("cryptographer, decode key method: unexpected cipher suite %d", e.suite)
+	default:
+		if key, err = c.open(aesGCM, e); err != nil {
+			return nil, fmt.Errorf("cryptographer, decode key method: %w", err)
+		}
+		nonce = e.nonce
+	}
+	if !hmac.Equal(nonce, c.keyNonce(key)[:aesGCM.NonceSize()]) {
+		return nil, errors.New("cryptographer, decode key method: key wasn't encoded with synthetic nonce")
+	}
+	return key, nil
+}
+
+// KeyID returns identifier of the cipher key, which is saved in every envelope.
+// It's safe to show key id, because it doesn't reveal the key.
+func (c *cryptographer) KeyID() []byte {
+	id := make([]byte, keyIDSize)
+	copy(id, c.keyID)
+	return id
+}
+
+func (c *cryptographer) aead() (cipher.AEAD, error) {
+	// Create a new Cipher Block from the key
 	block, err := aes.NewCipher(c.key)
 	if err != nil {
-		return nil, fmt.Errorf("cryptographer, decode method: invalid key: %w", err)
+		return nil, fmt.Errorf("invalid key: %w", err)
 	}
 	aesGCM, err := cipher.NewGCM(block)
 	if err != nil {
-		return nil, fmt.Errorf("cryptographer, decode method: invalid size: %w", err)
+		return nil, fmt.Errorf("invalid size: %w", err)
 	}
-	// Get the nonce size
+	return aesGCM, nil
+}
+
+// seal encrypts plaintext and prepends envelope header.
+// Header is authenticated as additional data, so it can't be changed unnoticed.
+func (c *cryptographer) seal(aesGCM cipher.AEAD, suite byte, nonce, plaintext []byte) []byte {
+	out := appendHeader(make([]byte, 0, headerSize+len(nonce)+len(plaintext)+aesGCM.Overhead()), suite, c.keyID)
+	out = append(out, nonce...)
+	return aesGCM.Seal(out, nonce, plaintext, out[:headerSize])
+}
+
+func (c *cryptographer) open(aesGCM cipher.AEAD, e envelope) ([]byte, error) {
+	if !hmac.Equal(e.keyID, c.keyID) {
+		return nil, fmt.Errorf("%w: data was encrypted with another key %x", secret.ErrDecrypt, e.keyID)
+	}
+	plaintext, err := aesGCM.Open(nil, e.nonce, e.ciphertext, e.header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", secret.ErrDecrypt, err)
+	}
+	return plaintext, nil
+}
+
+// openLegacy decrypts data encrypted without envelope: nonce followed by ciphertext.
+func openLegacy(aesGCM cipher.AEAD, data []byte) ([]byte, error) {
 	nonceSize := aesGCM.NonceSize()
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("%w: encoded value is too short", secret.ErrDecrypt)
+	}
 	// Extract the nonce from the encrypted data
-	nonce, ciphertext := encodedValue[:nonceSize], encodedValue[nonceSize:]
-	// Decrypt the data
+	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
 	plaintext, err := aesGCM.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
-		return nil, fmt.Errorf("cryptographer, decode method: decryption error: %w", err)
+		return nil, fmt.Errorf("%w: %s", secret.ErrDecrypt, err)
 	}
-
 	return plaintext, nil
 }
+
+func (c *cryptographer) keyNonce(key []byte) []byte {
+	mac := hmac.New(sha256.New, c.nonceKey)
+	mac.Write(key)
+	return mac.Sum(nil)
+}
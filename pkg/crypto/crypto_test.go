@@ -2,23 +2,31 @@ package crypto
 
 import (
 	"bytes"
+	"crypto/rand"
 	"encoding/hex"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 var tests = []struct {
-	name  string
-	key   []byte
-	value []byte
-	want  string
+	name   string
+	key    []byte
+	value  []byte
+	want   string
+	legacy string
 }{
 	{"encode/decode value 1", []byte("I am the key"), []byte("All i need is love"),
+		"475345430101082f1b03efac1ff9000000000000000000000000a285210979aab1707d6215a0eba48236698be5d5bfd671aef0f4e192650c3b18cf72",
 		"000000000000000000000000a285210979aab1707d6215a0eba48236698b06fb4a20005ed0e5e24b538ca5e65107"},
 	{"encode/decode value 2", []byte("I am another key"), []byte("All i need is love love love"),
+		"4753454301014eecca8889ad71f3000000000000000000000000a10c2db816d251e8242981a044d0452bd8abcad891a78a75ab0af64440924ced30929627097e25c6fe688f27",
 		"000000000000000000000000a10c2db816d251e8242981a044d0452bd8abcad891a78a75ab0af64444318cbe4c28fe88f4acab3c4e347827"},
 	{"encode/decode with key match more than 32", []byte("werwewtwtwrtrtert55tttttttttttttggggggggggggrt56456hfghfhj$34g"), []byte("All i need is love"),
+		"475345430101224186160ea85593000000000000000000000000918d52bfe6c8cd7898f5c2b7bd62b71ac34c24db029fd86e71d8965357996950d610",
 		"000000000000000000000000918d52bfe6c8cd7898f5c2b7bd62b71ac34cdd8b177858493ac6184e23fe3188ea14"},
 	{"empty key and value", []byte(""), []byte(""),
+		"475345430101827c49d1d8a93d83000000000000000000000000834d15b5a7d967c183578a2555df18f9",
 		"000000000000000000000000d51ed6081edb98739080fbe09ec476fb"},
 }
 
@@ -46,13 +54,133 @@ func TestCryptographer_Decode(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			decode := NewCryptographer(tt.key, &loopReader{})
-			got, err := decode.Decode([]byte(tt.want))
-			if err != nil {
-				return
-			}
-			if !bytes.Equal(got, tt.value) {
-				t.Errorf(string(got), tt.want)
+			for _, encoded := range []string{tt.want, tt.legacy} {
+				data, err := hex.DecodeString(encoded)
+				require.NoError(t, err)
+				got, err := decode.Decode(data)
+				require.NoError(t, err)
+				if !bytes.Equal(got, tt.value) {
+					t.Errorf(string(got), tt.want)
+				}
 			}
 		})
 	}
 }
+
+func TestCryptographer_Envelope(t *testing.T) {
+	c := NewCryptographer([]byte("I am the key"), rand.Reader)
+	encoded, err := c.Encode([]byte("All i need is love"))
+	require.NoError(t, err)
+	require.EqualValues(t, "GSEC", string(encoded[:4]))
+	require.EqualValues(t, EnvelopeVersion, encoded[4])
+	require.EqualValues(t, SuiteAES256GCM, encoded[5])
+	require.EqualValues(t, c.KeyID(), encoded[6:14])
+
+	t.Run("error if header is changed", func(t *testing.T) {
+		tampered := append([]byte(nil), encoded...)
+		tampered[5] = SuiteAES256GCMSynthetic
+		_, err := c.DecodeKey(tampered)
+		require.Error(t, err)
+	})
+	t.Run("error if version is unknown", func(t *testing.T) {
+		tampered := append([]byte(nil), encoded...)
+		tampered[4] = EnvelopeVersion + 1
+		_, err := c.Decode(tampered)
+		require.Error(t, err)
+		require.EqualValues(t, "cryptographer, decode method: unsupported envelope version 2", err.Error())
+	})
+	t.Run("error if encrypted with another key", func(t *testing.T) {
+		other := NewCryptographer([]byte("I am another key"), rand.Reader)
+		_, err := other.Decode(encoded)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "data was encrypted with another key")
+	})
+	t.Run("error if envelope is too short", func(t *testing.T) {
+		_, err := c.Decode(encoded[:20])
+		require.Error(t, err)
+	})
+}
+
+func TestCryptographer_DecodeShortValue(t *testing.T) {
+	decode := NewCryptographer([]byte("I am the key"), &loopReader{})
+	got, err := decode.Decode([]byte{1, 2, 3})
+	if err == nil {
+		t.Errorf("expected error for short value, got %q", got)
+	}
+}
+
+func TestCryptographer_EncodeRandomNonce(t *testing.T) {
+	c := NewCryptographer([]byte("I am the key"), rand.Reader)
+	first, err := c.Encode([]byte("All i need is love"))
+	require.NoError(t, err)
+	second, err := c.Encode([]byte("All i need is love"))
+	require.NoError(t, err)
+	require.NotEqual(t, first, second)
+
+	for _, encoded := range [][]byte{first, second} {
+		got, err := c.Decode(encoded)
+		require.NoError(t, err)
+		require.EqualValues(t, "All i need is love", string(got))
+	}
+}
+
+func TestCryptographer_EncodeKey(t *testing.T) {
+	t.Run("same result for same key", func(t *testing.T) {
+		c := NewCryptographer([]byte("I am the key"), rand.Reader)
+		first, err := c.EncodeKey([]byte("key name"))
+		require.NoError(t, err)
+		second, err := c.EncodeKey([]byte("key name"))
+		require.NoError(t, err)
+		require.EqualValues(t, first, second)
+
+		got, err := c.DecodeKey(first)
+		require.NoError(t, err)
+		require.EqualValues(t, "key name", string(got))
+	})
+	t.Run("different nonce for different keys", func(t *testing.T) {
+		c := NewCryptographer([]byte("I am the key"), rand.Reader)
+		first, err := c.EncodeKey([]byte("first"))
+		require.NoError(t, err)
+		second, err := c.EncodeKey([]byte("second"))
+		require.NoError(t, err)
+		require.NotEqual(t, first[headerSize:headerSize+12], second[headerSize:headerSize+12])
+	})
+	t.Run("error if decode with wrong cipher key", func(t *testing.T) {
+		encoded, err := NewCryptographer([]byte("I am the key"), rand.Reader).EncodeKey([]byte("key name"))
+		require.NoError(t, err)
+		_, err = NewCryptographer([]byte("I am another key"), rand.Reader).DecodeKey(encoded)
+		require.Error(t, err)
+	})
+	t.Run("error if key encoded as value", func(t *testing.T) {
+		c := NewCryptographer([]byte("I am the key"), LoopReader([]byte("legacy nonce")))
+		encoded, err := c.Encode([]byte("key name"))
+		require.NoError(t, err)
+		_, err = c.DecodeKey(encoded)
+		require.Error(t, err)
+		require.EqualValues(t, "cryptographer, decode key method: unexpected cipher suite 1", err.Error())
+	})
+	t.Run("error if legacy key encoded as value", func(t *testing.T) {
+		c := NewCryptographer([]byte("I am the key"), LoopReader([]byte("legacy nonce")))
+		aesGCM, err := c.aead()
+		require.NoError(t, err)
+		nonce := []byte("legacy nonce")
+		encoded := aesGCM.Seal(nonce, nonce, []byte("key name"), nil)
+		_, err = c.DecodeKey(encoded)
+		require.Error(t, err)
+		require.EqualValues(t, "cryptographer, decode key method: key wasn't encoded with synthetic nonce", err.Error())
+	})
+	t.Run("decode legacy key", func(t *testing.T) {
+		c := NewCryptographer([]byte("I am the key"), rand.Reader)
+		aesGCM, err := c.aead()
+		require.NoError(t, err)
+		nonce := c.keyNonce([]byte("key name"))[:aesGCM.NonceSize()]
+		encoded := aesGCM.Seal(append([]byte(nil), nonce...), nonce, []byte("key name"), nil)
+		got, err := c.DecodeKey(encoded)
+		require.NoError(t, err)
+		require.EqualValues(t, "key name", string(got))
+	})
+	t.Run("error if encoded key is too short", func(t *testing.T) {
+		_, err := NewCryptographer([]byte("I am the key"), rand.Reader).DecodeKey([]byte{1, 2})
+		require.Error(t, err)
+	})
+}
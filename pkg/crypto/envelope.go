@@ -0,0 +1,69 @@
+package crypto
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// Envelope layout:
+//
+//	| magic "GSEC" (4) | version (1) | suite (1) | key id (8) | nonce | ciphertext with tag |
+//
+// Nonce size depends on the suite. Magic, version, suite and key id form the header,
+// which is authenticated as additional data of AEAD.
+const (
+	// EnvelopeVersion is the current version of envelope format.
+	EnvelopeVersion = 1
+
+	// SuiteAES256GCM is AES-256-GCM with random 96-bit nonce. It's used for values.
+	SuiteAES256GCM = 1
+	// SuiteAES256GCMSynthetic is AES-256-GCM with nonce derived from plaintext by HMAC-SHA256.
+	// It's deterministic and is used for key names.
+	SuiteAES256GCMSynthetic = 2
+
+	magic      = "GSEC"
+	keyIDSize  = 8
+	headerSize = len(magic) + 1 + 1 + keyIDSize
+)
+
+var envelopeMagic = []byte(magic)
+
+type envelope struct {
+	header     []byte
+	version    byte
+	suite      byte
+	keyID      []byte
+	nonce      []byte
+	ciphertext []byte
+}
+
+func appendHeader(dst []byte, suite byte, keyID []byte) []byte {
+	dst = append(dst, envelopeMagic...)
+	dst = append(dst, EnvelopeVersion, suite)
+	return append(dst, keyID...)
+}
+
+// parseEnvelope splits data into envelope parts.
+// Returns false if data has no envelope magic, so it should be treated as legacy data.
+func parseEnvelope(data []byte, nonceSize int) (envelope, bool, error) {
+	if !bytes.HasPrefix(data, envelopeMagic) {
+		return envelope{}, false, nil
+	}
+	if len(data) < headerSize+nonceSize {
+		return envelope{}, true, fmt.Errorf("%w: envelope is too short", secret.ErrDecrypt)
+	}
+	e := envelope{
+		header:  data[:headerSize],
+		version: data[len(envelopeMagic)],
+		suite:   data[len(envelopeMagic)+1],
+		keyID:   data[len(envelopeMagic)+2 : headerSize],
+	}
+	if e.version != EnvelopeVersion {
+		return envelope{}, true, fmt.Errorf("unsupported envelope version %d", e.version)
+	}
+	e.nonce = data[headerSize : headerSize+nonceSize]
+	e.ciphertext = data[headerSize+nonceSize:]
+	return e, true, nil
+}
@@ -0,0 +1,79 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// KDFScrypt derives cipher key from passphrase with scrypt and random salt.
+	KDFScrypt = "scrypt"
+	// KDFLegacy derives cipher key as unsalted sha256 of passphrase.
+	// It is used only to open vaults created by previous versions.
+	KDFLegacy = "sha256"
+
+	// DefaultScryptCost is log2 of scrypt N parameter used for new vaults.
+	DefaultScryptCost = 15
+
+	scryptR  = 8
+	scryptP  = 1
+	saltSize = 16
+	keySize  = 32
+)
+
+// KDFParams describes how the cipher key is derived from the passphrase.
+// Params are not secret and are stored next to encrypted data, so the key can be derived again.
+type KDFParams struct {
+	Name string `json:"name"`
+	Salt []byte `json:"salt,omitempty"`
+	N    int    `json:"n,omitempty"`
+	R    int    `json:"r,omitempty"`
+	P    int    `json:"p,omitempty"`
+}
+
+// NewScryptParams creates scrypt params with random salt read from saltReader.
+// Cost is log2 of N parameter: each increment doubles time and memory to derive the key.
+func NewScryptParams(saltReader io.Reader, cost int) (KDFParams, error) {
+	if cost < 1 || cost > 30 {
+		return KDFParams{}, fmt.Errorf("kdf: scrypt cost should be in range [1, 30], got %d", cost)
+	}
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(saltReader, salt); err != nil {
+		return KDFParams{}, fmt.Errorf("kdf: can't read salt: %w", err)
+	}
+	return KDFParams{Name: KDFScrypt, Salt: salt, N: 1 << cost, R: scryptR, P: scryptP}, nil
+}
+
+// LegacyParams returns params of key derivation used by previous versions.
+func LegacyParams() KDFParams {
+	return KDFParams{Name: KDFLegacy}
+}
+
+// DeriveKey derives 32 bytes key for AES-256 from passphrase.
+func (p KDFParams) DeriveKey(passphrase []byte) ([]byte, error) {
+	switch p.Name {
+	case KDFScrypt:
+		key, err := scrypt.Key(passphrase, p.Salt, p.N, p.R, p.P, keySize)
+		if err != nil {
+			return nil, fmt.Errorf("kdf: can't derive key: %w", err)
+		}
+		return key, nil
+	case KDFLegacy:
+		h := sha256.Sum256(passphrase)
+		return h[:], nil
+	default:
+		return nil, fmt.Errorf("kdf: unsupported key derivation function %q", p.Name)
+	}
+}
+
+// NewCryptographerKDF creates cryptographer for the key derived from passphrase with params.
+func NewCryptographerKDF(passphrase []byte, params KDFParams, nonceReader io.Reader) (*cryptographer, error) {
+	key, err := params.DeriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return newCryptographer(key, nonceReader), nil
+}
@@ -0,0 +1,58 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKDFParams_DeriveKey(t *testing.T) {
+	t.Run("same key for same salt", func(t *testing.T) {
+		params, err := NewScryptParams(rand.Reader, 4)
+		require.NoError(t, err)
+		require.EqualValues(t, KDFScrypt, params.Name)
+		require.EqualValues(t, 16, params.N)
+		require.Len(t, params.Salt, 16)
+
+		first, err := params.DeriveKey([]byte("password"))
+		require.NoError(t, err)
+		second, err := params.DeriveKey([]byte("password"))
+		require.NoError(t, err)
+		require.Len(t, first, 32)
+		require.EqualValues(t, first, second)
+	})
+	t.Run("different key for different salt", func(t *testing.T) {
+		first, err := NewScryptParams(bytes.NewReader(bytes.Repeat([]byte{1}, 16)), 4)
+		require.NoError(t, err)
+		second, err := NewScryptParams(bytes.NewReader(bytes.Repeat([]byte{2}, 16)), 4)
+		require.NoError(t, err)
+
+		firstKey, err := first.DeriveKey([]byte("password"))
+		require.NoError(t, err)
+		secondKey, err := second.DeriveKey([]byte("password"))
+		require.NoError(t, err)
+		require.NotEqual(t, firstKey, secondKey)
+	})
+	t.Run("legacy key is compatible with NewCryptographer", func(t *testing.T) {
+		legacy, err := NewCryptographerKDF([]byte("I am the key"), LegacyParams(), &loopReader{})
+		require.NoError(t, err)
+		encoded, err := NewCryptographer([]byte("I am the key"), &loopReader{}).Encode([]byte("All i need is love"))
+		require.NoError(t, err)
+
+		got, err := legacy.Decode(encoded)
+		require.NoError(t, err)
+		require.EqualValues(t, "All i need is love", string(got))
+	})
+	t.Run("error if kdf is unsupported", func(t *testing.T) {
+		_, err := KDFParams{Name: "md5"}.DeriveKey([]byte("password"))
+		require.Error(t, err)
+		require.EqualValues(t, `kdf: unsupported key derivation function "md5"`, err.Error())
+	})
+	t.Run("error if cost is out of range", func(t *testing.T) {
+		_, err := NewScryptParams(rand.Reader, 31)
+		require.Error(t, err)
+		require.EqualValues(t, "kdf: scrypt cost should be in range [1, 30], got 31", err.Error())
+	})
+}
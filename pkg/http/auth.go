@@ -0,0 +1,67 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-itools-internship/go-secret/pkg/auth"
+)
+
+// Authenticate returns middleware, which authenticates callers with the authenticator.
+// Identity of the caller is saved into request context.
+// Requests without valid credentials are rejected with status 401.
+func (a *methods) Authenticate(authenticator auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, err := authenticator.Authenticate(r)
+			if err != nil {
+				a.writeErrorResponse(w, r, http.StatusUnauthorized, fmt.Errorf("cannot authenticate: %w", err))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), identity)))
+		})
+	}
+}
+
+// authorize checks that caller can do action with the key of the method and writes error response if it can't.
+func (a *methods) authorize(w http.ResponseWriter, r *http.Request, method string, action auth.Action, key string) bool {
+	if err := a.access(r, method, action, key); err != nil {
+		a.writeErrorResponse(w, r, errorStatus(err), fmt.Errorf("cannot authorize: %w", err))
+		return false
+	}
+	return true
+}
+
+// allowed reports whether caller can do action with the key of the method.
+func (a *methods) allowed(r *http.Request, method string, action auth.Action, key string) bool {
+	return a.access(r, method, action, key) == nil
+}
+
+// access returns error of authorizers if caller can't do action with the key of the method.
+func (a *methods) access(r *http.Request, method string, action auth.Action, key string) error {
+	if a.options.authorizer != nil {
+		if err := a.options.authorizer.Authorize(r.Context(), action, key); err != nil {
+			return err
+		}
+	}
+	if az, ok := a.options.methodAuthorizers[method]; ok {
+		return az.Authorize(r.Context(), action, key)
+	}
+	return nil
+}
+
+// authorizeAction checks that caller can do action with some keys of the method and writes error response if it can't.
+// Only authorizers, which are auth.ActionAuthorizer, are checked, others are checked by key later.
+func (a *methods) authorizeAction(w http.ResponseWriter, r *http.Request, method string, action auth.Action) bool {
+	for _, az := range []auth.Authorizer{a.options.authorizer, a.options.methodAuthorizers[method]} {
+		aa, ok := az.(auth.ActionAuthorizer)
+		if !ok {
+			continue
+		}
+		if err := aa.AuthorizeAction(r.Context(), action); err != nil {
+			a.writeErrorResponse(w, r, errorStatus(err), fmt.Errorf("cannot authorize: %w", err))
+			return false
+		}
+	}
+	return true
+}
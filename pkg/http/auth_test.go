@@ -0,0 +1,124 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/auth"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func newTestAuthRoutes(t *testing.T, p secret.Provider) *httptest.Server {
+	tokens, err := auth.NewTokens(map[string]string{"team-a": "token-a"})
+	require.NoError(t, err)
+	policy := &auth.Policy{Identities: map[string][]auth.Rule{
+		"team-a": {{Prefix: "team-a/", Actions: []auth.Action{auth.ActionRead, auth.ActionWrite, auth.ActionList}}},
+	}}
+	a := NewMethods(map[string]MethodFactoryFunc{
+		"test-method": func(cipher string) (secret.Provider, func()) {
+			return p, nil
+		},
+	}, createSugarLogger(), Authorizer(policy))
+	router := chi.NewRouter()
+	router.With(a.Authenticate(tokens)).Mount("/", a.Routes())
+	return httptest.NewServer(router)
+}
+
+func TestAuthenticate(t *testing.T) {
+	doAuthRequest := func(t *testing.T, s *httptest.Server, method, target, token string) (*http.Response, string) {
+		req, err := http.NewRequest(method, s.URL+target, bytes.NewBufferString(`{"value":"test-value-1"}`))
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := s.Client().Do(req)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, resp.Body.Close())
+		}()
+		var b bytes.Buffer
+		_, err = b.ReadFrom(resp.Body)
+		require.NoError(t, err)
+		return resp, b.String()
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		defer mockProvider.AssertExpectations(t)
+		mockProvider.On("SetData", []byte("team-a/key"), []byte("test-value-1")).Return(nil).Once()
+		s := newTestAuthRoutes(t, mockProvider)
+		defer s.Close()
+
+		resp, _ := doAuthRequest(t, s, http.MethodPut, "/test-method/team-a/key", "token-a")
+		require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+	})
+	t.Run("error without credentials", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		defer mockProvider.AssertExpectations(t)
+		s := newTestAuthRoutes(t, mockProvider)
+		defer s.Close()
+
+		resp, body := doAuthRequest(t, s, http.MethodGet, "/test-method/team-a/key", "")
+		require.EqualValues(t, http.StatusUnauthorized, resp.StatusCode)
+		require.EqualValues(t, `{"error":{"code":"unauthorized","message":"cannot authenticate: no credentials"}}`+jsonTerminator, body)
+	})
+	t.Run("error when token is unknown", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		defer mockProvider.AssertExpectations(t)
+		s := newTestAuthRoutes(t, mockProvider)
+		defer s.Close()
+
+		resp, _ := doAuthRequest(t, s, http.MethodGet, "/test-method/team-a/key", "token-b")
+		require.EqualValues(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+	t.Run("error when action is forbidden", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		defer mockProvider.AssertExpectations(t)
+		s := newTestAuthRoutes(t, mockProvider)
+		defer s.Close()
+
+		resp, body := doAuthRequest(t, s, http.MethodGet, "/test-method/team-b/key", "token-a")
+		require.EqualValues(t, http.StatusForbidden, resp.StatusCode)
+		require.EqualValues(t, `{"error":{"code":"forbidden","message":"cannot authorize: forbidden: team-a can't read \"team-b/key\""}}`+jsonTerminator, body)
+
+		resp, _ = doAuthRequest(t, s, http.MethodDelete, "/test-method/team-a/key", "token-a")
+		require.EqualValues(t, http.StatusForbidden, resp.StatusCode)
+	})
+	t.Run("list returns allowed keys only", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		defer mockProvider.AssertExpectations(t)
+		mockProvider.On("ListKeys").Return([][]byte{[]byte("team-b/key"), []byte("team-a/key")}, nil).Once()
+		s := newTestAuthRoutes(t, mockProvider)
+		defer s.Close()
+
+		resp, body := doAuthRequest(t, s, http.MethodGet, "/test-method", "token-a")
+		require.EqualValues(t, http.StatusOK, resp.StatusCode)
+		require.EqualValues(t, `{"keys":["team-a/key"]}`+jsonTerminator, body)
+	})
+	t.Run("list is forbidden before provider is created", func(t *testing.T) {
+		tokens, err := auth.NewTokens(map[string]string{"ci": "token-ci"})
+		require.NoError(t, err)
+		policy := &auth.Policy{Identities: map[string][]auth.Rule{
+			"ci": {{Prefix: "team-a/", Actions: []auth.Action{auth.ActionRead}}},
+		}}
+		a := NewMethods(map[string]MethodFactoryFunc{
+			"test-method": func(cipher string) (secret.Provider, func()) {
+				t.Error("provider shouldn't be created")
+				return nil, nil
+			},
+		}, createSugarLogger(), Authorizer(policy))
+		router := chi.NewRouter()
+		router.With(a.Authenticate(tokens)).Mount("/", a.Routes())
+		s := httptest.NewServer(router)
+		defer s.Close()
+
+		resp, body := doAuthRequest(t, s, http.MethodGet, "/test-method", "token-ci")
+		require.EqualValues(t, http.StatusForbidden, resp.StatusCode)
+		require.EqualValues(t, `{"error":{"code":"forbidden","message":"cannot authorize: forbidden: ci can't list any key"}}`+jsonTerminator, body)
+	})
+}
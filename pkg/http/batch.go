@@ -0,0 +1,237 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-itools-internship/go-secret/pkg/auth"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+const (
+	// BatchGetRoute is the route to get several values at once, see BatchGet.
+	BatchGetRoute = RoutePrefix + ":batchGet"
+	// BatchSetRoute is the route to set several values at once, see BatchSet.
+	BatchSetRoute = RoutePrefix + ":batchSet"
+)
+
+// errEmptyBatch is returned if batch request has no items.
+var errEmptyBatch = errors.New("batch should have items")
+
+// batchResult is the result of batch request for the key.
+type batchResult struct {
+	Key   string     `json:"key"`
+	Value *string    `json:"value,omitempty"`
+	Error *ErrorBody `json:"error,omitempty"`
+}
+
+// fail sets error of the key.
+func (b *batchResult) fail(err error) {
+	b.Error = &ErrorBody{Code: errorCode(errorStatus(err)), Message: err.Error()}
+}
+
+// BatchGet method fetches values by several keys of the method with single cipher key (provided in header).
+// Every key gets its own result, keys the caller isn't allowed to read get error.
+//
+// Example of request body:
+//
+//    {
+//        "method": "remote",
+//        "keys": ["db-password", "db-user"]
+//    }
+//
+// Example of response body:
+//
+//    {
+//        "results": [
+//            {"key": "db-password", "value": "123-456"},
+//            {"key": "db-user", "error": {"code": "not_found", "message": "..."}}
+//        ]
+//    }
+func (a *methods) BatchGet(w http.ResponseWriter, r *http.Request) {
+	logger := a.logger.Named("batch-get")
+	var requestBody struct {
+		Method string   `json:"method"`
+		Keys   []string `json:"keys"`
+	}
+	if err := a.decodeBody(r, &requestBody); err != nil {
+		a.writeErrorResponse(w, r, bodyErrorStatus(err), fmt.Errorf("cannot decode body: %w", err))
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			logger.Warnf("cannot close request body: %s", err.Error())
+		}
+	}()
+	if len(requestBody.Keys) == 0 {
+		a.writeErrorResponse(w, r, http.StatusBadRequest, errEmptyBatch)
+		return
+	}
+	if !a.knownMethod(w, r, requestBody.Method) {
+		return
+	}
+
+	results := make([]batchResult, len(requestBody.Keys))
+	keys := make([][]byte, 0, len(requestBody.Keys))
+	indexes := make([]int, 0, len(requestBody.Keys))
+	for i, key := range requestBody.Keys {
+		results[i].Key = key
+		if err := a.checkBatchKey(r, requestBody.Method, auth.ActionRead, key); err != nil {
+			results[i].fail(err)
+			continue
+		}
+		keys = append(keys, []byte(key))
+		indexes = append(indexes, i)
+	}
+	if len(keys) == 0 {
+		// cipher key isn't derived, if the caller can't read any key of the batch
+		a.writeBatchResults(w, r, results)
+		return
+	}
+	p, tearDownFn, ok := a.methodProvider(w, r, requestBody.Method)
+	if tearDownFn != nil {
+		defer tearDownFn()
+	}
+	if !ok {
+		return
+	}
+	got, err := getDataBatch(r.Context(), p, keys)
+	if err != nil {
+		a.writeErrorResponse(w, r, errorStatus(err), fmt.Errorf("cannot get data: %w", err))
+		return
+	}
+	for j, i := range indexes {
+		if got[j].Err != nil {
+			results[i].fail(fmt.Errorf("cannot get data by key: %w", got[j].Err))
+			continue
+		}
+		value := string(got[j].Value)
+		results[i].Value = &value
+	}
+	a.writeBatchResults(w, r, results)
+}
+
+// BatchSet method sets values by several keys of the method with single cipher key (provided in header).
+// Values the caller is allowed to write are set at once if storage supports it.
+// Every key gets its own result, keys the caller isn't allowed to write get error.
+//
+// Example of request body:
+//
+//    {
+//        "method": "remote",
+//        "items": [
+//            {"key": "db-password", "value": "123-456"},
+//            {"key": "db-user", "value": "admin"}
+//        ]
+//    }
+//
+// Example of response body:
+//
+//    {
+//        "results": [
+//            {"key": "db-password"},
+//            {"key": "db-user", "error": {"code": "forbidden", "message": "..."}}
+//        ]
+//    }
+func (a *methods) BatchSet(w http.ResponseWriter, r *http.Request) {
+	logger := a.logger.Named("batch-set")
+	var requestBody struct {
+		Method string `json:"method"`
+		Items  []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"items"`
+	}
+	if err := a.decodeBody(r, &requestBody); err != nil {
+		a.writeErrorResponse(w, r, bodyErrorStatus(err), fmt.Errorf("cannot decode body: %w", err))
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			logger.Warnf("cannot close request body: %s", err.Error())
+		}
+	}()
+	if len(requestBody.Items) == 0 {
+		a.writeErrorResponse(w, r, http.StatusBadRequest, errEmptyBatch)
+		return
+	}
+	if !a.knownMethod(w, r, requestBody.Method) {
+		return
+	}
+
+	results := make([]batchResult, len(requestBody.Items))
+	entries := make([]secret.Entry, 0, len(requestBody.Items))
+	for i, item := range requestBody.Items {
+		results[i].Key = item.Key
+		if err := a.checkBatchKey(r, requestBody.Method, auth.ActionWrite, item.Key); err != nil {
+			results[i].fail(err)
+			continue
+		}
+		entries = append(entries, secret.Entry{Key: []byte(item.Key), Value: []byte(item.Value)})
+	}
+	if len(entries) == 0 {
+		// cipher key isn't derived, if the caller can't write any key of the batch
+		a.writeBatchResults(w, r, results)
+		return
+	}
+	p, tearDownFn, ok := a.methodProvider(w, r, requestBody.Method)
+	if tearDownFn != nil {
+		defer tearDownFn()
+	}
+	if !ok {
+		return
+	}
+	if err := setDataBatch(r.Context(), p, entries); err != nil {
+		a.writeErrorResponse(w, r, errorStatus(err), fmt.Errorf("cannot set data: %w", err))
+		return
+	}
+	a.writeBatchResults(w, r, results)
+}
+
+// writeBatchResults writes results of batch items.
+func (a *methods) writeBatchResults(w http.ResponseWriter, r *http.Request, results []batchResult) {
+	a.writeJSONResponse(w, r, struct {
+		Results []batchResult `json:"results"`
+	}{Results: results})
+}
+
+// checkBatchKey returns error if key of batch item is empty or caller can't do action with it.
+func (a *methods) checkBatchKey(r *http.Request, method string, action auth.Action, key string) error {
+	if key == "" {
+		return fmt.Errorf("cannot find key: %w", secret.ErrEmptyKey)
+	}
+	if err := a.access(r, method, action, key); err != nil {
+		return fmt.Errorf("cannot authorize: %w", err)
+	}
+	return nil
+}
+
+// getDataBatch returns values by keys at once if provider supports it.
+func getDataBatch(ctx context.Context, p secret.ProviderCtx, keys [][]byte) ([]secret.Result, error) {
+	if bp, ok := p.(secret.BatchProvider); ok {
+		return bp.GetDataBatchCtx(ctx, keys)
+	}
+	results := make([]secret.Result, len(keys))
+	for i, key := range keys {
+		results[i].Value, results[i].Err = p.GetDataCtx(ctx, key)
+	}
+	return results, nil
+}
+
+// setDataBatch sets values by keys at once if provider supports it.
+func setDataBatch(ctx context.Context, p secret.ProviderCtx, entries []secret.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if bp, ok := p.(secret.BatchProvider); ok {
+		return bp.SetDataBatchCtx(ctx, entries)
+	}
+	for _, e := range entries {
+		if err := p.SetDataCtx(ctx, e.Key, e.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/auth"
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/io/storage"
+	"github.com/go-itools-internship/go-secret/pkg/provider"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func TestBatch(t *testing.T) {
+	ds := storage.NewMemoryVault()
+	p := provider.NewProvider(crypto.NewCryptographer([]byte("1234-5678"), rand.Reader), ds)
+	tokens, err := auth.NewTokens(map[string]string{"team-a": "token-a"})
+	require.NoError(t, err)
+	policy := &auth.Policy{Identities: map[string][]auth.Rule{
+		"team-a": {{Prefix: "team-a/", Actions: []auth.Action{auth.ActionRead, auth.ActionWrite}}},
+	}}
+	created := 0
+	a := NewMethods(map[string]MethodFactoryFunc{
+		"test-method": func(cipher string) (secret.Provider, func()) {
+			require.EqualValues(t, "1234-5678", cipher)
+			created++
+			return p, nil
+		},
+	}, createSugarLogger(), Authorizer(policy))
+	router := chi.NewRouter()
+	router.With(a.Authenticate(tokens)).Post(BatchGetRoute, a.BatchGet)
+	router.With(a.Authenticate(tokens)).Post(BatchSetRoute, a.BatchSet)
+	s := httptest.NewServer(router)
+	defer s.Close()
+
+	doBatchRequest := func(t *testing.T, target, body string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodPost, s.URL+target, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set(ParamCipherKey, "1234-5678")
+		req.Header.Set("Authorization", "Bearer token-a")
+		resp, err := s.Client().Do(req)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, resp.Body.Close())
+		}()
+		respBody, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(respBody)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		resp, body := doBatchRequest(t, BatchSetRoute,
+			`{"method":"test-method","items":[{"key":"team-a/first","value":"value 1"},{"key":"team-b/key","value":"value"},{"key":"team-a/second","value":"value 2"}]}`)
+		require.EqualValues(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, body, `{"key":"team-a/first"}`)
+		require.Contains(t, body, `{"key":"team-b/key","error":{"code":"forbidden"`)
+		_, err := ds.ReadData([]byte("team-b/key"))
+		require.Error(t, err)
+
+		resp, body = doBatchRequest(t, BatchGetRoute, `{"method":"test-method","keys":["team-a/second","team-a/missing","team-b/key","team-a/first"]}`)
+		require.EqualValues(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, body, `{"results":[{"key":"team-a/second","value":"value 2"},{"key":"team-a/missing","error":{"code":"not_found"`)
+		require.Contains(t, body, `{"key":"team-b/key","error":{"code":"forbidden"`)
+		require.Contains(t, body, `{"key":"team-a/first","value":"value 1"}]}`)
+	})
+	t.Run("provider isn't created if no key is allowed", func(t *testing.T) {
+		created = 0
+		resp, body := doBatchRequest(t, BatchSetRoute, `{"method":"test-method","items":[{"key":"team-b/key","value":"value"}]}`)
+		require.EqualValues(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, body, `{"results":[{"key":"team-b/key","error":{"code":"forbidden"`)
+
+		resp, body = doBatchRequest(t, BatchGetRoute, `{"method":"test-method","keys":["team-b/key"]}`)
+		require.EqualValues(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, body, `{"results":[{"key":"team-b/key","error":{"code":"forbidden"`)
+		require.EqualValues(t, 0, created)
+	})
+	t.Run("error if batch is empty", func(t *testing.T) {
+		resp, _ := doBatchRequest(t, BatchGetRoute, `{"method":"test-method","keys":[]}`)
+		require.EqualValues(t, http.StatusBadRequest, resp.StatusCode)
+		resp, _ = doBatchRequest(t, BatchSetRoute, `{"method":"test-method"}`)
+		require.EqualValues(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("error if method is unknown", func(t *testing.T) {
+		resp, _ := doBatchRequest(t, BatchGetRoute, `{"method":"unknown","keys":["team-a/first"]}`)
+		require.EqualValues(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,158 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/go-itools-internship/go-secret/pkg/auth"
+	"github.com/go-itools-internship/go-secret/pkg/keyring"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// Error codes of error responses.
+const (
+	CodeBadRequest         = "bad_request"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodePreconditionFailed = "precondition_failed"
+	CodePayloadTooLarge    = "payload_too_large"
+	CodeInternal           = "internal"
+	CodeUnavailable        = "unavailable"
+)
+
+var (
+	// errBodyTooLarge is returned if request body is bigger than allowed.
+	errBodyTooLarge = errors.New("request body is too large")
+	// errCipherKeyNotAccepted is returned if cipher key is sent to server, which holds the keys.
+	errCipherKeyNotAccepted = errors.New("cipher key header isn't accepted: server uses its own keys, set key id instead")
+)
+
+// ErrorResponse is the body of error responses.
+//
+// Example:
+//
+//    {
+//        "error": {
+//            "code": "not_found",
+//            "message": "cannot get data by key: ...",
+//            "request_id": "host/abcdef-000001"
+//        }
+//    }
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody describes the error.
+// Request id is set if server uses middleware.RequestID.
+type ErrorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// errorStatus returns status code for the error of provider or authorizer.
+// Provider returns secret.ErrDecrypt for wrong cipher key, so it gives 401 rather than 404.
+func errorStatus(err error) int {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, secret.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, secret.ErrEmptyKey), errors.Is(err, keyring.ErrUnknownKey), errors.Is(err, errCipherKeyNotAccepted),
+		errors.Is(err, secret.ErrExpiryNotSupported), errors.Is(err, errExpiryInPast), errors.Is(err, secret.ErrMetadataNotSupported),
+		errors.Is(err, secret.ErrRevisionNotSupported), errors.Is(err, errInvalidRevision):
+		return http.StatusBadRequest
+	case errors.Is(err, secret.ErrRevisionMismatch):
+		return http.StatusConflict
+	case errors.Is(err, secret.ErrDecrypt), errors.Is(err, auth.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, auth.ErrForbidden):
+		return http.StatusForbidden
+	case errors.As(err, &netErr):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// bodyErrorStatus returns status code for the error of request body decoding.
+func bodyErrorStatus(err error) int {
+	if errors.Is(err, errBodyTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// errorCode returns error code for the status.
+func errorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusPreconditionFailed:
+		return CodePreconditionFailed
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	default:
+		return CodeInternal
+	}
+}
+
+func (a *methods) writeErrorResponse(w http.ResponseWriter, r *http.Request, status int, response error) {
+	logger := a.logger.Named("write-error-response")
+	body := ErrorResponse{Error: ErrorBody{
+		Code:      errorCode(status),
+		RequestID: middleware.GetReqID(r.Context()),
+	}}
+	if response != nil {
+		body.Error.Message = response.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Warnf("cannot write response body: %s", err.Error())
+	}
+}
+
+func (a *methods) writeJSONResponse(w http.ResponseWriter, r *http.Request, body interface{}) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		a.writeErrorResponse(w, r, http.StatusInternalServerError, fmt.Errorf("cannot write response: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		a.logger.Named("write-response").Warnf("cannot write response body: %s", err.Error())
+	}
+}
+
+// decodeBody decodes JSON request body into v.
+// Returns errBodyTooLarge if body is bigger than max body size.
+func (a *methods) decodeBody(r *http.Request, v interface{}) error {
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, a.options.maxBodySize+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) > a.options.maxBodySize {
+		return errBodyTooLarge
+	}
+	return json.Unmarshal(data, v)
+}
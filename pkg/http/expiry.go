@@ -0,0 +1,28 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// errExpiryInPast is returned when value is set with expiration time, which has already come.
+var errExpiryInPast = errors.New("expires_at should be in the future")
+
+// setData sets value by key, which expires at the time if it isn't zero.
+func setData(ctx context.Context, p secret.ProviderCtx, key, value []byte, expiresAt time.Time) error {
+	if expiresAt.IsZero() {
+		return p.SetDataCtx(ctx, key, value)
+	}
+	if !expiresAt.After(time.Now()) {
+		return errExpiryInPast
+	}
+	ep, ok := p.(secret.ExpiringProvider)
+	if !ok {
+		return fmt.Errorf("method can't expire values: %w", secret.ErrExpiryNotSupported)
+	}
+	return ep.SetDataWithExpiryCtx(ctx, key, value, expiresAt)
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/io/storage"
+	"github.com/go-itools-internship/go-secret/pkg/provider"
+)
+
+func TestPut_ExpiresAt(t *testing.T) {
+	ds := storage.NewMemoryVault()
+	s := newTestRoutes(t, provider.NewProvider(crypto.NewCryptographer([]byte("1234-5678"), rand.Reader), ds))
+	defer s.Close()
+	body := func(expiresAt time.Time) *bytes.Buffer {
+		return bytes.NewBufferString(fmt.Sprintf(`{"value":"test-value-1","expires_at":%q}`, expiresAt.Format(time.RFC3339)))
+	}
+
+	resp, _ := doRequest(t, s, http.MethodPut, "/test-method/key", body(time.Now().Add(time.Hour)))
+	require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+	resp, respBody := doRequest(t, s, http.MethodGet, "/test-method/key", nil)
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, respBody, `"value":"test-value-1"`)
+
+	resp, respBody = doRequest(t, s, http.MethodPut, "/test-method/key", body(time.Now().Add(-time.Hour)))
+	require.EqualValues(t, http.StatusBadRequest, resp.StatusCode)
+	require.Contains(t, respBody, "expires_at should be in the future")
+}
+
+func TestPut_ExpiresAtNotSupported(t *testing.T) {
+	mockProvider := new(MockProvider)
+	s := newTestRoutes(t, mockProvider)
+	defer s.Close()
+
+	expiresAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+	resp, respBody := doRequest(t, s, http.MethodPut, "/test-method/key", bytes.NewBufferString(`{"value":"test-value-1","expires_at":"`+expiresAt+`"}`))
+	require.EqualValues(t, http.StatusBadRequest, resp.StatusCode)
+	require.Contains(t, respBody, "storage doesn't support expiration")
+	mockProvider.AssertExpectations(t)
+}
@@ -4,19 +4,22 @@ Package http provides handlers for different methods for REST API.
 package http
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/go-itools-internship/go-secret/pkg/auth"
+	"github.com/go-itools-internship/go-secret/pkg/keyring"
 	"github.com/go-itools-internship/go-secret/pkg/secret"
 )
 
 const (
 	ParamMethodKey = "method"
 	ParamCipherKey = "cipher"
+	ParamKeyID     = "key-id"
 	ParamGetterKey = "key"
 )
 
@@ -27,78 +30,158 @@ const (
 type MethodFactoryFunc func(cipher string) (secret.Provider, func())
 
 type methods struct {
-	ss     map[string]MethodFactoryFunc
-	logger *zap.SugaredLogger
+	ss      map[string]MethodFactoryFunc
+	logger  *zap.SugaredLogger
+	options options
+}
+
+type options struct {
+	maxBodySize       int64
+	authorizer        auth.Authorizer
+	methodAuthorizers map[string]auth.Authorizer
+	keyring           keyring.Keyring
+	defaultKeyID      string
+}
+
+var defaultOptions = options{
+	maxBodySize: 1 << 20,
+}
+
+type Option func(o *options)
+
+// MaxBodySize sets max size of request body in bytes. Default: 1 MiB.
+// Bigger requests are rejected with status 413.
+func MaxBodySize(n int64) Option {
+	return func(o *options) {
+		o.maxBodySize = n
+	}
+}
+
+// Authorizer sets authorizer to check access of the caller to the keys.
+// Caller should be authenticated by Authenticate middleware.
+// All authenticated callers have full access if authorizer isn't set.
+func Authorizer(az auth.Authorizer) Option {
+	return func(o *options) {
+		o.authorizer = az
+	}
+}
+
+// MethodAuthorizer sets authorizer to check access of the caller to the keys of the method.
+// It's checked in addition to the authorizer set by Authorizer option, so both should grant the access.
+func MethodAuthorizer(method string, az auth.Authorizer) Option {
+	return func(o *options) {
+		if o.methodAuthorizers == nil {
+			o.methodAuthorizers = make(map[string]auth.Authorizer)
+		}
+		o.methodAuthorizers[method] = az
+	}
+}
+
+// ServerKeys makes server use cipher keys from the keyring instead of cipher key header.
+// Callers choose the key by id in "key-id" header, default key id is used if header is empty.
+// Server keys are used only by callers authenticated by Authenticate middleware.
+// Authorizers, which are auth.KeyAuthorizer, check that caller can use the key, like auth.Policy does.
+// Requests with cipher key header are rejected, so passphrases aren't sent by mistake.
+func ServerKeys(kr keyring.Keyring, defaultKeyID string) Option {
+	return func(o *options) {
+		o.keyring = kr
+		o.defaultKeyID = defaultKeyID
+	}
 }
 
 // NewMethods initializes a structure that provides HTTP handler functions
 // to organize REST API access to different type of provides based on "method" type.
 //
 // Accepts `ss` map with a set of method-provider pair.
-func NewMethods(ss map[string]MethodFactoryFunc, logger *zap.SugaredLogger) *methods {
+func NewMethods(ss map[string]MethodFactoryFunc, logger *zap.SugaredLogger, opts ...Option) *methods {
+	options := defaultOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
 	return &methods{
-		ss:     ss,
-		logger: logger,
+		ss:      ss,
+		logger:  logger,
+		options: options,
 	}
 }
 
 // GetByKey method fetches a value specified by getter key.
 // Uses cipher key to access encrypted data.
 // Requires to provide getter key, cipher key (as a header) and method type to access as.
+// Revision of the value is returned as well if the method keeps it.
 func (a *methods) GetByKey(w http.ResponseWriter, r *http.Request) {
 	getterKey := r.URL.Query().Get(ParamGetterKey)
 	if getterKey == "" {
-		a.writeErrorResponse(w, http.StatusBadRequest, errors.New("cannot find getter key: empty"))
+		a.writeErrorResponse(w, r, http.StatusBadRequest, errors.New("cannot find getter key: empty"))
 		return
 	}
-
 	actionType := r.URL.Query().Get(ParamMethodKey)
+	if !a.authorize(w, r, actionType, auth.ActionRead, getterKey) {
+		return
+	}
+
 	if _, ok := a.ss[actionType]; !ok {
-		a.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("cannot find provided method type %s", actionType))
+		a.writeErrorResponse(w, r, http.StatusBadRequest, fmt.Errorf("cannot find provided method type %s", actionType))
 		return
 	}
 
-	cipherKey := r.Header.Get(ParamCipherKey)
+	cipherKey, err := a.cipherKey(r, actionType)
+	if err != nil {
+		a.writeErrorResponse(w, r, errorStatus(err), err)
+		return
+	}
 	p, tearDownFn := a.ss[actionType](cipherKey)
 	if tearDownFn != nil {
 		defer tearDownFn()
 	}
+	if p == nil {
+		a.writeErrorResponse(w, r, http.StatusInternalServerError, errors.New("cannot create provider"))
+		return
+	}
 
-	result, err := p.GetData([]byte(getterKey))
+	result, revision, err := getData(r.Context(), secret.ProviderWithContext(p), []byte(getterKey))
 	if err != nil {
-		a.writeErrorResponse(w, http.StatusInternalServerError, fmt.Errorf("cannot get data by key: %w", err))
+		a.writeErrorResponse(w, r, errorStatus(err), fmt.Errorf("cannot get data by key: %w", err))
 		return
 	}
 
 	var responseBody struct {
-		Value string `json:"value"`
+		Value    string `json:"value"`
+		Revision int    `json:"revision,omitempty"`
 	}
 	responseBody.Value = string(result)
-	if err := json.NewEncoder(w).Encode(responseBody); err != nil {
-		a.writeErrorResponse(w, http.StatusInternalServerError, fmt.Errorf("cannot write response: %w", err))
-	}
+	responseBody.Revision = revision
+	setETag(w, revision)
+	a.writeJSONResponse(w, r, responseBody)
 }
 
 // SetByKey method sets a new value for specified getter key.
 // Value is encrypted using cipher key (provided in header).
 // Requires to provide getter key, cipher key (as a header) and method type to access as.
+// Value expires at optional "expires_at" time in RFC 3339 format.
+// Value is set only if it has optional "revision" returned by GetByKey, 0 means that value shouldn't exist.
+// Responds with status 409 if revision doesn't match.
 //
 // Example of request body:
 //
 //    {
 //        "getter": "cloud-key",
 //        "method": "memory",
-//        "value": "123-456"
+//        "value": "123-456",
+//        "expires_at": "2021-06-01T00:00:00Z",
+//        "revision": 3
 //    }
 func (a *methods) SetByKey(w http.ResponseWriter, r *http.Request) {
 	logger := a.logger.Named("set-by-key")
 	var requestBody struct {
-		GetterKey  string `json:"getter"`
-		MethodType string `json:"method"`
-		Value      string `json:"value"`
+		GetterKey  string    `json:"getter"`
+		MethodType string    `json:"method"`
+		Value      string    `json:"value"`
+		ExpiresAt  time.Time `json:"expires_at"`
+		Revision   *int      `json:"revision"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		a.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("cannot decode body: %w", err))
+	if err := a.decodeBody(r, &requestBody); err != nil {
+		a.writeErrorResponse(w, r, bodyErrorStatus(err), fmt.Errorf("cannot decode body: %w", err))
 		return
 	}
 	defer func() {
@@ -108,34 +191,43 @@ func (a *methods) SetByKey(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	if requestBody.GetterKey == "" {
-		a.writeErrorResponse(w, http.StatusBadRequest, errors.New("cannot find getter key: empty"))
+		a.writeErrorResponse(w, r, http.StatusBadRequest, errors.New("cannot find getter key: empty"))
+		return
+	}
+	revision := secret.AnyRevision
+	if requestBody.Revision != nil {
+		if revision = *requestBody.Revision; revision < 0 {
+			a.writeErrorResponse(w, r, http.StatusBadRequest, fmt.Errorf("%w: revision can't be negative", errInvalidRevision))
+			return
+		}
+	}
+	if !a.authorize(w, r, requestBody.MethodType, auth.ActionWrite, requestBody.GetterKey) {
 		return
 	}
 	if _, ok := a.ss[requestBody.MethodType]; !ok {
-		a.writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("cannot find provided method type %s", requestBody.MethodType))
+		a.writeErrorResponse(w, r, http.StatusBadRequest, fmt.Errorf("cannot find provided method type %s", requestBody.MethodType))
 		return
 	}
 
-	cipherKey := r.Header.Get(ParamCipherKey)
+	cipherKey, err := a.cipherKey(r, requestBody.MethodType)
+	if err != nil {
+		a.writeErrorResponse(w, r, errorStatus(err), err)
+		return
+	}
 	p, tearDownFn := a.ss[requestBody.MethodType](cipherKey)
 	if tearDownFn != nil {
 		defer tearDownFn()
 	}
+	if p == nil {
+		a.writeErrorResponse(w, r, http.StatusInternalServerError, errors.New("cannot create provider"))
+		return
+	}
 
-	err := p.SetData([]byte(requestBody.GetterKey), []byte(requestBody.Value))
+	revision, err = setDataIfRevision(r.Context(), secret.ProviderWithContext(p), []byte(requestBody.GetterKey), []byte(requestBody.Value), requestBody.ExpiresAt, revision)
 	if err != nil {
-		a.writeErrorResponse(w, http.StatusInternalServerError, fmt.Errorf("cannot set data: %w", err))
+		a.writeErrorResponse(w, r, errorStatus(err), fmt.Errorf("cannot set data: %w", err))
 		return
 	}
+	setETag(w, revision)
 	w.WriteHeader(http.StatusNoContent)
 }
-
-func (a *methods) writeErrorResponse(w http.ResponseWriter, status int, response error) {
-	logger := a.logger.Named("write-error-response")
-	w.WriteHeader(status)
-	if response != nil {
-		if _, err := fmt.Fprintf(w, `{"error":"%s"}`, response.Error()); err != nil {
-			logger.Warnf("cannot write response body: %s", err.Error())
-		}
-	}
-}
@@ -2,14 +2,17 @@ package http
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
 
 	"github.com/go-itools-internship/go-secret/pkg/secret"
@@ -78,7 +81,7 @@ func TestHTTPHandlers(t *testing.T) {
 
 			respBody, err := io.ReadAll(resp.Body)
 			require.NoError(t, err)
-			require.EqualValues(t, `{"error":"cannot find provided method type test-method-1"}`, respBody)
+			require.EqualValues(t, `{"error":{"code":"bad_request","message":"cannot find provided method type test-method-1"}}`+jsonTerminator, string(respBody))
 		})
 
 		t.Run("error when provider returned error", func(t *testing.T) {
@@ -109,7 +112,7 @@ func TestHTTPHandlers(t *testing.T) {
 
 			respBody, err := io.ReadAll(resp.Body)
 			require.NoError(t, err)
-			require.EqualValues(t, `{"error":"cannot set data: test error"}`, respBody)
+			require.EqualValues(t, `{"error":{"code":"internal","message":"cannot set data: test error"}}`+jsonTerminator, string(respBody))
 		})
 
 		t.Run("error when cannot decode request body", func(t *testing.T) {
@@ -131,7 +134,47 @@ func TestHTTPHandlers(t *testing.T) {
 
 			respBody, err := io.ReadAll(resp.Body)
 			require.NoError(t, err)
-			require.Contains(t, string(respBody), `{"error":"cannot decode body:`)
+			require.Contains(t, string(respBody), `{"error":{"code":"bad_request","message":"cannot decode body:`)
+		})
+
+		t.Run("error when request body is too large", func(t *testing.T) {
+			a := NewMethods(map[string]MethodFactoryFunc{}, createSugarLogger(), MaxBodySize(16))
+
+			s := httptest.NewServer(http.HandlerFunc(a.SetByKey))
+			defer s.Close()
+
+			body := bytes.NewBufferString(`{"getter":"test-getter-1","method":"test-method","value":"test-value-1"}`)
+			req := httptest.NewRequest(http.MethodPost, s.URL, body)
+			req.RequestURI = ""
+
+			resp, err := s.Client().Do(req)
+			require.NoError(t, err)
+			require.EqualValues(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+			require.EqualValues(t, "application/json", resp.Header.Get("Content-Type"))
+
+			respBody, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.EqualValues(t, `{"error":{"code":"payload_too_large","message":"cannot decode body: request body is too large"}}`+jsonTerminator, string(respBody))
+		})
+
+		t.Run("error contains request id", func(t *testing.T) {
+			a := NewMethods(map[string]MethodFactoryFunc{}, createSugarLogger())
+
+			s := httptest.NewServer(middleware.RequestID(http.HandlerFunc(a.SetByKey)))
+			defer s.Close()
+
+			body := bytes.NewBufferString(`{"getter":"test-getter-1","method":"test-method","value":"test-value-1"}`)
+			req := httptest.NewRequest(http.MethodPost, s.URL, body)
+			req.Header.Set(middleware.RequestIDHeader, "test-request-id")
+			req.RequestURI = ""
+
+			resp, err := s.Client().Do(req)
+			require.NoError(t, err)
+			require.EqualValues(t, http.StatusBadRequest, resp.StatusCode)
+
+			respBody, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.EqualValues(t, `{"error":{"code":"bad_request","message":"cannot find provided method type test-method","request_id":"test-request-id"}}`+jsonTerminator, string(respBody))
 		})
 	})
 
@@ -197,7 +240,7 @@ func TestHTTPHandlers(t *testing.T) {
 
 			respBody, err := io.ReadAll(resp.Body)
 			require.NoError(t, err)
-			require.EqualValues(t, `{"error":"cannot find getter key: empty"}`, respBody)
+			require.EqualValues(t, `{"error":{"code":"bad_request","message":"cannot find getter key: empty"}}`+jsonTerminator, string(respBody))
 		})
 
 		t.Run("error when no method type exists", func(t *testing.T) {
@@ -230,13 +273,39 @@ func TestHTTPHandlers(t *testing.T) {
 
 			respBody, err := io.ReadAll(resp.Body)
 			require.NoError(t, err)
-			require.EqualValues(t, `{"error":"cannot find provided method type test-method-1"}`, respBody)
+			require.EqualValues(t, `{"error":{"code":"bad_request","message":"cannot find provided method type test-method-1"}}`+jsonTerminator, string(respBody))
 
 			require.Eventually(t, func() bool {
 				return atomic.LoadInt64(&tearDownFnCounter) == 1 // NOTE: do not expect to be changed
 			}, 300*time.Millisecond, 100*time.Millisecond)
 		})
 
+		t.Run("error when provider can't be created", func(t *testing.T) {
+			a := NewMethods(map[string]MethodFactoryFunc{
+				"test-method": func(cipher string) (secret.Provider, func()) {
+					return nil, nil
+				},
+			}, createSugarLogger())
+
+			s := httptest.NewServer(http.HandlerFunc(a.GetByKey))
+			defer s.Close()
+
+			req := httptest.NewRequest(http.MethodGet, s.URL, nil)
+			req.RequestURI = ""
+			query := req.URL.Query()
+			query.Set(ParamGetterKey, "test-getter-1")
+			query.Set(ParamMethodKey, "test-method")
+			req.URL.RawQuery = query.Encode()
+
+			resp, err := s.Client().Do(req)
+			require.NoError(t, err)
+			require.EqualValues(t, http.StatusInternalServerError, resp.StatusCode)
+
+			respBody, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.EqualValues(t, `{"error":{"code":"internal","message":"cannot create provider"}}`+jsonTerminator, string(respBody))
+		})
+
 		t.Run("error when GetData returned error", func(t *testing.T) {
 			mockProvider := new(MockProvider)
 			defer mockProvider.AssertExpectations(t)
@@ -268,7 +337,39 @@ func TestHTTPHandlers(t *testing.T) {
 
 			respBody, err := io.ReadAll(resp.Body)
 			require.NoError(t, err)
-			require.Contains(t, string(respBody), `{"error":"cannot get data by key:`)
+			require.Contains(t, string(respBody), `{"error":{"code":"internal","message":"cannot get data by key:`)
+		})
+
+		t.Run("error status by provider error", func(t *testing.T) {
+			tests := []struct {
+				err    error
+				status int
+			}{
+				{fmt.Errorf("test: %w", secret.ErrNotFound), http.StatusNotFound},
+				{fmt.Errorf("test: %w", secret.ErrEmptyKey), http.StatusBadRequest},
+				{fmt.Errorf("test: %w", secret.ErrDecrypt), http.StatusUnauthorized},
+				{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, http.StatusServiceUnavailable},
+			}
+			for _, tt := range tests {
+				mockProvider := new(MockProvider)
+				mockProvider.On("GetData", []byte("test-getter-1")).Return(nil, tt.err).Once()
+
+				a := NewMethods(map[string]MethodFactoryFunc{
+					"test-method": func(cipher string) (secret.Provider, func()) {
+						return mockProvider, nil
+					},
+				}, createSugarLogger())
+
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				query := req.URL.Query()
+				query.Set(ParamGetterKey, "test-getter-1")
+				query.Set(ParamMethodKey, "test-method")
+				req.URL.RawQuery = query.Encode()
+				w := httptest.NewRecorder()
+				a.GetByKey(w, req)
+				require.EqualValues(t, tt.status, w.Code)
+				mockProvider.AssertExpectations(t)
+			}
 		})
 	})
 }
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-itools-internship/go-secret/pkg/auth"
+)
+
+// cipherKey returns cipher key of the request to the method.
+// It's the cipher key header or the key from server keyring if server holds the keys.
+// Server keys are used only by authenticated callers, authorizers, which are auth.KeyAuthorizer, check key ids.
+func (a *methods) cipherKey(r *http.Request, method string) (string, error) {
+	if a.options.keyring == nil {
+		return r.Header.Get(ParamCipherKey), nil
+	}
+	if r.Header.Get(ParamCipherKey) != "" {
+		return "", errCipherKeyNotAccepted
+	}
+	id := r.Header.Get(ParamKeyID)
+	if id == "" {
+		id = a.options.defaultKeyID
+	}
+	if err := a.keyAccess(r, method, id); err != nil {
+		return "", fmt.Errorf("cannot use server key: %w", err)
+	}
+	key, err := a.options.keyring.Key(r.Context(), id)
+	if err != nil {
+		return "", fmt.Errorf("cannot get server key: %w", err)
+	}
+	return key, nil
+}
+
+// keyAccess returns error if caller isn't authenticated or authorizers don't grant server key by id.
+func (a *methods) keyAccess(r *http.Request, method, id string) error {
+	if _, ok := auth.IdentityFromContext(r.Context()); !ok {
+		return auth.ErrUnauthenticated
+	}
+	for _, az := range []auth.Authorizer{a.options.authorizer, a.options.methodAuthorizers[method]} {
+		if ka, ok := az.(auth.KeyAuthorizer); ok {
+			if err := ka.AuthorizeKey(r.Context(), id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/auth"
+	"github.com/go-itools-internship/go-secret/pkg/keyring"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func TestServerKeys(t *testing.T) {
+	mockProvider := new(MockProvider)
+	defer mockProvider.AssertExpectations(t)
+	mockProvider.On("GetData", []byte("key")).Return([]byte("test-value-1"), nil).Twice()
+
+	tokens, err := auth.NewTokens(map[string]string{"team-a": "token-a", "team-b": "token-b"})
+	require.NoError(t, err)
+	policy := &auth.Policy{
+		Identities: map[string][]auth.Rule{
+			"team-a": {{Prefix: "", Actions: []auth.Action{auth.ActionRead}}},
+			"team-b": {{Prefix: "", Actions: []auth.Action{auth.ActionRead}}},
+		},
+		Keys: map[string][]string{"team-a": {"payments", "billing", "unknown"}, "team-b": {"billing"}},
+	}
+	var ciphers []string
+	a := NewMethods(map[string]MethodFactoryFunc{
+		"test-method": func(cipher string) (secret.Provider, func()) {
+			ciphers = append(ciphers, cipher)
+			return mockProvider, nil
+		},
+	}, createSugarLogger(), Authorizer(policy), ServerKeys(keyring.Static{"payments": "key-1", "billing": "key-2"}, "payments"))
+	router := chi.NewRouter()
+	router.With(a.Authenticate(tokens)).Mount("/", a.Routes())
+	s := httptest.NewServer(router)
+	defer s.Close()
+
+	doAs := func(token, header, value string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodGet, s.URL+"/test-method/key", nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := s.Client().Do(req)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, resp.Body.Close())
+		}()
+		respBody, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(respBody)
+	}
+	do := func(header, value string) (*http.Response, string) {
+		return doAs("token-a", header, value)
+	}
+
+	resp, _ := do("", "")
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	resp, _ = do(ParamKeyID, "billing")
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, []string{"key-1", "key-2"}, ciphers)
+
+	resp, body := do(ParamKeyID, "unknown")
+	require.EqualValues(t, http.StatusBadRequest, resp.StatusCode)
+	require.EqualValues(t, `{"error":{"code":"bad_request","message":"cannot get server key: unknown key id \"unknown\""}}`+jsonTerminator, body)
+
+	resp, body = do(ParamCipherKey, "passphrase")
+	require.EqualValues(t, http.StatusBadRequest, resp.StatusCode)
+	require.Contains(t, body, "cipher key header isn't accepted")
+
+	t.Run("error when caller is unauthenticated", func(t *testing.T) {
+		a := NewMethods(map[string]MethodFactoryFunc{
+			"test-method": func(cipher string) (secret.Provider, func()) {
+				return mockProvider, nil
+			},
+		}, createSugarLogger(), ServerKeys(keyring.Static{"payments": "key-1"}, "payments"))
+		s := httptest.NewServer(a.Routes())
+		defer s.Close()
+		resp, err := s.Client().Get(s.URL + "/test-method/key")
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.EqualValues(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+	t.Run("error when key id isn't granted", func(t *testing.T) {
+		resp, body := doAs("token-b", ParamKeyID, "payments")
+		require.EqualValues(t, http.StatusForbidden, resp.StatusCode)
+		require.Contains(t, body, `cannot use server key: forbidden: team-b can't use key \"payments\"`)
+		resp, _ = doAs("token-b", "", "")
+		require.EqualValues(t, http.StatusForbidden, resp.StatusCode)
+		require.EqualValues(t, []string{"key-1", "key-2"}, ciphers)
+	})
+}
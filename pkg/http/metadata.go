@@ -0,0 +1,62 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// ParamLabelKey is the query parameter to filter listed keys by label.
+// It's "name=value" to match the value or "name" to match any value, several labels should all match.
+const ParamLabelKey = "label"
+
+// metadataBody is metadata of the value in request body.
+// Metadata is replaced only if any of its fields is present.
+type metadataBody struct {
+	Labels      map[string]string `json:"labels"`
+	Description *string           `json:"description"`
+	Owner       *string           `json:"owner"`
+}
+
+func (b metadataBody) present() bool {
+	return b.Labels != nil || b.Description != nil || b.Owner != nil
+}
+
+func (b metadataBody) metadata() secret.Metadata {
+	md := secret.Metadata{Labels: b.Labels}
+	if b.Description != nil {
+		md.Description = *b.Description
+	}
+	if b.Owner != nil {
+		md.Owner = *b.Owner
+	}
+	return md
+}
+
+// metadataProvider returns p if it gives access to metadata.
+func metadataProvider(p secret.ProviderCtx) (secret.MetadataProvider, error) {
+	mp, ok := p.(secret.MetadataProvider)
+	if !ok {
+		return nil, fmt.Errorf("method doesn't keep metadata: %w", secret.ErrMetadataNotSupported)
+	}
+	return mp, nil
+}
+
+// metadataValueProvider returns p if it sets the value with its metadata at once.
+func metadataValueProvider(p secret.ProviderCtx) (secret.MetadataValueProvider, error) {
+	mp, ok := p.(secret.MetadataValueProvider)
+	if !ok {
+		return nil, fmt.Errorf("method doesn't keep metadata: %w", secret.ErrMetadataNotSupported)
+	}
+	return mp, nil
+}
+
+// setDataWithMetadata sets value, which expires at the time, with metadata at once if value has the revision.
+func setDataWithMetadata(ctx context.Context, mp secret.MetadataValueProvider, key, value []byte, md secret.Metadata, expiresAt time.Time, revision int) (int, error) {
+	if !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return 0, errExpiryInPast
+	}
+	return mp.SetDataWithMetadataCtx(ctx, key, value, md, expiresAt, revision)
+}
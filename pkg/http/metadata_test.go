@@ -0,0 +1,108 @@
+package http
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/io/storage"
+	"github.com/go-itools-internship/go-secret/pkg/provider"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func TestRoutes_Metadata(t *testing.T) {
+	ds := storage.NewMemoryVault()
+	s := newTestRoutes(t, provider.NewProvider(crypto.NewCryptographer([]byte("1234-5678"), rand.Reader), ds))
+	defer s.Close()
+
+	resp, _ := doRequest(t, s, http.MethodPut, "/test-method/db-password",
+		bytes.NewBufferString(`{"value":"test-value-1","labels":{"env":"prod","team":"a"},"description":"database password","owner":"team-a"}`))
+	require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+	resp, _ = doRequest(t, s, http.MethodPut, "/test-method/api-token", bytes.NewBufferString(`{"value":"test-value-2","labels":{"env":"dev"}}`))
+	require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+
+	// metadata is kept if body has no metadata
+	resp, _ = doRequest(t, s, http.MethodPut, "/test-method/db-password", bytes.NewBufferString(`{"value":"test-value-3"}`))
+	require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, body := doRequest(t, s, http.MethodGet, "/test-method/db-password", nil)
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	var getBody struct {
+		Value    string          `json:"value"`
+		Metadata secret.Metadata `json:"metadata"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &getBody))
+	require.EqualValues(t, "test-value-3", getBody.Value)
+	require.EqualValues(t, map[string]string{"env": "prod", "team": "a"}, getBody.Metadata.Labels)
+	require.EqualValues(t, "database password", getBody.Metadata.Description)
+	require.EqualValues(t, "team-a", getBody.Metadata.Owner)
+	require.False(t, getBody.Metadata.CreatedAt.IsZero())
+	require.True(t, getBody.Metadata.UpdatedAt.After(getBody.Metadata.CreatedAt))
+
+	resp, body = doRequest(t, s, http.MethodGet, "/test-method?label=env=prod", nil)
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, `{"keys":["db-password"]}`+jsonTerminator, body)
+	resp, body = doRequest(t, s, http.MethodGet, "/test-method?label=env", nil)
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, `{"keys":["api-token","db-password"]}`+jsonTerminator, body)
+	resp, body = doRequest(t, s, http.MethodGet, "/test-method?label=env=prod&label=team=b", nil)
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, `{"keys":[]}`+jsonTerminator, body)
+	resp, _ = doRequest(t, s, http.MethodGet, "/test-method?label==prod", nil)
+	require.EqualValues(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestRoutes_MetadataNotSupported(t *testing.T) {
+	mockProvider := new(MockProvider)
+	s := newTestRoutes(t, mockProvider)
+	defer s.Close()
+
+	resp, body := doRequest(t, s, http.MethodPut, "/test-method/key", bytes.NewBufferString(`{"value":"test-value-1","owner":"team-a"}`))
+	require.EqualValues(t, http.StatusBadRequest, resp.StatusCode)
+	require.Contains(t, body, "storage doesn't keep metadata")
+	mockProvider.AssertNotCalled(t, "SetData", mock.Anything, mock.Anything)
+
+	resp, _ = doRequest(t, s, http.MethodGet, "/test-method?label=env", nil)
+	require.EqualValues(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+// metadataFailingCryptographer fails to encrypt metadata, which is JSON object.
+type metadataFailingCryptographer struct {
+	secret.Cryptographer
+}
+
+func (c metadataFailingCryptographer) Encode(data []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, []byte("{")) {
+		return nil, errors.New("cannot encrypt metadata")
+	}
+	return c.Cryptographer.Encode(data)
+}
+
+func TestRoutes_MetadataSaveFails(t *testing.T) {
+	cr := metadataFailingCryptographer{crypto.NewCryptographer([]byte("1234-5678"), rand.Reader)}
+	s := newTestRoutes(t, provider.NewProvider(cr, storage.NewMemoryVault(), provider.EncryptMetadata()))
+	defer s.Close()
+
+	resp, _ := doRequest(t, s, http.MethodPut, "/test-method/key", bytes.NewBufferString(`{"value":"test-value-1"}`))
+	require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+	resp, body := doRequest(t, s, http.MethodPut, "/test-method/key", bytes.NewBufferString(`{"value":"test-value-2","owner":"team-a"}`))
+	require.EqualValues(t, http.StatusInternalServerError, resp.StatusCode)
+	require.Contains(t, body, "cannot encrypt metadata")
+
+	// value isn't changed if its metadata can't be saved
+	resp, body = doRequest(t, s, http.MethodGet, "/test-method/key", nil)
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	var getBody struct {
+		Value string `json:"value"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &getBody))
+	require.EqualValues(t, "test-value-1", getBody.Value)
+	require.EqualValues(t, `"1"`, resp.Header.Get(ETagHeader))
+}
@@ -0,0 +1,27 @@
+package http
+
+import (
+	"net/http"
+	"sort"
+)
+
+// MethodsRoute is the route to list available methods, see ListMethods.
+const MethodsRoute = "/v1/methods"
+
+// ListMethods method returns sorted names of methods served by handlers.
+//
+// Example of response body:
+//
+//    {
+//        "methods": ["ci-file", "shared-pg", "team-a-redis"]
+//    }
+func (a *methods) ListMethods(w http.ResponseWriter, r *http.Request) {
+	responseBody := struct {
+		Methods []string `json:"methods"`
+	}{Methods: make([]string, 0, len(a.ss))}
+	for method := range a.ss {
+		responseBody.Methods = append(responseBody.Methods, method)
+	}
+	sort.Strings(responseBody.Methods)
+	a.writeJSONResponse(w, r, responseBody)
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/auth"
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/io/storage"
+	"github.com/go-itools-internship/go-secret/pkg/provider"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func TestMethods(t *testing.T) {
+	ds := storage.NewMemoryVault()
+	cr := crypto.NewCryptographer([]byte("1234-5678"), rand.Reader)
+	factory := func(opts ...provider.Option) MethodFactoryFunc {
+		return func(cipher string) (secret.Provider, func()) {
+			return provider.NewProvider(cr, ds, opts...), nil
+		}
+	}
+	tokens, err := auth.NewTokens(map[string]string{"team-a": "token-a"})
+	require.NoError(t, err)
+	policy := &auth.Policy{Identities: map[string][]auth.Rule{
+		"team-a": {{Prefix: "db/", Actions: []auth.Action{auth.ActionRead, auth.ActionWrite, auth.ActionList}}},
+	}}
+	a := NewMethods(map[string]MethodFactoryFunc{
+		"team-a-file": factory(provider.KeyPrefix("team-a/")),
+		"shared-file": factory(),
+	}, createSugarLogger(), MethodAuthorizer("team-a-file", policy))
+	router := chi.NewRouter()
+	router.Get(MethodsRoute, a.ListMethods)
+	router.With(a.Authenticate(tokens)).Mount(RoutePrefix, a.Routes())
+	s := httptest.NewServer(router)
+	defer s.Close()
+
+	doRequest := func(t *testing.T, method, target, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, s.URL+target, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set(ParamCipherKey, "1234-5678")
+		req.Header.Set("Authorization", "Bearer token-a")
+		resp, err := s.Client().Do(req)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, resp.Body.Close())
+		}()
+		respBody, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(respBody)
+	}
+
+	t.Run("list methods", func(t *testing.T) {
+		resp, body := doRequest(t, http.MethodGet, MethodsRoute, "")
+		require.EqualValues(t, http.StatusOK, resp.StatusCode)
+		require.JSONEq(t, `{"methods":["shared-file","team-a-file"]}`, body)
+	})
+	t.Run("method policy and key prefix", func(t *testing.T) {
+		resp, _ := doRequest(t, http.MethodPut, RoutePrefix+"/team-a-file/db/password", `{"value":"value a"}`)
+		require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+		resp, _ = doRequest(t, http.MethodPut, RoutePrefix+"/team-a-file/other", `{"value":"value"}`)
+		require.EqualValues(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, body := doRequest(t, http.MethodGet, RoutePrefix+"/team-a-file", "")
+		require.EqualValues(t, http.StatusOK, resp.StatusCode)
+		require.JSONEq(t, `{"keys":["db/password"]}`, body)
+
+		resp, body = doRequest(t, http.MethodGet, RoutePrefix+"/shared-file/team-a/db/password", "")
+		require.EqualValues(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, body, `"value":"value a"`)
+		resp, _ = doRequest(t, http.MethodPut, RoutePrefix+"/shared-file/other", `{"value":"value"}`)
+		require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+	})
+}
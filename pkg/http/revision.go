@@ -0,0 +1,124 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+const (
+	// ETagHeader is the response header with revision of the value.
+	ETagHeader = "ETag"
+	// IfMatchHeader is the request header to set value only if it has the revision from ETagHeader.
+	// "*" matches any revision of existing value.
+	IfMatchHeader = "If-Match"
+	// IfNoneMatchHeader is the request header "*" to set value only if there is no value by key.
+	IfNoneMatchHeader = "If-None-Match"
+)
+
+// errInvalidRevision is returned if revision of request can't be parsed.
+var errInvalidRevision = errors.New("invalid revision")
+
+// etag returns entity tag of the revision.
+func etag(revision int) string {
+	return strconv.Quote(strconv.Itoa(revision))
+}
+
+// parseETag returns revision from entity tag.
+func parseETag(tag string) (int, error) {
+	tag = strings.TrimSpace(tag)
+	value, err := strconv.Unquote(tag)
+	if err != nil {
+		return 0, fmt.Errorf("%w: entity tag %s should be quoted", errInvalidRevision, tag)
+	}
+	revision, err := strconv.Atoi(value)
+	if err != nil || revision <= 0 {
+		return 0, fmt.Errorf("%w: entity tag %s should be positive number", errInvalidRevision, tag)
+	}
+	return revision, nil
+}
+
+// precondition returns revision the value should have according to If-Match or If-None-Match headers.
+// It returns secret.AnyRevision if there are no preconditions and 0 if value shouldn't exist.
+// Revision of existing value is read for If-Match "*".
+func precondition(r *http.Request, p secret.ProviderCtx, key []byte) (int, error) {
+	ifMatch, ifNoneMatch := r.Header.Get(IfMatchHeader), r.Header.Get(IfNoneMatchHeader)
+	switch {
+	case ifMatch != "" && ifNoneMatch != "":
+		return 0, fmt.Errorf("%w: %s and %s can't be used together", errInvalidRevision, IfMatchHeader, IfNoneMatchHeader)
+	case ifNoneMatch != "":
+		if strings.TrimSpace(ifNoneMatch) != "*" {
+			return 0, fmt.Errorf("%w: only \"*\" is supported by %s", errInvalidRevision, IfNoneMatchHeader)
+		}
+		return 0, nil
+	case strings.TrimSpace(ifMatch) == "*":
+		_, revision, err := getData(r.Context(), p, key)
+		if errors.Is(err, secret.ErrNotFound) {
+			return 0, fmt.Errorf("value doesn't exist: %w", secret.ErrRevisionMismatch)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if revision == 0 {
+			return 0, fmt.Errorf("method doesn't keep revisions: %w", secret.ErrRevisionNotSupported)
+		}
+		return revision, nil
+	case ifMatch != "":
+		return parseETag(ifMatch)
+	default:
+		return secret.AnyRevision, nil
+	}
+}
+
+// getData returns value by key with its revision.
+// Revision is 0 if method doesn't keep revisions.
+func getData(ctx context.Context, p secret.ProviderCtx, key []byte) ([]byte, int, error) {
+	if rp, ok := p.(secret.RevisionedProvider); ok {
+		value, revision, err := rp.GetDataWithRevisionCtx(ctx, key)
+		if !errors.Is(err, secret.ErrRevisionNotSupported) {
+			return value, revision, err
+		}
+	}
+	value, err := p.GetDataCtx(ctx, key)
+	return value, 0, err
+}
+
+// setDataIfRevision sets value by key, which expires at the time if it isn't zero, if value has the revision.
+// Value is set unconditionally with secret.AnyRevision. Returns the new revision, which is 0 if method doesn't keep revisions.
+func setDataIfRevision(ctx context.Context, p secret.ProviderCtx, key, value []byte, expiresAt time.Time, revision int) (int, error) {
+	if !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return 0, errExpiryInPast
+	}
+	if rp, ok := p.(secret.RevisionedProvider); ok {
+		saved, err := rp.SetDataIfRevisionCtx(ctx, key, value, expiresAt, revision)
+		if !errors.Is(err, secret.ErrRevisionNotSupported) {
+			return saved, err
+		}
+	}
+	if revision != secret.AnyRevision {
+		return 0, fmt.Errorf("method doesn't keep revisions: %w", secret.ErrRevisionNotSupported)
+	}
+	return 0, setData(ctx, p, key, value, expiresAt)
+}
+
+// preconditionErrorStatus returns status code for the error of value set with precondition headers.
+// Mismatched revision fails the precondition.
+func preconditionErrorStatus(err error) int {
+	if errors.Is(err, secret.ErrRevisionMismatch) {
+		return http.StatusPreconditionFailed
+	}
+	return errorStatus(err)
+}
+
+// setETag sets entity tag of the revision, if method keeps revisions.
+func setETag(w http.ResponseWriter, revision int) {
+	if revision > 0 {
+		w.Header().Set(ETagHeader, etag(revision))
+	}
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/io/storage"
+	"github.com/go-itools-internship/go-secret/pkg/provider"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func doRequestWithHeader(t *testing.T, s *httptest.Server, method, target string, body io.Reader, header, value string) (*http.Response, string) {
+	req, err := http.NewRequest(method, s.URL+target, body)
+	require.NoError(t, err)
+	req.Header.Set(ParamCipherKey, "1234-5678")
+	req.Header.Set(header, value)
+	resp, err := s.Client().Do(req)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, resp.Body.Close())
+	}()
+	respBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(respBody)
+}
+
+func TestRoutes_Revision(t *testing.T) {
+	ds := storage.NewMemoryVault()
+	s := newTestRoutes(t, provider.NewProvider(crypto.NewCryptographer([]byte("1234-5678"), rand.Reader), ds))
+	defer s.Close()
+
+	resp, _ := doRequestWithHeader(t, s, http.MethodPut, "/test-method/key", bytes.NewBufferString(`{"value":"value 1"}`), IfNoneMatchHeader, "*")
+	require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+	require.EqualValues(t, `"1"`, resp.Header.Get(ETagHeader))
+	resp, _ = doRequestWithHeader(t, s, http.MethodPut, "/test-method/key", bytes.NewBufferString(`{"value":"value 2"}`), IfNoneMatchHeader, "*")
+	require.EqualValues(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+	resp, _ = doRequestWithHeader(t, s, http.MethodPut, "/test-method/key", bytes.NewBufferString(`{"value":"value 2"}`), IfMatchHeader, `"1"`)
+	require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+	require.EqualValues(t, `"2"`, resp.Header.Get(ETagHeader))
+	// the other writer has stale revision
+	resp, body := doRequestWithHeader(t, s, http.MethodPut, "/test-method/key", bytes.NewBufferString(`{"value":"stale"}`), IfMatchHeader, `"1"`)
+	require.EqualValues(t, http.StatusPreconditionFailed, resp.StatusCode)
+	require.Contains(t, body, CodePreconditionFailed)
+
+	resp, body = doRequest(t, s, http.MethodGet, "/test-method/key", nil)
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, `"2"`, resp.Header.Get(ETagHeader))
+	require.Contains(t, body, `"value":"value 2"`)
+	resp, _ = doRequest(t, s, http.MethodHead, "/test-method/key", nil)
+	require.EqualValues(t, `"2"`, resp.Header.Get(ETagHeader))
+
+	resp, _ = doRequestWithHeader(t, s, http.MethodPut, "/test-method/key", bytes.NewBufferString(`{"value":"value 3"}`), IfMatchHeader, "*")
+	require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+	require.EqualValues(t, `"3"`, resp.Header.Get(ETagHeader))
+	resp, _ = doRequestWithHeader(t, s, http.MethodPut, "/test-method/missing", bytes.NewBufferString(`{"value":"value"}`), IfMatchHeader, "*")
+	require.EqualValues(t, http.StatusPreconditionFailed, resp.StatusCode)
+	resp, _ = doRequestWithHeader(t, s, http.MethodPut, "/test-method/key", bytes.NewBufferString(`{"value":"value"}`), IfMatchHeader, "3")
+	require.EqualValues(t, http.StatusBadRequest, resp.StatusCode)
+
+}
+
+func TestHTTPHandlers_Revision(t *testing.T) {
+	ds := storage.NewMemoryVault()
+	p := provider.NewProvider(crypto.NewCryptographer([]byte("1234-5678"), rand.Reader), ds)
+	a := NewMethods(map[string]MethodFactoryFunc{
+		"test-method": func(cipher string) (secret.Provider, func()) {
+			return p, nil
+		},
+	}, createSugarLogger())
+	require.NoError(t, p.SetData([]byte("key"), []byte("value 1")))
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/?key=key&method=test-method", nil)
+	req.Header.Set(ParamCipherKey, "1234-5678")
+	a.GetByKey(rr, req)
+	require.EqualValues(t, http.StatusOK, rr.Code)
+	require.EqualValues(t, `{"value":"value 1","revision":1}`+jsonTerminator, rr.Body.String())
+	require.EqualValues(t, `"1"`, rr.Header().Get(ETagHeader))
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"getter":"key","method":"test-method","value":"value 2","revision":1}`))
+	req.Header.Set(ParamCipherKey, "1234-5678")
+	a.SetByKey(rr, req)
+	require.EqualValues(t, http.StatusNoContent, rr.Code)
+	require.EqualValues(t, `"2"`, rr.Header().Get(ETagHeader))
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"getter":"key","method":"test-method","value":"stale","revision":1}`))
+	req.Header.Set(ParamCipherKey, "1234-5678")
+	a.SetByKey(rr, req)
+	require.EqualValues(t, http.StatusConflict, rr.Code)
+	require.Contains(t, rr.Body.String(), CodeConflict)
+}
+
+func TestRoutes_RevisionNotSupported(t *testing.T) {
+	mockProvider := new(MockProvider)
+	s := newTestRoutes(t, mockProvider)
+	defer s.Close()
+
+	resp, body := doRequestWithHeader(t, s, http.MethodPut, "/test-method/key", bytes.NewBufferString(`{"value":"value"}`), IfMatchHeader, `"1"`)
+	require.EqualValues(t, http.StatusBadRequest, resp.StatusCode)
+	require.Contains(t, body, "storage doesn't keep revisions")
+}
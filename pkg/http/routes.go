@@ -0,0 +1,339 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/go-itools-internship/go-secret/pkg/auth"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+const (
+	// RoutePrefix is the prefix of resource routes.
+	RoutePrefix = "/v1/secrets"
+
+	// ParamPrefixKey is the query parameter to filter listed keys.
+	ParamPrefixKey = "prefix"
+
+	// DeprecationHeader marks responses of deprecated routes.
+	DeprecationHeader = "Deprecation"
+)
+
+// Routes returns router with resource routes for secrets.
+// It should be mounted at RoutePrefix:
+//
+//    GET    /{method}        list key names, optionally filtered by "prefix" and "label" query parameters
+//    GET    /{method}/{key}  get value by key
+//    HEAD   /{method}/{key}  check that key exists
+//    PUT    /{method}/{key}  set value by key
+//    DELETE /{method}/{key}  delete value by key
+//
+// Key is the rest of the path, so it can contain slashes.
+func (a *methods) Routes() chi.Router {
+	router := chi.NewRouter()
+	router.Get("/{method}", a.List)
+	router.Get("/{method}/*", a.Get)
+	router.Head("/{method}/*", a.Head)
+	router.Put("/{method}/*", a.Put)
+	router.Delete("/{method}/*", a.Delete)
+	return router
+}
+
+// Deprecated marks responses of the handler as deprecated and points to resource routes.
+func Deprecated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(DeprecationHeader, "true")
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", RoutePrefix))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Get method fetches a value by key from the path.
+// Uses cipher key (as a header) to access encrypted data.
+// Wrong cipher key responds with status 401.
+// Metadata is returned as well if the method keeps it, revision of the value is returned in ETag header.
+//
+// Example of response body:
+//
+//    {
+//        "value": "123-456",
+//        "metadata": {
+//            "labels": {"env": "prod"},
+//            "owner": "team-a",
+//            "created_at": "2021-06-01T00:00:00Z",
+//            "updated_at": "2021-06-01T00:00:00Z"
+//        }
+//    }
+func (a *methods) Get(w http.ResponseWriter, r *http.Request) {
+	key, ok := a.keyParam(w, r)
+	if !ok {
+		return
+	}
+	if !a.authorize(w, r, chi.URLParam(r, ParamMethodKey), auth.ActionRead, key) {
+		return
+	}
+	p, tearDownFn, ok := a.provider(w, r)
+	if tearDownFn != nil {
+		defer tearDownFn()
+	}
+	if !ok {
+		return
+	}
+	result, revision, err := getData(r.Context(), p, []byte(key))
+	if err != nil {
+		a.writeErrorResponse(w, r, errorStatus(err), fmt.Errorf("cannot get data by key: %w", err))
+		return
+	}
+
+	var responseBody struct {
+		Value    string           `json:"value"`
+		Metadata *secret.Metadata `json:"metadata,omitempty"`
+	}
+	responseBody.Value = string(result)
+	if mp, err := metadataProvider(p); err == nil {
+		md, err := mp.GetMetadataCtx(r.Context(), []byte(key))
+		switch {
+		case err == nil:
+			responseBody.Metadata = &md
+		case !errors.Is(err, secret.ErrMetadataNotSupported):
+			a.writeErrorResponse(w, r, errorStatus(err), fmt.Errorf("cannot get metadata by key: %w", err))
+			return
+		}
+	}
+	setETag(w, revision)
+	a.writeJSONResponse(w, r, responseBody)
+}
+
+// Head method checks that key from the path exists.
+// Responds with status 200 and ETag header if it does, 404 if it doesn't and 401 if cipher key is wrong.
+func (a *methods) Head(w http.ResponseWriter, r *http.Request) {
+	key, ok := a.keyParam(w, r)
+	if !ok {
+		return
+	}
+	if !a.authorize(w, r, chi.URLParam(r, ParamMethodKey), auth.ActionRead, key) {
+		return
+	}
+	p, tearDownFn, ok := a.provider(w, r)
+	if tearDownFn != nil {
+		defer tearDownFn()
+	}
+	if !ok {
+		return
+	}
+	_, revision, err := getData(r.Context(), p, []byte(key))
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		return
+	}
+	setETag(w, revision)
+	w.WriteHeader(http.StatusOK)
+}
+
+// Put method sets a new value for key from the path.
+// Value is encrypted using cipher key (provided in header).
+// Value expires at optional "expires_at" time in RFC 3339 format.
+// Metadata is replaced if any of "labels", "description" or "owner" is present, otherwise it's kept.
+// Value is set only if it has revision from If-Match header or doesn't exist with If-None-Match "*",
+// responds with status 412 otherwise. New revision is returned in ETag header.
+//
+// Example of request body:
+//
+//    {
+//        "value": "123-456",
+//        "expires_at": "2021-06-01T00:00:00Z",
+//        "labels": {"env": "prod"},
+//        "description": "database password",
+//        "owner": "team-a"
+//    }
+func (a *methods) Put(w http.ResponseWriter, r *http.Request) {
+	logger := a.logger.Named("put")
+	key, ok := a.keyParam(w, r)
+	if !ok {
+		return
+	}
+	if !a.authorize(w, r, chi.URLParam(r, ParamMethodKey), auth.ActionWrite, key) {
+		return
+	}
+	var requestBody struct {
+		Value     string    `json:"value"`
+		ExpiresAt time.Time `json:"expires_at"`
+		metadataBody
+	}
+	if err := a.decodeBody(r, &requestBody); err != nil {
+		a.writeErrorResponse(w, r, bodyErrorStatus(err), fmt.Errorf("cannot decode body: %w", err))
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			logger.Warnf("cannot close request body: %s", err.Error())
+		}
+	}()
+	var err error
+	p, tearDownFn, ok := a.provider(w, r)
+	if tearDownFn != nil {
+		defer tearDownFn()
+	}
+	if !ok {
+		return
+	}
+	var mp secret.MetadataValueProvider
+	if requestBody.present() {
+		if mp, err = metadataValueProvider(p); err != nil {
+			a.writeErrorResponse(w, r, errorStatus(err), fmt.Errorf("cannot set metadata: %w", err))
+			return
+		}
+	}
+	revision, err := precondition(r, p, []byte(key))
+	if err != nil {
+		a.writeErrorResponse(w, r, preconditionErrorStatus(err), fmt.Errorf("cannot check precondition: %w", err))
+		return
+	}
+	if mp != nil {
+		// value and metadata are set at once, so the value isn't changed if metadata can't be set
+		revision, err = setDataWithMetadata(r.Context(), mp, []byte(key), []byte(requestBody.Value), requestBody.metadata(), requestBody.ExpiresAt, revision)
+	} else {
+		revision, err = setDataIfRevision(r.Context(), p, []byte(key), []byte(requestBody.Value), requestBody.ExpiresAt, revision)
+	}
+	if err != nil {
+		a.writeErrorResponse(w, r, preconditionErrorStatus(err), fmt.Errorf("cannot set data: %w", err))
+		return
+	}
+	setETag(w, revision)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Delete method removes key from the path.
+func (a *methods) Delete(w http.ResponseWriter, r *http.Request) {
+	key, ok := a.keyParam(w, r)
+	if !ok {
+		return
+	}
+	if !a.authorize(w, r, chi.URLParam(r, ParamMethodKey), auth.ActionDelete, key) {
+		return
+	}
+	p, tearDownFn, ok := a.provider(w, r)
+	if tearDownFn != nil {
+		defer tearDownFn()
+	}
+	if !ok {
+		return
+	}
+	if err := p.DeleteDataCtx(r.Context(), []byte(key)); err != nil {
+		a.writeErrorResponse(w, r, errorStatus(err), fmt.Errorf("cannot delete data: %w", err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// List method returns sorted names of keys, which can be decrypted with cipher key (provided in header).
+// Names can be filtered by "prefix" query parameter and by labels with "label" query parameters.
+// Only keys the caller is allowed to list are returned.
+// Callers, who can't list any key, are rejected before cipher key is derived.
+//
+// Example of response body:
+//
+//    {
+//        "keys": ["db-password", "db-user"]
+//    }
+func (a *methods) List(w http.ResponseWriter, r *http.Request) {
+	if !a.authorizeAction(w, r, chi.URLParam(r, ParamMethodKey), auth.ActionList) {
+		return
+	}
+	p, tearDownFn, ok := a.provider(w, r)
+	if tearDownFn != nil {
+		defer tearDownFn()
+	}
+	if !ok {
+		return
+	}
+	labels, err := secret.ParseLabels(r.URL.Query()[ParamLabelKey])
+	if err != nil {
+		a.writeErrorResponse(w, r, http.StatusBadRequest, fmt.Errorf("cannot parse labels: %w", err))
+		return
+	}
+	var keys [][]byte
+	if len(labels) > 0 {
+		var mp secret.MetadataProvider
+		if mp, err = metadataProvider(p); err == nil {
+			keys, err = mp.ListKeysByLabelsCtx(r.Context(), labels)
+		}
+	} else {
+		keys, err = p.ListKeysCtx(r.Context())
+	}
+	if err != nil {
+		a.writeErrorResponse(w, r, errorStatus(err), fmt.Errorf("cannot list keys: %w", err))
+		return
+	}
+	prefix := r.URL.Query().Get(ParamPrefixKey)
+	responseBody := struct {
+		Keys []string `json:"keys"`
+	}{Keys: make([]string, 0, len(keys))}
+	for _, k := range keys {
+		if strings.HasPrefix(string(k), prefix) && a.allowed(r, chi.URLParam(r, ParamMethodKey), auth.ActionList, string(k)) {
+			responseBody.Keys = append(responseBody.Keys, string(k))
+		}
+	}
+	sort.Strings(responseBody.Keys)
+	a.writeJSONResponse(w, r, responseBody)
+}
+
+// keyParam returns unescaped key from the path.
+func (a *methods) keyParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	key := chi.URLParam(r, "*")
+	// router matches escaped path if it differs from decoded one
+	if r.URL.RawPath != "" {
+		var err error
+		if key, err = url.PathUnescape(key); err != nil {
+			a.writeErrorResponse(w, r, http.StatusBadRequest, fmt.Errorf("cannot decode key: %w", err))
+			return "", false
+		}
+	}
+	if key == "" {
+		a.writeErrorResponse(w, r, http.StatusBadRequest, errors.New("cannot find getter key: empty"))
+		return "", false
+	}
+	return key, true
+}
+
+// provider creates provider for method from the path and cipher key of the request.
+// Tear down function should be called even if provider wasn't created.
+func (a *methods) provider(w http.ResponseWriter, r *http.Request) (secret.ProviderCtx, func(), bool) {
+	return a.methodProvider(w, r, chi.URLParam(r, ParamMethodKey))
+}
+
+// knownMethod checks that method is served and writes error response if it isn't.
+func (a *methods) knownMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if _, ok := a.ss[method]; !ok {
+		a.writeErrorResponse(w, r, http.StatusNotFound, fmt.Errorf("cannot find provided method type %s", method))
+		return false
+	}
+	return true
+}
+
+// methodProvider creates provider for the method and cipher key of the request.
+// Tear down function should be called even if provider wasn't created.
+func (a *methods) methodProvider(w http.ResponseWriter, r *http.Request, method string) (secret.ProviderCtx, func(), bool) {
+	if !a.knownMethod(w, r, method) {
+		return nil, nil, false
+	}
+	factory := a.ss[method]
+	cipherKey, err := a.cipherKey(r, method)
+	if err != nil {
+		a.writeErrorResponse(w, r, errorStatus(err), err)
+		return nil, nil, false
+	}
+	p, tearDownFn := factory(cipherKey)
+	if p == nil {
+		a.writeErrorResponse(w, r, http.StatusInternalServerError, errors.New("cannot create provider"))
+		return nil, tearDownFn, false
+	}
+	return secret.ProviderWithContext(p), tearDownFn, true
+}
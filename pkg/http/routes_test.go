@@ -0,0 +1,176 @@
+package http
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/io/storage"
+	"github.com/go-itools-internship/go-secret/pkg/provider"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func newTestRoutes(t *testing.T, p secret.Provider) *httptest.Server {
+	a := NewMethods(map[string]MethodFactoryFunc{
+		"test-method": func(cipher string) (secret.Provider, func()) {
+			require.EqualValues(t, "1234-5678", cipher)
+			return p, nil
+		},
+	}, createSugarLogger())
+	return httptest.NewServer(a.Routes())
+}
+
+func doRequest(t *testing.T, s *httptest.Server, method, target string, body io.Reader) (*http.Response, string) {
+	req, err := http.NewRequest(method, s.URL+target, body)
+	require.NoError(t, err)
+	req.Header.Set(ParamCipherKey, "1234-5678")
+	resp, err := s.Client().Do(req)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, resp.Body.Close())
+	}()
+	respBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(respBody)
+}
+
+func TestRoutes(t *testing.T) {
+	t.Run("get", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		defer mockProvider.AssertExpectations(t)
+		mockProvider.On("GetData", []byte("team/db password")).Return([]byte("test-value-1"), nil).Once()
+		s := newTestRoutes(t, mockProvider)
+		defer s.Close()
+
+		resp, body := doRequest(t, s, http.MethodGet, "/test-method/team%2Fdb%20password", nil)
+		require.EqualValues(t, http.StatusOK, resp.StatusCode)
+		require.EqualValues(t, `{"value":"test-value-1"}`+jsonTerminator, body)
+	})
+	t.Run("get key with slash", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		defer mockProvider.AssertExpectations(t)
+		mockProvider.On("GetData", []byte("team/key")).Return([]byte("test-value-1"), nil).Once()
+		s := newTestRoutes(t, mockProvider)
+		defer s.Close()
+
+		resp, _ := doRequest(t, s, http.MethodGet, "/test-method/team/key", nil)
+		require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	})
+	t.Run("error if method is not found", func(t *testing.T) {
+		s := newTestRoutes(t, new(MockProvider))
+		defer s.Close()
+
+		resp, body := doRequest(t, s, http.MethodGet, "/unknown/key", nil)
+		require.EqualValues(t, http.StatusNotFound, resp.StatusCode)
+		require.EqualValues(t, `{"error":{"code":"not_found","message":"cannot find provided method type unknown"}}`+jsonTerminator, body)
+	})
+	t.Run("head", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		defer mockProvider.AssertExpectations(t)
+		mockProvider.On("GetData", []byte("key")).Return([]byte("test-value-1"), nil).Once()
+		mockProvider.On("GetData", []byte("missing")).Return(nil, fmt.Errorf("test: %w", secret.ErrNotFound)).Once()
+		s := newTestRoutes(t, mockProvider)
+		defer s.Close()
+
+		resp, body := doRequest(t, s, http.MethodHead, "/test-method/key", nil)
+		require.EqualValues(t, http.StatusOK, resp.StatusCode)
+		require.Empty(t, body)
+		resp, _ = doRequest(t, s, http.MethodHead, "/test-method/missing", nil)
+		require.EqualValues(t, http.StatusNotFound, resp.StatusCode)
+	})
+	t.Run("put", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		defer mockProvider.AssertExpectations(t)
+		mockProvider.On("SetData", []byte("key"), []byte("test-value-1")).Return(nil).Once()
+		s := newTestRoutes(t, mockProvider)
+		defer s.Close()
+
+		resp, _ := doRequest(t, s, http.MethodPut, "/test-method/key", bytes.NewBufferString(`{"value":"test-value-1"}`))
+		require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+	})
+	t.Run("error if put body is invalid", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		s := newTestRoutes(t, mockProvider)
+		defer s.Close()
+
+		resp, _ := doRequest(t, s, http.MethodPut, "/test-method/key", bytes.NewBufferString(`value`))
+		require.EqualValues(t, http.StatusBadRequest, resp.StatusCode)
+		mockProvider.AssertNotCalled(t, "SetData", mock.Anything, mock.Anything)
+	})
+	t.Run("delete", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		defer mockProvider.AssertExpectations(t)
+		mockProvider.On("DeleteData", []byte("key")).Return(nil).Once()
+		mockProvider.On("DeleteData", []byte("missing")).Return(fmt.Errorf("test: %w", secret.ErrNotFound)).Once()
+		s := newTestRoutes(t, mockProvider)
+		defer s.Close()
+
+		resp, _ := doRequest(t, s, http.MethodDelete, "/test-method/key", nil)
+		require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+		resp, _ = doRequest(t, s, http.MethodDelete, "/test-method/missing", nil)
+		require.EqualValues(t, http.StatusNotFound, resp.StatusCode)
+	})
+	t.Run("list", func(t *testing.T) {
+		mockProvider := new(MockProvider)
+		defer mockProvider.AssertExpectations(t)
+		mockProvider.On("ListKeys").Return([][]byte{[]byte("db-user"), []byte("api-token"), []byte("db-password")}, nil).Twice()
+		s := newTestRoutes(t, mockProvider)
+		defer s.Close()
+
+		resp, body := doRequest(t, s, http.MethodGet, "/test-method", nil)
+		require.EqualValues(t, http.StatusOK, resp.StatusCode)
+		require.EqualValues(t, `{"keys":["api-token","db-password","db-user"]}`+jsonTerminator, body)
+
+		resp, body = doRequest(t, s, http.MethodGet, "/test-method?prefix=db-", nil)
+		require.EqualValues(t, http.StatusOK, resp.StatusCode)
+		require.EqualValues(t, `{"keys":["db-password","db-user"]}`+jsonTerminator, body)
+	})
+}
+
+func TestDeprecated(t *testing.T) {
+	h := Deprecated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.EqualValues(t, http.StatusNoContent, w.Code)
+	require.EqualValues(t, "true", w.Header().Get(DeprecationHeader))
+	require.EqualValues(t, `</v1/secrets>; rel="successor-version"`, w.Header().Get("Link"))
+}
+
+func TestRoutes_WrongCipherKey(t *testing.T) {
+	ds := storage.NewMemoryVault()
+	a := NewMethods(map[string]MethodFactoryFunc{
+		"test-method": func(cipher string) (secret.Provider, func()) {
+			return provider.NewProvider(crypto.NewCryptographer([]byte(cipher), rand.Reader), ds), nil
+		},
+	}, createSugarLogger())
+	s := httptest.NewServer(a.Routes())
+	defer s.Close()
+
+	resp, _ := doRequest(t, s, http.MethodPut, "/test-method/key", bytes.NewBufferString(`{"value":"test-value-1"}`))
+	require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		req, err := http.NewRequest(method, s.URL+"/test-method/key", nil)
+		require.NoError(t, err)
+		req.Header.Set(ParamCipherKey, "wrong-key")
+		resp, err := s.Client().Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.EqualValues(t, http.StatusUnauthorized, resp.StatusCode, method)
+	}
+	resp, _ = doRequest(t, s, http.MethodGet, "/test-method/key", nil)
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	// missing key is still not found with the right cipher key
+	resp, _ = doRequest(t, s, http.MethodGet, "/test-method/other", nil)
+	require.EqualValues(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+	t.Run("file vault by relative path", func(t *testing.T) {
+		defer func() {
+			require.NoError(t, os.Remove(testFilename))
+		}()
+		ds, closeFn, err := Open(ctx, "file://"+testFilename, BackendOptions{MaxVersions: 1})
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, closeFn())
+		}()
+		require.NoError(t, ds.SaveData([]byte("key"), []byte("value 1")))
+		require.NoError(t, ds.SaveData([]byte("key"), []byte("value 2")))
+
+		versions, err := ds.(secret.VersionedDataSaver).VersionsCtx(ctx, []byte("key"))
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+		_, err = os.Stat(testFilename)
+		require.NoError(t, err)
+	})
+	t.Run("file vault by path with spaces", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "secret file.txt")
+		ds, _, err := Open(ctx, FileURL(path), BackendOptions{})
+		require.NoError(t, err)
+		require.NoError(t, ds.SaveData([]byte("key"), []byte("value")))
+		_, err = os.Stat(path)
+		require.NoError(t, err)
+	})
+	t.Run("sealed file vault is detected", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "sealed.txt")
+		vault, err := NewSealedFileVault(path, []byte("passphrase"), SealKDFCost(4))
+		require.NoError(t, err)
+		require.NoError(t, vault.SaveData([]byte("key"), []byte("value")))
+
+		ds, _, err := Open(ctx, FileURL(path), BackendOptions{Passphrase: []byte("passphrase")})
+		require.NoError(t, err)
+		got, err := ds.ReadData([]byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value", got)
+	})
+	t.Run("error if sealed parameter is wrong", func(t *testing.T) {
+		_, _, err := Open(ctx, "file://"+testFilename+"?sealed=maybe", BackendOptions{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "filevault: can't parse sealed parameter")
+	})
+	t.Run("error if scheme is unknown", func(t *testing.T) {
+		_, _, err := Open(ctx, "mongodb://localhost", BackendOptions{})
+		require.True(t, errors.Is(err, ErrUnknownScheme))
+		require.EqualValues(t, `storage: unknown storage scheme "mongodb"`, err.Error())
+	})
+	t.Run("registered backend", func(t *testing.T) {
+		var got *url.URL
+		Register("test-backend", func(_ context.Context, u *url.URL, o BackendOptions) (secret.DataSaver, func() error, error) {
+			got = u
+			return nil, nil, errors.New("test error")
+		})
+		require.Contains(t, Schemes(), "test-backend")
+		require.Panics(t, func() {
+			Register("test-backend", openFile)
+		})
+
+		_, _, err := Open(ctx, "test-backend://host/name", BackendOptions{})
+		require.EqualError(t, err, "test error")
+		require.EqualValues(t, "host", got.Host)
+	})
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// ReadDataBatchCtx returns values by keys, the file is read once.
+// Value is nil if there is no such key.
+func (f *fileVault) ReadDataBatchCtx(ctx context.Context, keys [][]byte) ([][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("filevault: %w", err)
+	}
+	for _, key := range keys {
+		if len(key) == 0 {
+			return nil, fmt.Errorf("filevault: %w", secret.ErrEmptyKey)
+		}
+	}
+	if err := f.read(); err != nil {
+		return nil, fmt.Errorf("filevault: unable to read file while reading: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		value, _ := f.value(hex.EncodeToString(key))
+		values[i] = cloneBytes(value)
+	}
+	return values, nil
+}
+
+// SaveDataBatchCtx saves values by keys as new versions, the file is written once.
+func (f *fileVault) SaveDataBatchCtx(ctx context.Context, entries []secret.Entry) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("filevault: %w", err)
+	}
+	for _, e := range entries {
+		if len(e.Key) == 0 {
+			return fmt.Errorf("filevault: %w", secret.ErrEmptyKey)
+		}
+	}
+	err := f.update(func() error {
+		for _, e := range entries {
+			f.put(hex.EncodeToString(e.Key), e.Value, time.Time{})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("filevault: unable to save data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// expiryKey is the entry of file vault document with expiration times of values.
+// It can't be a key of data, because keys of data are hex encoded.
+const expiryKey = "expiry"
+
+// SaveDataWithExpiryCtx saves value, which expires at the time.
+// Expired value is removed from the file by ReapCtx.
+func (f *fileVault) SaveDataWithExpiryCtx(ctx context.Context, key, encodedValue []byte, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("filevault: %w", err)
+	}
+	return f.save(key, encodedValue, expiresAt)
+}
+
+// ExpiryCtx returns expiration time of the value by key.
+func (f *fileVault) ExpiryCtx(ctx context.Context, key []byte) (time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, fmt.Errorf("filevault: %w", err)
+	}
+	if len(key) == 0 {
+		return time.Time{}, fmt.Errorf("filevault: %w", secret.ErrEmptyKey)
+	}
+	if err := f.read(); err != nil {
+		return time.Time{}, fmt.Errorf("filevault: unable to read file while reading expiry: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	hexKey := hex.EncodeToString(key)
+	if _, ok := f.value(hexKey); !ok {
+		return time.Time{}, fmt.Errorf("filevault: cannot read expiry: %w", secret.ErrNotFound)
+	}
+	return f.expiry[hexKey], nil
+}
+
+// ReapCtx removes expired values with their versions from the file.
+// File isn't written if there are no expired values.
+func (f *fileVault) ReapCtx(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, fmt.Errorf("filevault: %w", err)
+	}
+	if err := f.read(); err != nil {
+		return 0, fmt.Errorf("filevault: unable to read file while reaping: %w", err)
+	}
+	f.mu.Lock()
+	n := len(f.expiredKeys())
+	f.mu.Unlock()
+	if n == 0 {
+		return 0, nil
+	}
+	err := f.update(func() error {
+		expired := f.expiredKeys()
+		for _, hexKey := range expired {
+			f.remove(hexKey)
+		}
+		n = len(expired)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("filevault: unable to save data while reaping: %w", err)
+	}
+	return n, nil
+}
+
+// save saves value by key as the new version, which expires at the time.
+func (f *fileVault) save(key, encodedValue []byte, expiresAt time.Time) error {
+	_, err := f.saveIfRevision(key, encodedValue, expiresAt, secret.AnyRevision)
+	return err
+}
+
+// value returns value by key if it exists and isn't expired.
+// Mutex should be locked by caller.
+func (f *fileVault) value(hexKey string) ([]byte, bool) {
+	data, ok := f.storage[hexKey]
+	if !ok || f.expired(hexKey) {
+		return nil, false
+	}
+	return data, true
+}
+
+// expired reports whether value by key has expired.
+// Mutex should be locked by caller.
+func (f *fileVault) expired(hexKey string) bool {
+	expiresAt, ok := f.expiry[hexKey]
+	return ok && !time.Now().Before(expiresAt)
+}
+
+// expiredKeys returns keys of expired values.
+// Mutex should be locked by caller.
+func (f *fileVault) expiredKeys() []string {
+	var keys []string
+	for hexKey := range f.expiry {
+		if f.expired(hexKey) {
+			keys = append(keys, hexKey)
+		}
+	}
+	return keys
+}
+
+// remove deletes value by key with its versions, expiration time and metadata.
+// Mutex should be locked by caller.
+func (f *fileVault) remove(hexKey string) {
+	delete(f.storage, hexKey)
+	delete(f.versions, hexKey)
+	delete(f.expiry, hexKey)
+	delete(f.metadata, hexKey)
+}
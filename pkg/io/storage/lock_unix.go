@@ -0,0 +1,81 @@
+//go:build !windows
+// +build !windows
+
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"syscall"
+)
+
+// lockFile locks the file by path with flock and returns its content.
+// Lock is exclusive for writers and shared for readers, it's held until unlock is called.
+// Writers replace the file by rename, so lock is taken again if the file was replaced while waiting.
+// Returns empty content if exclusive lock is requested and the file doesn't exist yet.
+func lockFile(path string, exclusive bool) ([]byte, func(), error) {
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	for {
+		file, err := os.OpenFile(path, os.O_RDONLY, 0600)
+		if err != nil {
+			if exclusive && os.IsNotExist(err) {
+				return nil, func() {}, nil
+			}
+			return nil, nil, fmt.Errorf("unable to open file: %w", err)
+		}
+		unlock := func() {
+			_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+			_ = file.Close()
+		}
+		if err := syscall.Flock(int(file.Fd()), how); err != nil {
+			_ = file.Close()
+			return nil, nil, fmt.Errorf("unable to lock file: %w", err)
+		}
+		locked, err := file.Stat()
+		if err != nil {
+			unlock()
+			return nil, nil, fmt.Errorf("unable to stat file: %w", err)
+		}
+		current, err := os.Stat(path)
+		if err != nil && !os.IsNotExist(err) {
+			unlock()
+			return nil, nil, fmt.Errorf("unable to stat file: %w", err)
+		}
+		if err != nil || !os.SameFile(locked, current) {
+			unlock()
+			continue
+		}
+		data, err := ioutil.ReadAll(file)
+		if err != nil {
+			unlock()
+			return nil, nil, fmt.Errorf("unable to read file: %w", err)
+		}
+		return data, unlock, nil
+	}
+}
+
+// syncDir flushes directory entry of renamed file to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("unable to open dir: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("unable to sync dir: %w", err)
+	}
+	return nil
+}
+
+// lockOpenFile locks the open file exclusively with flock until it's closed.
+// Lock isn't waited for, error is returned if another process holds it.
+func lockOpenFile(file *os.File) error {
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		return fmt.Errorf("unable to lock file, it's used by another process: %w", err)
+	}
+	return nil
+}
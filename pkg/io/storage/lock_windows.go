@@ -0,0 +1,34 @@
+//go:build windows
+// +build windows
+
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// lockFile returns content of the file by path.
+// Advisory locking isn't implemented on windows, so only writes within one process are serialized.
+// Returns empty content if exclusive lock is requested and the file doesn't exist yet.
+func lockFile(path string, exclusive bool) ([]byte, func(), error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if exclusive && os.IsNotExist(err) {
+			return nil, func() {}, nil
+		}
+		return nil, nil, fmt.Errorf("unable to open file: %w", err)
+	}
+	return data, func() {}, nil
+}
+
+// syncDir does nothing, because directories can't be synced on windows.
+func syncDir(string) error {
+	return nil
+}
+
+// lockOpenFile does nothing, because advisory locking isn't implemented on windows.
+func lockOpenFile(*os.File) error {
+	return nil
+}
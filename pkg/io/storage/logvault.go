@@ -0,0 +1,538 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// Log vault keeps values in append-only log file, every transaction is a single record:
+//
+//    crc32 of payload (4 bytes) | payload length (4 bytes) | payload
+//
+// Payload is the list of operations: op (1 byte), key length (uvarint), key, value length (uvarint), value.
+// Record is written and synced before the transaction is applied, so the transaction is either
+// fully in the log or not at all. Torn or corrupted last record of the log is dropped on open,
+// corrupted record or record length followed by other records fails the open, so committed transactions aren't lost.
+// Index of the latest values is kept in memory, values are read from the log by offset.
+const (
+	logOpPut    byte = 1
+	logOpDelete byte = 2
+
+	logHeaderSize = 8
+	// logMaxRecordSize limits payload of the record, bigger length in the header is the corruption.
+	logMaxRecordSize = 64 << 20
+	// logCompactMinGarbage is the size of overwritten and deleted data, after which log is compacted
+	// if it exceeds the size of live data as well.
+	logCompactMinGarbage = 1 << 20
+	// logCompactSuffix is the suffix of the new log written by compaction.
+	logCompactSuffix = ".compact"
+)
+
+var (
+	errLogClosed    = errors.New("log is closed")
+	errLogCorrupted = errors.New("log is corrupted")
+	logChecksumTab  = crc32.MakeTable(crc32.Castagnoli)
+)
+
+type logVault struct {
+	mu   sync.RWMutex
+	file *os.File
+	path string
+	// size is the end of the last valid record
+	size int64
+	// live is the size of keys and values in the index
+	live  int64
+	index map[string]logPosition
+	// keys are sorted keys of the index for prefix scans
+	keys []string
+}
+
+// logPosition is the place of the value in the log.
+type logPosition struct {
+	offset int64
+	size   int
+}
+
+// logOp is the operation of the transaction.
+type logOp struct {
+	op    byte
+	key   []byte
+	value []byte
+}
+
+// NewLogVault creates storage in the append-only log file by path.
+// File is created if it doesn't exist. It's locked while the vault is open, so it can't be shared by processes.
+// Vault should be closed by Close when it isn't needed anymore.
+func NewLogVault(path string) (*logVault, error) {
+	path = filepath.Clean(path)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("logvault: unable to open file: %w", err)
+	}
+	if err := lockOpenFile(file); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("logvault: %w", err)
+	}
+	// new log of interrupted compaction is incomplete, the old one is still valid
+	if err := os.Remove(path + logCompactSuffix); err != nil && !os.IsNotExist(err) {
+		_ = file.Close()
+		return nil, fmt.Errorf("logvault: unable to remove incomplete compaction: %w", err)
+	}
+	l := &logVault{file: file, path: path, index: make(map[string]logPosition)}
+	if err := l.load(); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("logvault: %w", err)
+	}
+	return l, nil
+}
+
+// load reads the index from the log and truncates torn tail of the log.
+// Only the last record can be torn by crash, so checksum mismatch of another record is the corruption.
+func (l *logVault) load() error {
+	info, err := l.file.Stat()
+	if err != nil {
+		return fmt.Errorf("unable to stat log: %w", err)
+	}
+	r := bufio.NewReader(io.NewSectionReader(l.file, 0, info.Size()))
+	var offset int64
+	header := make([]byte, logHeaderSize)
+	for offset < info.Size() {
+		if info.Size()-offset < logHeaderSize {
+			return l.truncate(offset)
+		}
+		if _, err := io.ReadFull(r, header); err != nil {
+			return fmt.Errorf("unable to read log: %w", err)
+		}
+		n := int64(binary.BigEndian.Uint32(header[4:]))
+		if n > logMaxRecordSize {
+			if offset+logHeaderSize < info.Size() {
+				return fmt.Errorf("%w: invalid length of record at %d", errLogCorrupted, offset)
+			}
+			return l.truncate(offset)
+		}
+		if info.Size()-offset-logHeaderSize < n {
+			// torn last record is shorter than its length, corrupted length may point past other records
+			after, err := l.recordAfter(offset+logHeaderSize, info.Size())
+			if err != nil {
+				return err
+			}
+			if after {
+				return fmt.Errorf("%w: length of record at %d exceeds the log", errLogCorrupted, offset)
+			}
+			return l.truncate(offset)
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return fmt.Errorf("unable to read log: %w", err)
+		}
+		if crc32.Checksum(payload, logChecksumTab) != binary.BigEndian.Uint32(header) {
+			if offset+logHeaderSize+n == info.Size() {
+				return l.truncate(offset)
+			}
+			return fmt.Errorf("%w: checksum mismatch of record at %d", errLogCorrupted, offset)
+		}
+		ops, valueOffsets, err := decodeLogOps(payload)
+		if err != nil {
+			return fmt.Errorf("unable to decode record at %d: %w", offset, err)
+		}
+		l.apply(ops, valueOffsets, offset+logHeaderSize)
+		offset += logHeaderSize + n
+		l.size = offset
+	}
+	return nil
+}
+
+// recordAfter reports whether a valid record starts in the log between start and end.
+// Torn record is the last one, so there is no valid record after its header.
+// Record after corrupted one starts within max record size, so only this part of the log is searched.
+func (l *logVault) recordAfter(start, end int64) (bool, error) {
+	window := end - start
+	if window > logHeaderSize+logMaxRecordSize {
+		window = logHeaderSize + logMaxRecordSize
+	}
+	buf := make([]byte, window)
+	if _, err := l.file.ReadAt(buf, start); err != nil {
+		return false, fmt.Errorf("unable to read log: %w", err)
+	}
+	for p := int64(0); p+logHeaderSize <= window; p++ {
+		n := int64(binary.BigEndian.Uint32(buf[p+4:]))
+		// empty payload isn't written, so zeroed tail isn't taken for records
+		if n == 0 || n > logMaxRecordSize || start+p+logHeaderSize+n > end {
+			continue
+		}
+		payload := make([]byte, n)
+		if _, err := l.file.ReadAt(payload, start+p+logHeaderSize); err != nil {
+			return false, fmt.Errorf("unable to read log: %w", err)
+		}
+		if crc32.Checksum(payload, logChecksumTab) != binary.BigEndian.Uint32(buf[p:]) {
+			continue
+		}
+		if _, _, err := decodeLogOps(payload); err == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// truncate drops the log after offset.
+func (l *logVault) truncate(offset int64) error {
+	if err := l.file.Truncate(offset); err != nil {
+		return fmt.Errorf("unable to truncate torn log: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("unable to sync log: %w", err)
+	}
+	l.size = offset
+	return nil
+}
+
+// SaveData saves value by key.
+func (l *logVault) SaveData(key, encodedValue []byte) error {
+	return l.SaveDataCtx(context.Background(), key, encodedValue)
+}
+
+// ReadData returns value by key.
+func (l *logVault) ReadData(key []byte) ([]byte, error) {
+	return l.ReadDataCtx(context.Background(), key)
+}
+
+// DeleteData removes value by key.
+func (l *logVault) DeleteData(key []byte) error {
+	return l.DeleteDataCtx(context.Background(), key)
+}
+
+// ListKeys returns all keys sorted.
+func (l *logVault) ListKeys() ([][]byte, error) {
+	return l.ListKeysCtx(context.Background())
+}
+
+// SaveDataCtx is SaveData which checks context first.
+func (l *logVault) SaveDataCtx(ctx context.Context, key, encodedValue []byte) error {
+	return l.SaveDataBatchCtx(ctx, []secret.Entry{{Key: key, Value: encodedValue}})
+}
+
+// ReadDataCtx is ReadData which checks context first.
+func (l *logVault) ReadDataCtx(ctx context.Context, key []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("logvault: %w", err)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("logvault: %w", secret.ErrEmptyKey)
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	pos, ok := l.index[string(key)]
+	if !ok {
+		return nil, fmt.Errorf("logvault: cannot read data: %w", secret.ErrNotFound)
+	}
+	value, err := l.value(pos)
+	if err != nil {
+		return nil, fmt.Errorf("logvault: unable to read data: %w", err)
+	}
+	return value, nil
+}
+
+// DeleteDataCtx is DeleteData which checks context first.
+func (l *logVault) DeleteDataCtx(ctx context.Context, key []byte) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("logvault: %w", err)
+	}
+	if len(key) == 0 {
+		return fmt.Errorf("logvault: %w", secret.ErrEmptyKey)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.index[string(key)]; !ok {
+		return fmt.Errorf("logvault: cannot delete data: %w", secret.ErrNotFound)
+	}
+	if err := l.commit([]logOp{{op: logOpDelete, key: key}}); err != nil {
+		return fmt.Errorf("logvault: unable to delete data: %w", err)
+	}
+	return nil
+}
+
+// ListKeysCtx is ListKeys which checks context first.
+func (l *logVault) ListKeysCtx(ctx context.Context) ([][]byte, error) {
+	return l.ScanPrefixCtx(ctx, nil)
+}
+
+// ScanPrefixCtx returns sorted keys, which start with the prefix.
+// Only the keys with the prefix are visited.
+func (l *logVault) ScanPrefixCtx(ctx context.Context, prefix []byte) ([][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("logvault: %w", err)
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	keys := make([][]byte, 0)
+	for i := sort.SearchStrings(l.keys, string(prefix)); i < len(l.keys); i++ {
+		if !bytes.HasPrefix([]byte(l.keys[i]), prefix) {
+			break
+		}
+		keys = append(keys, []byte(l.keys[i]))
+	}
+	return keys, nil
+}
+
+// ReadDataBatchCtx returns values by keys.
+// Value is nil if there is no such key.
+func (l *logVault) ReadDataBatchCtx(ctx context.Context, keys [][]byte) ([][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("logvault: %w", err)
+	}
+	for _, key := range keys {
+		if len(key) == 0 {
+			return nil, fmt.Errorf("logvault: %w", secret.ErrEmptyKey)
+		}
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		pos, ok := l.index[string(key)]
+		if !ok {
+			continue
+		}
+		var err error
+		if values[i], err = l.value(pos); err != nil {
+			return nil, fmt.Errorf("logvault: unable to read data: %w", err)
+		}
+	}
+	return values, nil
+}
+
+// SaveDataBatchCtx saves values by keys in single transaction.
+func (l *logVault) SaveDataBatchCtx(ctx context.Context, entries []secret.Entry) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("logvault: %w", err)
+	}
+	ops := make([]logOp, 0, len(entries))
+	for _, e := range entries {
+		if len(e.Key) == 0 {
+			return fmt.Errorf("logvault: %w", secret.ErrEmptyKey)
+		}
+		ops = append(ops, logOp{op: logOpPut, key: e.Key, value: e.Value})
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.commit(ops); err != nil {
+		return fmt.Errorf("logvault: unable to save data: %w", err)
+	}
+	return nil
+}
+
+// Compact rewrites the log with the latest values only.
+// New log replaces the old one by rename, so the old log is kept if compaction is interrupted.
+// Log is compacted automatically when overwritten and deleted data exceeds live data.
+func (l *logVault) Compact() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.compact(); err != nil {
+		return fmt.Errorf("logvault: unable to compact: %w", err)
+	}
+	return nil
+}
+
+// Close releases the log file. Vault can't be used after that.
+func (l *logVault) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	if err != nil {
+		return fmt.Errorf("logvault: unable to close file: %w", err)
+	}
+	return nil
+}
+
+// value reads value from the log by position.
+// Mutex should be locked by caller.
+func (l *logVault) value(pos logPosition) ([]byte, error) {
+	if l.file == nil {
+		return nil, errLogClosed
+	}
+	value := make([]byte, pos.size)
+	if _, err := l.file.ReadAt(value, pos.offset); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// commit appends the transaction to the log, syncs it and applies it to the index.
+// Log is compacted after that if it has too much garbage, data is committed even if compaction fails.
+// Mutex should be locked by caller.
+func (l *logVault) commit(ops []logOp) error {
+	if l.file == nil {
+		return errLogClosed
+	}
+	payload, valueOffsets := encodeLogOps(ops)
+	if len(payload) > logMaxRecordSize {
+		return fmt.Errorf("transaction of %d bytes exceeds max record size %d", len(payload), logMaxRecordSize)
+	}
+	record := make([]byte, logHeaderSize, logHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record, crc32.Checksum(payload, logChecksumTab))
+	binary.BigEndian.PutUint32(record[4:], uint32(len(payload)))
+	record = append(record, payload...)
+	if _, err := l.file.WriteAt(record, l.size); err != nil {
+		// partially written record is dropped, it would be dropped on next open anyway
+		_ = l.file.Truncate(l.size)
+		return fmt.Errorf("unable to write log: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("unable to sync log: %w", err)
+	}
+	l.apply(ops, valueOffsets, l.size+logHeaderSize)
+	l.size += int64(len(record))
+	if garbage := l.size - l.live; garbage > logCompactMinGarbage && garbage > l.live {
+		_ = l.compact()
+	}
+	return nil
+}
+
+// apply updates the index with operations of the record, which payload starts at offset.
+func (l *logVault) apply(ops []logOp, valueOffsets []int, offset int64) {
+	for i, op := range ops {
+		key := string(op.key)
+		old, exists := l.index[key]
+		if exists {
+			l.live -= int64(len(key) + old.size)
+		}
+		switch op.op {
+		case logOpPut:
+			if !exists {
+				at := sort.SearchStrings(l.keys, key)
+				l.keys = append(l.keys, "")
+				copy(l.keys[at+1:], l.keys[at:])
+				l.keys[at] = key
+			}
+			l.index[key] = logPosition{offset: offset + int64(valueOffsets[i]), size: len(op.value)}
+			l.live += int64(len(key) + len(op.value))
+		case logOpDelete:
+			if exists {
+				at := sort.SearchStrings(l.keys, key)
+				l.keys = append(l.keys[:at], l.keys[at+1:]...)
+				delete(l.index, key)
+			}
+		}
+	}
+}
+
+// compact writes the latest values to the new log and replaces the old log with it.
+// Mutex should be locked by caller.
+func (l *logVault) compact() error {
+	if l.file == nil {
+		return errLogClosed
+	}
+	tmpPath := l.path + logCompactSuffix
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to create file: %w", err)
+	}
+	compacted := &logVault{file: tmp, path: l.path, index: make(map[string]logPosition, len(l.index))}
+	err = func() error {
+		if err := lockOpenFile(tmp); err != nil {
+			return err
+		}
+		w := bufio.NewWriter(tmp)
+		for _, key := range l.keys {
+			value, err := l.value(l.index[key])
+			if err != nil {
+				return fmt.Errorf("unable to read data: %w", err)
+			}
+			ops := []logOp{{op: logOpPut, key: []byte(key), value: value}}
+			payload, valueOffsets := encodeLogOps(ops)
+			var header [logHeaderSize]byte
+			binary.BigEndian.PutUint32(header[:], crc32.Checksum(payload, logChecksumTab))
+			binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+			if _, err := w.Write(header[:]); err != nil {
+				return err
+			}
+			if _, err := w.Write(payload); err != nil {
+				return err
+			}
+			compacted.apply(ops, valueOffsets, compacted.size+logHeaderSize)
+			compacted.size += logHeaderSize + int64(len(payload))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if err := tmp.Sync(); err != nil {
+			return err
+		}
+		if err := os.Rename(tmpPath, l.path); err != nil {
+			return err
+		}
+		return syncDir(filepath.Dir(l.path))
+	}()
+	if err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	_ = l.file.Close()
+	l.file, l.size, l.live, l.index, l.keys = tmp, compacted.size, compacted.live, compacted.index, compacted.keys
+	return nil
+}
+
+// encodeLogOps encodes operations to the payload of the record.
+// Returns offsets of values in the payload as well.
+func encodeLogOps(ops []logOp) ([]byte, []int) {
+	var buf bytes.Buffer
+	valueOffsets := make([]int, len(ops))
+	varint := make([]byte, binary.MaxVarintLen64)
+	for i, op := range ops {
+		buf.WriteByte(op.op)
+		buf.Write(varint[:binary.PutUvarint(varint, uint64(len(op.key)))])
+		buf.Write(op.key)
+		buf.Write(varint[:binary.PutUvarint(varint, uint64(len(op.value)))])
+		valueOffsets[i] = buf.Len()
+		buf.Write(op.value)
+	}
+	return buf.Bytes(), valueOffsets
+}
+
+// decodeLogOps decodes operations from the payload of the record.
+// Values of operations point to the payload.
+func decodeLogOps(payload []byte) ([]logOp, []int, error) {
+	var ops []logOp
+	var valueOffsets []int
+	next := func(pos int) ([]byte, int, error) {
+		n, size := binary.Uvarint(payload[pos:])
+		if size <= 0 || uint64(len(payload)-pos-size) < n {
+			return nil, 0, errors.New("invalid length")
+		}
+		start := pos + size
+		return payload[start : start+int(n)], start + int(n), nil
+	}
+	for pos := 0; pos < len(payload); {
+		op := logOp{op: payload[pos]}
+		if op.op != logOpPut && op.op != logOpDelete {
+			return nil, nil, fmt.Errorf("unknown operation %d", op.op)
+		}
+		var err error
+		if op.key, pos, err = next(pos + 1); err != nil {
+			return nil, nil, err
+		}
+		if op.value, pos, err = next(pos); err != nil {
+			return nil, nil, err
+		}
+		ops = append(ops, op)
+		valueOffsets = append(valueOffsets, pos-len(op.value))
+	}
+	return ops, valueOffsets, nil
+}
@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/io/storage/storagetest"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func TestLogVault(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "secrets.log")
+	var _ secret.BatchDataSaver = &logVault{}
+
+	t.Run("save, read, list and delete", func(t *testing.T) {
+		l, err := NewLogVault(path)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, l.Close())
+		}()
+		require.NoError(t, l.SaveData([]byte("b"), []byte("value b")))
+		require.NoError(t, l.SaveData([]byte("a"), []byte("value a")))
+		require.NoError(t, l.SaveData([]byte("b"), []byte("new value b")))
+		require.NoError(t, l.SaveData([]byte("c"), []byte("value c")))
+
+		got, err := l.ReadData([]byte("b"))
+		require.NoError(t, err)
+		require.EqualValues(t, "new value b", got)
+		keys, err := l.ListKeys()
+		require.NoError(t, err)
+		require.EqualValues(t, [][]byte{[]byte("a"), []byte("b"), []byte("c")}, keys)
+
+		require.NoError(t, l.DeleteData([]byte("c")))
+		_, err = l.ReadData([]byte("c"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		err = l.DeleteData([]byte("c"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		err = l.SaveData(nil, []byte("value"))
+		require.True(t, errors.Is(err, secret.ErrEmptyKey))
+	})
+	t.Run("data is kept after reopen", func(t *testing.T) {
+		l, err := NewLogVault(path)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, l.Close())
+		}()
+		values, err := l.ReadDataBatchCtx(ctx, [][]byte{[]byte("a"), []byte("c"), []byte("b")})
+		require.NoError(t, err)
+		require.EqualValues(t, [][]byte{[]byte("value a"), nil, []byte("new value b")}, values)
+	})
+	t.Run("torn transaction is dropped on open", func(t *testing.T) {
+		l, err := NewLogVault(path)
+		require.NoError(t, err)
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.NoError(t, l.SaveDataBatchCtx(ctx, []secret.Entry{
+			{Key: []byte("a"), Value: []byte("torn value a")},
+			{Key: []byte("d"), Value: []byte("torn value d")},
+		}))
+		require.NoError(t, l.Close())
+		// crash in the middle of writing the record
+		full, err := os.Stat(path)
+		require.NoError(t, err)
+		require.NoError(t, os.Truncate(path, full.Size()-3))
+
+		l, err = NewLogVault(path)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, l.Close())
+		}()
+		values, err := l.ReadDataBatchCtx(ctx, [][]byte{[]byte("a"), []byte("d")})
+		require.NoError(t, err)
+		require.EqualValues(t, [][]byte{[]byte("value a"), nil}, values)
+		truncated, err := os.Stat(path)
+		require.NoError(t, err)
+		require.EqualValues(t, info.Size(), truncated.Size())
+
+		require.NoError(t, l.SaveData([]byte("d"), []byte("value d")))
+		got, err := l.ReadData([]byte("d"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value d", got)
+	})
+	t.Run("corrupted record is dropped on open", func(t *testing.T) {
+		l, err := NewLogVault(path)
+		require.NoError(t, err)
+		require.NoError(t, l.SaveData([]byte("e"), []byte("value e")))
+		require.NoError(t, l.Close())
+		file, err := os.OpenFile(path, os.O_RDWR, 0600)
+		require.NoError(t, err)
+		info, err := file.Stat()
+		require.NoError(t, err)
+		_, err = file.WriteAt([]byte("X"), info.Size()-1)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+
+		l, err = NewLogVault(path)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, l.Close())
+		}()
+		_, err = l.ReadData([]byte("e"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		got, err := l.ReadData([]byte("d"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value d", got)
+	})
+	t.Run("error if record in the middle is corrupted", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "secrets.log")
+		l, err := NewLogVault(path)
+		require.NoError(t, err)
+		require.NoError(t, l.SaveData([]byte("a"), []byte("value a")))
+		middle := l.size
+		require.NoError(t, l.SaveData([]byte("b"), []byte("value b")))
+		require.NoError(t, l.SaveData([]byte("c"), []byte("value c")))
+		require.NoError(t, l.Close())
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		file, err := os.OpenFile(path, os.O_RDWR, 0600)
+		require.NoError(t, err)
+		_, err = file.WriteAt([]byte("X"), middle+logHeaderSize+1)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+
+		_, err = NewLogVault(path)
+		require.True(t, errors.Is(err, errLogCorrupted))
+		require.Contains(t, err.Error(), fmt.Sprintf("checksum mismatch of record at %d", middle))
+		after, err := os.Stat(path)
+		require.NoError(t, err)
+		require.EqualValues(t, info.Size(), after.Size(), "committed records shouldn't be dropped")
+	})
+	t.Run("error if length of record in the middle is corrupted", func(t *testing.T) {
+		for name, length := range map[string]uint32{"above max record size": 0xffffffff, "past the end of log": 1 << 10} {
+			t.Run(name, func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), "secrets.log")
+				l, err := NewLogVault(path)
+				require.NoError(t, err)
+				require.NoError(t, l.SaveData([]byte("a"), []byte("value a")))
+				middle := l.size
+				require.NoError(t, l.SaveData([]byte("b"), []byte("value b")))
+				require.NoError(t, l.SaveData([]byte("c"), []byte("value c")))
+				require.NoError(t, l.Close())
+				info, err := os.Stat(path)
+				require.NoError(t, err)
+				file, err := os.OpenFile(path, os.O_RDWR, 0600)
+				require.NoError(t, err)
+				header := make([]byte, 4)
+				binary.BigEndian.PutUint32(header, length)
+				_, err = file.WriteAt(header, middle+4)
+				require.NoError(t, err)
+				require.NoError(t, file.Close())
+
+				_, err = NewLogVault(path)
+				require.True(t, errors.Is(err, errLogCorrupted), "%v", err)
+				require.Contains(t, err.Error(), fmt.Sprintf("record at %d", middle))
+				after, err := os.Stat(path)
+				require.NoError(t, err)
+				require.EqualValues(t, info.Size(), after.Size(), "committed records shouldn't be dropped")
+			})
+		}
+	})
+	t.Run("error if file is used by another vault", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("file locking isn't implemented on windows")
+		}
+		l, err := NewLogVault(path)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, l.Close())
+		}()
+		_, err = NewLogVault(path)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "it's used by another process")
+	})
+	t.Run("error if vault is closed", func(t *testing.T) {
+		l, err := NewLogVault(path)
+		require.NoError(t, err)
+		require.NoError(t, l.Close())
+		require.NoError(t, l.Close())
+		_, err = l.ReadData([]byte("a"))
+		require.True(t, errors.Is(err, errLogClosed))
+		err = l.SaveData([]byte("a"), []byte("value"))
+		require.True(t, errors.Is(err, errLogClosed))
+	})
+}
+
+func TestLogVault_ScanPrefix(t *testing.T) {
+	ctx := context.Background()
+	l, err := NewLogVault(filepath.Join(t.TempDir(), "secrets.log"))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, l.Close())
+	}()
+	for _, key := range []string{"team-b/x", "team-a/y", "team", "team-a/x", "other"} {
+		require.NoError(t, l.SaveData([]byte(key), []byte("value")))
+	}
+
+	keys, err := l.ScanPrefixCtx(ctx, []byte("team-a/"))
+	require.NoError(t, err)
+	require.EqualValues(t, [][]byte{[]byte("team-a/x"), []byte("team-a/y")}, keys)
+	keys, err = l.ScanPrefixCtx(ctx, []byte("missing"))
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
+
+func TestLogVault_Compact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.log")
+	l, err := NewLogVault(path)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, l.SaveData([]byte("key"), []byte("value "+strconv.Itoa(i))))
+		require.NoError(t, l.SaveData([]byte("deleted"), []byte("value")))
+		require.NoError(t, l.DeleteData([]byte("deleted")))
+	}
+	before, err := os.Stat(path)
+	require.NoError(t, err)
+
+	require.NoError(t, l.Compact())
+	after, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Less(t, after.Size(), before.Size()/100)
+	got, err := l.ReadData([]byte("key"))
+	require.NoError(t, err)
+	require.EqualValues(t, "value 99", got)
+
+	require.NoError(t, l.SaveData([]byte("new"), []byte("new value")))
+	require.NoError(t, l.Close())
+	l, err = NewLogVault(path)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, l.Close())
+	}()
+	keys, err := l.ListKeys()
+	require.NoError(t, err)
+	require.EqualValues(t, [][]byte{[]byte("key"), []byte("new")}, keys)
+	got, err = l.ReadData([]byte("new"))
+	require.NoError(t, err)
+	require.EqualValues(t, "new value", got)
+}
+
+func TestLogVault_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) secret.DataSaver {
+		l, err := NewLogVault(filepath.Join(t.TempDir(), "secrets.log"))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, l.Close())
+		})
+		return l
+	})
+}
@@ -0,0 +1,19 @@
+package storage
+
+// NewMemoryVault creates storage, which keeps data in memory of the process only.
+// It's the file vault without the file, so it keeps versions, expiration times, metadata and revisions the same way.
+// Data is lost when the vault isn't referenced anymore, so it's useful for tests and ephemeral servers.
+func NewMemoryVault(opts ...Option) *fileVault {
+	f := newFileVault("", newOptions(opts))
+	f.memory = true
+	return f
+}
+
+// cloneBytes returns the copy of b.
+// Values are copied in and out of in-memory storage, so memory vault doesn't share them with callers.
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/io/storage/storagetest"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func TestMemoryVault(t *testing.T) {
+	ctx := context.Background()
+	var _ secret.BatchDataSaver = NewMemoryVault()
+	var _ secret.RevisionedDataSaver = NewMemoryVault()
+	var _ secret.Reaper = NewMemoryVault()
+
+	t.Run("save, read, list and delete", func(t *testing.T) {
+		m := NewMemoryVault()
+		require.NoError(t, m.SaveData([]byte("a"), []byte("value a")))
+		require.NoError(t, m.SaveData([]byte("b"), []byte("value b")))
+
+		got, err := m.ReadData([]byte("a"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value a", got)
+		keys, err := m.ListKeys()
+		require.NoError(t, err)
+		require.ElementsMatch(t, [][]byte{[]byte("a"), []byte("b")}, keys)
+
+		require.NoError(t, m.DeleteData([]byte("a")))
+		_, err = m.ReadData([]byte("a"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		err = m.DeleteData([]byte("a"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		err = m.SaveData(nil, []byte("value"))
+		require.True(t, errors.Is(err, secret.ErrEmptyKey))
+	})
+	t.Run("vaults don't share data", func(t *testing.T) {
+		m := NewMemoryVault()
+		require.NoError(t, m.SaveData([]byte("key"), []byte("value")))
+		_, err := NewMemoryVault().ReadData([]byte("key"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+	})
+	t.Run("versions", func(t *testing.T) {
+		m := NewMemoryVault(MaxVersions(2))
+		for _, v := range []string{"value 1", "value 2", "value 3"} {
+			require.NoError(t, m.SaveData([]byte("key"), []byte(v)))
+		}
+		versions, err := m.VersionsCtx(ctx, []byte("key"))
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		require.EqualValues(t, 2, versions[0].Number)
+		got, err := m.ReadVersionCtx(ctx, []byte("key"), 2)
+		require.NoError(t, err)
+		require.EqualValues(t, "value 2", got)
+	})
+	t.Run("expiry", func(t *testing.T) {
+		m := NewMemoryVault()
+		require.NoError(t, m.SaveDataWithExpiryCtx(ctx, []byte("expired"), []byte("value"), time.Now().Add(-time.Second)))
+		require.NoError(t, m.SaveDataWithExpiryCtx(ctx, []byte("key"), []byte("value"), time.Now().Add(time.Hour)))
+
+		_, err := m.ReadData([]byte("expired"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		keys, err := m.ListKeys()
+		require.NoError(t, err)
+		require.EqualValues(t, [][]byte{[]byte("key")}, keys)
+		removed, err := m.ReapCtx(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, removed)
+	})
+	t.Run("revision", func(t *testing.T) {
+		m := NewMemoryVault()
+		revision, err := m.SaveDataIfRevisionCtx(ctx, []byte("key"), []byte("value"), time.Time{}, 0)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, revision)
+		_, err = m.SaveDataIfRevisionCtx(ctx, []byte("key"), []byte("value"), time.Time{}, 0)
+		require.True(t, errors.Is(err, secret.ErrRevisionMismatch))
+	})
+}
+
+func TestMemoryVault_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) secret.DataSaver {
+		return NewMemoryVault()
+	})
+}
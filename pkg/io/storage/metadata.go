@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// metadataKey is the entry of file vault document with metadata of values.
+// It can't be a key of data, because keys of data are hex encoded.
+const metadataKey = "metadata"
+
+// fileMetadata is the metadata of the value in file vault.
+// Creation time is zero for values saved before file vault started to keep metadata.
+type fileMetadata struct {
+	Data      []byte    `json:"data,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SaveMetadataCtx saves encoded metadata of the value by key.
+func (f *fileVault) SaveMetadataCtx(ctx context.Context, key, encodedMetadata []byte) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("filevault: %w", err)
+	}
+	if len(key) == 0 {
+		return fmt.Errorf("filevault: %w", secret.ErrEmptyKey)
+	}
+	err := f.update(func() error {
+		hexKey := hex.EncodeToString(key)
+		if _, ok := f.value(hexKey); !ok {
+			return secret.ErrNotFound
+		}
+		f.touch(hexKey, false)
+		md := f.metadata[hexKey]
+		md.Data = cloneBytes(encodedMetadata)
+		f.metadata[hexKey] = md
+		return nil
+	})
+	if errors.Is(err, secret.ErrNotFound) {
+		return fmt.Errorf("filevault: cannot save metadata: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("filevault: unable to save metadata: %w", err)
+	}
+	return nil
+}
+
+// SaveDataWithMetadataCtx saves value, which expires at the time, with encoded metadata, if it has the revision.
+// Both of them are written to the file at once.
+func (f *fileVault) SaveDataWithMetadataCtx(ctx context.Context, key, encodedValue, encodedMetadata []byte, expiresAt time.Time, revision int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, fmt.Errorf("filevault: %w", err)
+	}
+	if len(key) == 0 {
+		return 0, fmt.Errorf("filevault: %w", secret.ErrEmptyKey)
+	}
+	var saved int
+	err := f.update(func() error {
+		hexKey := hex.EncodeToString(key)
+		if revision != secret.AnyRevision && f.revision(hexKey) != revision {
+			return secret.ErrRevisionMismatch
+		}
+		saved = f.put(hexKey, encodedValue, expiresAt)
+		md := f.metadata[hexKey]
+		md.Data = cloneBytes(encodedMetadata)
+		f.metadata[hexKey] = md
+		return nil
+	})
+	if errors.Is(err, secret.ErrRevisionMismatch) {
+		return 0, fmt.Errorf("filevault: cannot save data: %w", err)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("filevault: unable to save data with metadata: %w", err)
+	}
+	return saved, nil
+}
+
+// ReadMetadataCtx returns metadata of the value by key.
+func (f *fileVault) ReadMetadataCtx(ctx context.Context, key []byte) (secret.MetadataRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return secret.MetadataRecord{}, fmt.Errorf("filevault: %w", err)
+	}
+	if len(key) == 0 {
+		return secret.MetadataRecord{}, fmt.Errorf("filevault: %w", secret.ErrEmptyKey)
+	}
+	if err := f.read(); err != nil {
+		return secret.MetadataRecord{}, fmt.Errorf("filevault: unable to read file while reading metadata: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	hexKey := hex.EncodeToString(key)
+	if _, ok := f.value(hexKey); !ok {
+		return secret.MetadataRecord{}, fmt.Errorf("filevault: cannot read metadata: %w", secret.ErrNotFound)
+	}
+	md := f.metadata[hexKey]
+	return secret.MetadataRecord{Data: cloneBytes(md.Data), CreatedAt: md.CreatedAt, UpdatedAt: md.UpdatedAt}, nil
+}
+
+// touch updates update time of the value by key, creation time is set for the new value.
+// Mutex should be locked by caller.
+func (f *fileVault) touch(hexKey string, created bool) {
+	now := time.Now().UTC()
+	md := f.metadata[hexKey]
+	if created {
+		md = fileMetadata{CreatedAt: now}
+	}
+	md.UpdatedAt = now
+	f.metadata[hexKey] = md
+}
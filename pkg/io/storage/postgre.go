@@ -3,56 +3,305 @@ package storage
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
 )
 
 type postgreVault struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	options options
 }
 
+// notExpired is the condition for rows of postgres table, which aren't expired.
+const notExpired = "(expires_at IS NULL OR expires_at > now())"
+
 // NewPostgreVault create new postgreSQL  client
-func NewPostgreVault(p *sqlx.DB) *postgreVault {
+// Every save creates a new version of the value, see MaxVersions.
+// Versions are kept in the table created by migration 2, expiration times are added by migration 3,
+// metadata by migration 4 and revisions by migration 5.
+func NewPostgreVault(p *sqlx.DB, opts ...Option) *postgreVault {
 	pv := &postgreVault{
-		db: p,
+		db:      p,
+		options: newOptions(opts),
 	}
 	return pv
 }
 
-// SaveData put data in postgres storage by key and encoded value
+// SaveDataCtx put data in postgres storage by key and encoded value
 // 	key to set in postgres storage
 // 	encoded value to storage
-func (r *postgreVault) SaveData(key, encodedValue []byte) error {
-	ctx := context.Background()
+// Every save creates a new version of the value, see MaxVersions.
+// Saved value never expires.
+func (r *postgreVault) SaveDataCtx(ctx context.Context, key, encodedValue []byte) error {
+	return r.save(ctx, key, encodedValue, time.Time{})
+}
+
+// SaveDataWithExpiryCtx is SaveDataCtx for value, which expires at the time.
+// Expired value is removed by ReapCtx.
+func (r *postgreVault) SaveDataWithExpiryCtx(ctx context.Context, key, encodedValue []byte, expiresAt time.Time) error {
+	return r.save(ctx, key, encodedValue, expiresAt)
+}
+
+// ExpiryCtx returns expiration time of the value by key.
+func (r *postgreVault) ExpiryCtx(ctx context.Context, key []byte) (time.Time, error) {
 	if bytes.Equal(key, []byte("")) {
-		return errors.New("postgres: key can't be nil")
+		return time.Time{}, fmt.Errorf("postgres: %w", secret.ErrEmptyKey)
 	}
-	tx, err := r.db.BeginTxx(ctx, nil)
+	var expiry []sql.NullTime
+	err := r.db.SelectContext(ctx, &expiry, "SELECT expires_at FROM postgres WHERE key=$1 AND "+notExpired+";", hex.EncodeToString(key))
 	if err != nil {
-		return fmt.Errorf("postgres: can't begin transaction: %w", err)
+		return time.Time{}, fmt.Errorf("postgres: %w", err)
 	}
-	if bytes.Equal(encodedValue, []byte("")) {
-		_, err = tx.ExecContext(ctx, "DELETE FROM postgres WHERE key=$1;", string(key))
+	if len(expiry) == 0 {
+		return time.Time{}, fmt.Errorf("postgres: %w", secret.ErrNotFound)
+	}
+	if !expiry[0].Valid {
+		return time.Time{}, nil
+	}
+	return expiry[0].Time.UTC(), nil
+}
+
+// ReapCtx removes expired values with their versions.
+func (r *postgreVault) ReapCtx(ctx context.Context) (int, error) {
+	var n int64
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, "DELETE FROM versions WHERE key IN (SELECT key FROM postgres WHERE expires_at <= now());")
+		if err != nil {
+			return fmt.Errorf("can't delete versions: %w", err)
+		}
+		res, err := tx.ExecContext(ctx, "DELETE FROM postgres WHERE expires_at <= now();")
 		if err != nil {
-			rErr := tx.Rollback()
-			if rErr != nil {
-				return fmt.Errorf("postgres: can't rollback err=%v: %w", rErr, err)
+			return fmt.Errorf("can't delete data: %w", err)
+		}
+		if n, err = res.RowsAffected(); err != nil {
+			return fmt.Errorf("can't get deleted rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+func (r *postgreVault) save(ctx context.Context, key, encodedValue []byte, expiresAt time.Time) error {
+	if bytes.Equal(key, []byte("")) {
+		return fmt.Errorf("postgres: %w", secret.ErrEmptyKey)
+	}
+	hexKey := hex.EncodeToString(key)
+	if bytes.Equal(encodedValue, []byte("")) {
+		return r.inTx(ctx, func(tx *sqlx.Tx) error {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM versions WHERE key=$1;", hexKey); err != nil {
+				return fmt.Errorf("can't delete versions: %w", err)
 			}
-			return fmt.Errorf("postgres: can't delete data: %w", err)
+			if _, err := tx.ExecContext(ctx, "DELETE FROM postgres WHERE key=$1;", hexKey); err != nil {
+				return fmt.Errorf("can't delete data: %w", err)
+			}
+			return nil
+		})
+	}
+	_, err := r.saveIfRevision(ctx, hexKey, encodedValue, nil, expiresAt, secret.AnyRevision)
+	return err
+}
+
+// ReadDataWithRevisionCtx returns value by key with its revision.
+// Revision is the number of the latest version.
+func (r *postgreVault) ReadDataWithRevisionCtx(ctx context.Context, key []byte) ([]byte, int, error) {
+	if bytes.Equal(key, []byte("")) {
+		return nil, 0, fmt.Errorf("postgres: %w", secret.ErrEmptyKey)
+	}
+	var val []struct {
+		Value    string `db:"value"`
+		Revision int    `db:"revision"`
+	}
+	err := r.db.SelectContext(ctx, &val, "SELECT value, revision FROM postgres WHERE key=$1 AND "+notExpired+" LIMIT 1;", hex.EncodeToString(key))
+	if err != nil {
+		return nil, 0, fmt.Errorf("postgres: %w", err)
+	}
+	if len(val) == 0 {
+		return nil, 0, fmt.Errorf("postgres: %w", secret.ErrNotFound)
+	}
+	value, err := hex.DecodeString(val[0].Value)
+	if err != nil {
+		return nil, 0, fmt.Errorf("postgres: cant't decode value: %w", err)
+	}
+	return value, val[0].Revision, nil
+}
+
+// SaveDataIfRevisionCtx saves value, which expires at the time, if it has the revision.
+// Row of the value is updated only where its revision matches, so concurrent saves are detected.
+func (r *postgreVault) SaveDataIfRevisionCtx(ctx context.Context, key, encodedValue []byte, expiresAt time.Time, revision int) (int, error) {
+	if bytes.Equal(key, []byte("")) {
+		return 0, fmt.Errorf("postgres: %w", secret.ErrEmptyKey)
+	}
+	return r.saveIfRevision(ctx, hex.EncodeToString(key), encodedValue, nil, expiresAt, revision)
+}
+
+// SaveDataWithMetadataCtx saves value, which expires at the time, with encoded metadata, if it has the revision.
+// Both of them are saved in single transaction.
+func (r *postgreVault) SaveDataWithMetadataCtx(ctx context.Context, key, encodedValue, encodedMetadata []byte, expiresAt time.Time, revision int) (int, error) {
+	if bytes.Equal(key, []byte("")) {
+		return 0, fmt.Errorf("postgres: %w", secret.ErrEmptyKey)
+	}
+	if encodedMetadata == nil {
+		encodedMetadata = []byte{}
+	}
+	return r.saveIfRevision(ctx, hex.EncodeToString(key), encodedValue, encodedMetadata, expiresAt, revision)
+}
+
+// ReadDataBatchCtx returns values by keys with single query.
+// Value is nil if there is no such key.
+func (r *postgreVault) ReadDataBatchCtx(ctx context.Context, keys [][]byte) ([][]byte, error) {
+	hexKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if bytes.Equal(key, []byte("")) {
+			return nil, fmt.Errorf("postgres: %w", secret.ErrEmptyKey)
 		}
-	} else {
-		_, err = tx.ExecContext(ctx, "INSERT INTO postgres (key , value) VALUES ($1,$2) ON CONFLICT (key) DO UPDATE SET value=$2;", hex.EncodeToString(key), hex.EncodeToString(encodedValue))
+		hexKeys = append(hexKeys, hex.EncodeToString(key))
+	}
+	var rows []struct {
+		Key   string `db:"key"`
+		Value string `db:"value"`
+	}
+	err := r.db.SelectContext(ctx, &rows, "SELECT key, value FROM postgres WHERE key = ANY($1) AND "+notExpired+";", pq.Array(hexKeys))
+	if err != nil {
+		return nil, fmt.Errorf("postgres: %w", err)
+	}
+	found := make(map[string][]byte, len(rows))
+	for _, row := range rows {
+		value, err := hex.DecodeString(row.Value)
 		if err != nil {
-			rErr := tx.Rollback()
-			if rErr != nil {
-				return fmt.Errorf("postgres: can't rollback err=%v: %w", rErr, err)
+			return nil, fmt.Errorf("postgres: cant't decode value: %w", err)
+		}
+		found[row.Key] = value
+	}
+	values := make([][]byte, len(keys))
+	for i, hexKey := range hexKeys {
+		values[i] = found[hexKey]
+	}
+	return values, nil
+}
+
+// SaveDataBatchCtx saves values by keys as new versions in single transaction.
+// Saved values never expire.
+func (r *postgreVault) SaveDataBatchCtx(ctx context.Context, entries []secret.Entry) error {
+	for _, e := range entries {
+		if bytes.Equal(e.Key, []byte("")) {
+			return fmt.Errorf("postgres: %w", secret.ErrEmptyKey)
+		}
+	}
+	return r.inTx(ctx, func(tx *sqlx.Tx) error {
+		for _, e := range entries {
+			if _, err := r.saveTx(ctx, tx, hex.EncodeToString(e.Key), e.Value, time.Time{}, secret.AnyRevision); err != nil {
+				return err
 			}
-			return fmt.Errorf("postgres: can't insert data: %w", err)
 		}
+		return nil
+	})
+}
+
+// saveIfRevision saves value as the new version, which expires at the time, if it has the revision.
+// Metadata of the value is replaced in the same transaction if encoded metadata isn't nil.
+// Returns the new revision of the value.
+func (r *postgreVault) saveIfRevision(ctx context.Context, hexKey string, encodedValue, encodedMetadata []byte, expiresAt time.Time, revision int) (int, error) {
+	var saved int
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		var err error
+		if saved, err = r.saveTx(ctx, tx, hexKey, encodedValue, expiresAt, revision); err != nil {
+			return err
+		}
+		if encodedMetadata == nil {
+			return nil
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE postgres SET metadata=$2 WHERE key=$1;", hexKey, hex.EncodeToString(encodedMetadata))
+		if err != nil {
+			return fmt.Errorf("can't update metadata: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return saved, nil
+}
+
+// saveTx is saveIfRevision in the transaction.
+// Versions of expired value are dropped, so history starts again.
+func (r *postgreVault) saveTx(ctx context.Context, tx *sqlx.Tx, hexKey string, encodedValue []byte, expiresAt time.Time, revision int) (int, error) {
+	expiry := sql.NullTime{Time: expiresAt, Valid: !expiresAt.IsZero()}
+	// existing row is locked until commit, so concurrent saves of the key don't both backfill its legacy version
+	_, err := tx.ExecContext(ctx, "SELECT 1 FROM postgres WHERE key=$1 FOR UPDATE;", hexKey)
+	if err != nil {
+		return 0, fmt.Errorf("can't lock data: %w", err)
+	}
+	// versions of expired value are dropped, so history starts again
+	_, err = tx.ExecContext(ctx, "DELETE FROM versions WHERE key IN (SELECT key FROM postgres WHERE key=$1 AND expires_at <= now());", hexKey)
+	if err != nil {
+		return 0, fmt.Errorf("can't delete expired versions: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM postgres WHERE key=$1 AND expires_at <= now();", hexKey)
+	if err != nil {
+		return 0, fmt.Errorf("can't delete expired data: %w", err)
+	}
+	// value saved before postgres vault started to keep versions becomes the version 1
+	_, err = tx.ExecContext(ctx, "INSERT INTO versions (key, version, value) SELECT key, 1, value FROM postgres WHERE key=$1 AND NOT EXISTS (SELECT 1 FROM versions WHERE key=$1) ON CONFLICT (key, version) DO NOTHING;", hexKey)
+	if err != nil {
+		return 0, fmt.Errorf("can't save legacy version: %w", err)
+	}
+	var saved []int
+	// row of the key is locked by upsert until commit, so concurrent saves get different revisions
+	query := "INSERT INTO postgres (key, value, expires_at, created_at, updated_at, revision) VALUES ($1,$2,$3,now(),now(),1) "
+	switch revision {
+	case secret.AnyRevision:
+		query += "ON CONFLICT (key) DO UPDATE SET value=$2, expires_at=$3, updated_at=now(), revision=postgres.revision+1 RETURNING revision;"
+		err = tx.SelectContext(ctx, &saved, query, hexKey, hex.EncodeToString(encodedValue), expiry)
+	case 0:
+		query += "ON CONFLICT (key) DO NOTHING RETURNING revision;"
+		err = tx.SelectContext(ctx, &saved, query, hexKey, hex.EncodeToString(encodedValue), expiry)
+	default:
+		query += "ON CONFLICT (key) DO UPDATE SET value=$2, expires_at=$3, updated_at=now(), revision=postgres.revision+1 WHERE postgres.revision=$4 RETURNING revision;"
+		err = tx.SelectContext(ctx, &saved, query, hexKey, hex.EncodeToString(encodedValue), expiry, revision)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("can't insert data: %w", err)
+	}
+	// new row has revision 1, which is matched only by revision 0
+	if len(saved) == 0 || (saved[0] == 1 && revision > 0) {
+		return 0, secret.ErrRevisionMismatch
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO versions (key, version, value, created_at) VALUES ($1, $2, $3, now());", hexKey, saved[0], hex.EncodeToString(encodedValue))
+	if err != nil {
+		return 0, fmt.Errorf("can't insert version: %w", err)
+	}
+	if r.options.maxVersions > 0 {
+		_, err = tx.ExecContext(ctx, "DELETE FROM versions WHERE key=$1 AND version <= $2;", hexKey, saved[0]-r.options.maxVersions)
+		if err != nil {
+			return 0, fmt.Errorf("can't delete old versions: %w", err)
+		}
+	}
+	return saved[0], nil
+}
+
+// inTx runs fn in transaction, which is committed if fn succeeds and rolled back otherwise.
+func (r *postgreVault) inTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("postgres: can't begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		rErr := tx.Rollback()
+		if rErr != nil {
+			return fmt.Errorf("postgres: can't rollback err=%v: %w", rErr, err)
+		}
+		return fmt.Errorf("postgres: %w", err)
 	}
 	err = tx.Commit()
 	if err != nil {
@@ -65,22 +314,21 @@ func (r *postgreVault) SaveData(key, encodedValue []byte) error {
 	return nil
 }
 
-// ReadData get data from postgres storage by key
+// ReadDataCtx get data from postgres storage by key
 // 	key to get value for pair key-value from postgres storage
-func (r *postgreVault) ReadData(key []byte) ([]byte, error) {
-	ctx := context.Background()
+func (r *postgreVault) ReadDataCtx(ctx context.Context, key []byte) ([]byte, error) {
 	if bytes.Equal(key, []byte("")) {
-		return nil, errors.New("postgres: key can't be nil")
+		return nil, fmt.Errorf("postgres: %w", secret.ErrEmptyKey)
 	}
 	var val []struct {
 		Value string `db:"value"`
 	}
-	err := r.db.SelectContext(ctx, &val, "SELECT value FROM postgres WHERE key=$1 LIMIT 1;", hex.EncodeToString(key))
+	err := r.db.SelectContext(ctx, &val, "SELECT value FROM postgres WHERE key=$1 AND "+notExpired+" LIMIT 1;", hex.EncodeToString(key))
 	if err != nil {
 		return nil, fmt.Errorf("postgres: %w", err)
 	}
 	if len(val) == 0 {
-		return nil, errors.New("postgres: key not found")
+		return nil, fmt.Errorf("postgres: %w", secret.ErrNotFound)
 	}
 	value, err := hex.DecodeString(val[0].Value)
 	if err != nil {
@@ -88,3 +336,226 @@ func (r *postgreVault) ReadData(key []byte) ([]byte, error) {
 	}
 	return value, nil
 }
+
+// DeleteDataCtx remove data from postgres storage by key
+// 	key to delete pair key-value from postgres storage
+func (r *postgreVault) DeleteDataCtx(ctx context.Context, key []byte) error {
+	if bytes.Equal(key, []byte("")) {
+		return fmt.Errorf("postgres: %w", secret.ErrEmptyKey)
+	}
+	hexKey := hex.EncodeToString(key)
+	return r.inTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM versions WHERE key=$1;", hexKey); err != nil {
+			return fmt.Errorf("can't delete versions: %w", err)
+		}
+		res, err := tx.ExecContext(ctx, "DELETE FROM postgres WHERE key=$1 AND "+notExpired+";", hexKey)
+		if err != nil {
+			return fmt.Errorf("can't delete data: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("can't get deleted rows: %w", err)
+		}
+		if n == 0 {
+			return secret.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// ListKeysCtx get all keys from postgres storage
+func (r *postgreVault) ListKeysCtx(ctx context.Context) ([][]byte, error) {
+	var hexKeys []string
+	err := r.db.SelectContext(ctx, &hexKeys, "SELECT key FROM postgres WHERE "+notExpired+";")
+	if err != nil {
+		return nil, fmt.Errorf("postgres: %w", err)
+	}
+	keys := make([][]byte, 0, len(hexKeys))
+	for _, hexKey := range hexKeys {
+		key, err := hex.DecodeString(hexKey)
+		if err != nil {
+			return nil, fmt.Errorf("postgres: can't decode key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// SaveData is SaveDataCtx with background context
+func (r *postgreVault) SaveData(key, encodedValue []byte) error {
+	return r.SaveDataCtx(context.Background(), key, encodedValue)
+}
+
+// ReadData is ReadDataCtx with background context
+func (r *postgreVault) ReadData(key []byte) ([]byte, error) {
+	return r.ReadDataCtx(context.Background(), key)
+}
+
+// DeleteData is DeleteDataCtx with background context
+func (r *postgreVault) DeleteData(key []byte) error {
+	return r.DeleteDataCtx(context.Background(), key)
+}
+
+// ListKeys is ListKeysCtx with background context
+func (r *postgreVault) ListKeys() ([][]byte, error) {
+	return r.ListKeysCtx(context.Background())
+}
+
+// VersionsCtx returns versions of the key from the oldest to the latest.
+func (r *postgreVault) VersionsCtx(ctx context.Context, key []byte) ([]secret.Version, error) {
+	if bytes.Equal(key, []byte("")) {
+		return nil, fmt.Errorf("postgres: %w", secret.ErrEmptyKey)
+	}
+	var rows []struct {
+		Version   int          `db:"version"`
+		CreatedAt sql.NullTime `db:"created_at"`
+	}
+	err := r.db.SelectContext(ctx, &rows, "SELECT v.version, v.created_at FROM versions v JOIN postgres p ON p.key=v.key WHERE v.key=$1 AND (p.expires_at IS NULL OR p.expires_at > now()) ORDER BY v.version;", hex.EncodeToString(key))
+	if err != nil {
+		return nil, fmt.Errorf("postgres: %w", err)
+	}
+	if len(rows) == 0 {
+		// value saved before postgres vault started to keep versions is the version 1
+		if _, err := r.ReadDataCtx(ctx, key); err != nil {
+			return nil, err
+		}
+		return []secret.Version{{Number: 1}}, nil
+	}
+	versions := make([]secret.Version, 0, len(rows))
+	for _, row := range rows {
+		v := secret.Version{Number: row.Version}
+		if row.CreatedAt.Valid {
+			v.CreatedAt = row.CreatedAt.Time.UTC()
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// ReadVersionCtx returns encoded value of the version.
+func (r *postgreVault) ReadVersionCtx(ctx context.Context, key []byte, version int) ([]byte, error) {
+	if bytes.Equal(key, []byte("")) {
+		return nil, fmt.Errorf("postgres: %w", secret.ErrEmptyKey)
+	}
+	hexKey := hex.EncodeToString(key)
+	var val []string
+	err := r.db.SelectContext(ctx, &val, "SELECT v.value FROM versions v JOIN postgres p ON p.key=v.key WHERE v.key=$1 AND v.version=$2 AND (p.expires_at IS NULL OR p.expires_at > now());", hexKey, version)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: %w", err)
+	}
+	if len(val) == 0 {
+		if version != 1 {
+			return nil, fmt.Errorf("postgres: cannot read version %d: %w", version, secret.ErrNotFound)
+		}
+		var n int
+		if err := r.db.GetContext(ctx, &n, "SELECT COUNT(*) FROM versions WHERE key=$1;", hexKey); err != nil {
+			return nil, fmt.Errorf("postgres: %w", err)
+		}
+		if n > 0 {
+			return nil, fmt.Errorf("postgres: cannot read version %d: %w", version, secret.ErrNotFound)
+		}
+		// value saved before postgres vault started to keep versions is the version 1
+		return r.ReadDataCtx(ctx, key)
+	}
+	value, err := hex.DecodeString(val[0])
+	if err != nil {
+		return nil, fmt.Errorf("postgres: cant't decode value: %w", err)
+	}
+	return value, nil
+}
+
+// SaveHistoryCtx replaces the value by key and its versions with the history, which expires at the time.
+// Versions over the limit are removed.
+func (r *postgreVault) SaveHistoryCtx(ctx context.Context, key []byte, history []secret.VersionedValue, expiresAt time.Time) error {
+	if bytes.Equal(key, []byte("")) {
+		return fmt.Errorf("postgres: %w", secret.ErrEmptyKey)
+	}
+	if len(history) == 0 {
+		return errors.New("postgres: history is empty")
+	}
+	if max := r.options.maxVersions; max > 0 && len(history) > max {
+		history = history[len(history)-max:]
+	}
+	hexKey := hex.EncodeToString(key)
+	latest := history[len(history)-1]
+	expiry := sql.NullTime{Time: expiresAt, Valid: !expiresAt.IsZero()}
+	return r.inTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, "SELECT 1 FROM postgres WHERE key=$1 FOR UPDATE;", hexKey); err != nil {
+			return fmt.Errorf("can't lock data: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM postgres WHERE key=$1 AND expires_at <= now();", hexKey); err != nil {
+			return fmt.Errorf("can't delete expired data: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM versions WHERE key=$1;", hexKey); err != nil {
+			return fmt.Errorf("can't delete versions: %w", err)
+		}
+		_, err := tx.ExecContext(ctx, "INSERT INTO postgres (key, value, expires_at, created_at, updated_at, revision) VALUES ($1,$2,$3,now(),now(),$4) "+
+			"ON CONFLICT (key) DO UPDATE SET value=$2, expires_at=$3, updated_at=now(), revision=$4;",
+			hexKey, hex.EncodeToString(latest.Value), expiry, latest.Number)
+		if err != nil {
+			return fmt.Errorf("can't insert data: %w", err)
+		}
+		for _, v := range history {
+			// version saved before postgres vault started to keep versions has no creation time
+			createdAt := sql.NullTime{Time: v.CreatedAt, Valid: !v.CreatedAt.IsZero()}
+			_, err := tx.ExecContext(ctx, "INSERT INTO versions (key, version, value, created_at) VALUES ($1, $2, $3, $4);",
+				hexKey, v.Number, hex.EncodeToString(v.Value), createdAt)
+			if err != nil {
+				return fmt.Errorf("can't insert version: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// SaveMetadataCtx saves encoded metadata of the value by key.
+func (r *postgreVault) SaveMetadataCtx(ctx context.Context, key, encodedMetadata []byte) error {
+	if bytes.Equal(key, []byte("")) {
+		return fmt.Errorf("postgres: %w", secret.ErrEmptyKey)
+	}
+	res, err := r.db.ExecContext(ctx, "UPDATE postgres SET metadata=$2, updated_at=now() WHERE key=$1 AND "+notExpired+";",
+		hex.EncodeToString(key), hex.EncodeToString(encodedMetadata))
+	if err != nil {
+		return fmt.Errorf("postgres: can't update metadata: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("postgres: can't get updated rows: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("postgres: %w", secret.ErrNotFound)
+	}
+	return nil
+}
+
+// ReadMetadataCtx returns metadata of the value by key.
+func (r *postgreVault) ReadMetadataCtx(ctx context.Context, key []byte) (secret.MetadataRecord, error) {
+	if bytes.Equal(key, []byte("")) {
+		return secret.MetadataRecord{}, fmt.Errorf("postgres: %w", secret.ErrEmptyKey)
+	}
+	var rows []struct {
+		Metadata  sql.NullString `db:"metadata"`
+		CreatedAt sql.NullTime   `db:"created_at"`
+		UpdatedAt sql.NullTime   `db:"updated_at"`
+	}
+	err := r.db.SelectContext(ctx, &rows, "SELECT metadata, created_at, updated_at FROM postgres WHERE key=$1 AND "+notExpired+";", hex.EncodeToString(key))
+	if err != nil {
+		return secret.MetadataRecord{}, fmt.Errorf("postgres: %w", err)
+	}
+	if len(rows) == 0 {
+		return secret.MetadataRecord{}, fmt.Errorf("postgres: %w", secret.ErrNotFound)
+	}
+	var record secret.MetadataRecord
+	if rows[0].Metadata.Valid {
+		if record.Data, err = hex.DecodeString(rows[0].Metadata.String); err != nil {
+			return secret.MetadataRecord{}, fmt.Errorf("postgres: cant't decode metadata: %w", err)
+		}
+	}
+	if rows[0].CreatedAt.Valid {
+		record.CreatedAt = rows[0].CreatedAt.Time.UTC()
+	}
+	if rows[0].UpdatedAt.Valid {
+		record.UpdatedAt = rows[0].UpdatedAt.Time.UTC()
+	}
+	return record, nil
+}
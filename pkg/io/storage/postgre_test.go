@@ -2,6 +2,9 @@ package storage
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -12,6 +15,9 @@ import (
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/io/storage/storagetest"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
 )
 
 const (
@@ -118,6 +124,61 @@ func TestPostgreVault_ReadData(t *testing.T) {
 	})
 }
 
+func TestPostgreVault_DeleteData(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	db, err := sqlx.ConnectContext(ctx, "postgres", postgreURL)
+	require.NoError(t, err)
+	defer disconnectPDB(db, t)
+
+	t.Run("success", func(t *testing.T) {
+		migrateUp(t)
+		defer migrateDown(t)
+
+		d := NewPostgreVault(db)
+		err = d.SaveData([]byte("k1234"), []byte("value1234"))
+		require.NoError(t, err)
+
+		err = d.DeleteData([]byte("k1234"))
+		require.NoError(t, err)
+
+		data, err := d.ReadData([]byte("k1234"))
+		require.Error(t, err)
+		require.Empty(t, data)
+		require.EqualValues(t, "postgres: key not found", err.Error())
+	})
+	t.Run("error if key not found", func(t *testing.T) {
+		migrateUp(t)
+		defer migrateDown(t)
+
+		d := NewPostgreVault(db)
+		err = d.DeleteData([]byte("k1234"))
+		require.Error(t, err)
+		require.EqualValues(t, "postgres: key not found", err.Error())
+	})
+}
+
+func TestPostgreVault_ListKeys(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	db, err := sqlx.ConnectContext(ctx, "postgres", postgreURL)
+	require.NoError(t, err)
+	defer disconnectPDB(db, t)
+
+	t.Run("success", func(t *testing.T) {
+		migrateUp(t)
+		defer migrateDown(t)
+
+		d := NewPostgreVault(db)
+		require.NoError(t, d.SaveData([]byte("k1"), []byte("value1")))
+		require.NoError(t, d.SaveData([]byte("k2"), []byte("value2")))
+
+		keys, err := d.ListKeys()
+		require.NoError(t, err)
+		require.ElementsMatch(t, [][]byte{[]byte("k1"), []byte("k2")}, keys)
+	})
+}
+
 func migrateUp(t *testing.T) {
 	m, err := migrate.New(
 		migration,
@@ -150,3 +211,251 @@ func disconnectPDB(pdb *sqlx.DB, t *testing.T) {
 		t.Log("can't disconnect postgres db")
 	}
 }
+
+func TestPostgreVault_Versions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	db, err := sqlx.ConnectContext(ctx, "postgres", postgreURL)
+	require.NoError(t, err)
+	defer disconnectPDB(db, t)
+
+	t.Run("success", func(t *testing.T) {
+		migrateUp(t)
+		defer migrateDown(t)
+
+		d := NewPostgreVault(db, MaxVersions(2))
+		for _, v := range []string{"value1", "value2", "value3"} {
+			require.NoError(t, d.SaveData([]byte("k1"), []byte(v)))
+		}
+
+		versions, err := d.VersionsCtx(ctx, []byte("k1"))
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		require.EqualValues(t, 2, versions[0].Number)
+		require.EqualValues(t, 3, versions[1].Number)
+
+		data, err := d.ReadVersionCtx(ctx, []byte("k1"), 2)
+		require.NoError(t, err)
+		require.EqualValues(t, "value2", string(data))
+		_, err = d.ReadVersionCtx(ctx, []byte("k1"), 1)
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+
+		require.NoError(t, d.DeleteData([]byte("k1")))
+		_, err = d.VersionsCtx(ctx, []byte("k1"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+	})
+	t.Run("save history", func(t *testing.T) {
+		migrateUp(t)
+		defer migrateDown(t)
+
+		d := NewPostgreVault(db, MaxVersions(2))
+		created := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+		history := []secret.VersionedValue{
+			{Version: secret.Version{Number: 1}, Value: []byte("value1")},
+			{Version: secret.Version{Number: 4, CreatedAt: created}, Value: []byte("value4")},
+			{Version: secret.Version{Number: 7, CreatedAt: created.Add(time.Hour)}, Value: []byte("value7")},
+		}
+		require.NoError(t, d.SaveData([]byte("k1"), []byte("old value")))
+		require.NoError(t, d.SaveHistoryCtx(ctx, []byte("k1"), history, time.Time{}))
+
+		versions, err := d.VersionsCtx(ctx, []byte("k1"))
+		require.NoError(t, err)
+		require.EqualValues(t, []secret.Version{history[1].Version, history[2].Version}, versions)
+		data, err := d.ReadVersionCtx(ctx, []byte("k1"), 4)
+		require.NoError(t, err)
+		require.EqualValues(t, "value4", string(data))
+		data, revision, err := d.ReadDataWithRevisionCtx(ctx, []byte("k1"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value7", string(data))
+		require.EqualValues(t, 7, revision)
+	})
+}
+
+func TestPostgreVault_Expiry(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	db, err := sqlx.ConnectContext(ctx, "postgres", postgreURL)
+	require.NoError(t, err)
+	defer disconnectPDB(db, t)
+
+	t.Run("success", func(t *testing.T) {
+		migrateUp(t)
+		defer migrateDown(t)
+
+		d := NewPostgreVault(db)
+		require.NoError(t, d.SaveDataWithExpiryCtx(ctx, []byte("k1"), []byte("value1"), time.Now().Add(-time.Second)))
+		require.NoError(t, d.SaveDataWithExpiryCtx(ctx, []byte("k2"), []byte("value2"), time.Now().Add(time.Hour)))
+
+		_, err := d.ReadData([]byte("k1"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		keys, err := d.ListKeys()
+		require.NoError(t, err)
+		require.ElementsMatch(t, [][]byte{[]byte("k2")}, keys)
+		expiry, err := d.ExpiryCtx(ctx, []byte("k2"))
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(time.Hour), expiry, time.Minute)
+
+		n, err := d.ReapCtx(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, n)
+	})
+}
+
+func TestPostgreVault_Metadata(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	db, err := sqlx.ConnectContext(ctx, "postgres", postgreURL)
+	require.NoError(t, err)
+	defer disconnectPDB(db, t)
+
+	t.Run("success", func(t *testing.T) {
+		migrateUp(t)
+		defer migrateDown(t)
+
+		d := NewPostgreVault(db)
+		require.NoError(t, d.SaveData([]byte("k1"), []byte("value1")))
+		require.NoError(t, d.SaveMetadataCtx(ctx, []byte("k1"), []byte(`{"owner":"team-a"}`)))
+		require.NoError(t, d.SaveData([]byte("k1"), []byte("value2")))
+
+		record, err := d.ReadMetadataCtx(ctx, []byte("k1"))
+		require.NoError(t, err)
+		require.EqualValues(t, `{"owner":"team-a"}`, string(record.Data))
+		require.False(t, record.CreatedAt.IsZero())
+		require.True(t, record.UpdatedAt.After(record.CreatedAt))
+
+		err = d.SaveMetadataCtx(ctx, []byte("k2"), []byte(`{"owner":"team-a"}`))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		_, err = d.ReadMetadataCtx(ctx, []byte("k2"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+	})
+	t.Run("save data with metadata", func(t *testing.T) {
+		migrateUp(t)
+		defer migrateDown(t)
+
+		d := NewPostgreVault(db)
+		revision, err := d.SaveDataWithMetadataCtx(ctx, []byte("k1"), []byte("value1"), []byte(`{"owner":"team-a"}`), time.Time{}, 0)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, revision)
+
+		// neither value nor metadata is saved if revision doesn't match
+		_, err = d.SaveDataWithMetadataCtx(ctx, []byte("k1"), []byte("value2"), []byte(`{"owner":"team-b"}`), time.Time{}, 0)
+		require.True(t, errors.Is(err, secret.ErrRevisionMismatch))
+		value, err := d.ReadData([]byte("k1"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value1", value)
+		record, err := d.ReadMetadataCtx(ctx, []byte("k1"))
+		require.NoError(t, err)
+		require.EqualValues(t, `{"owner":"team-a"}`, string(record.Data))
+	})
+}
+
+func TestPostgreVault_Revision(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	db, err := sqlx.ConnectContext(ctx, "postgres", postgreURL)
+	require.NoError(t, err)
+	defer disconnectPDB(db, t)
+
+	t.Run("success", func(t *testing.T) {
+		migrateUp(t)
+		defer migrateDown(t)
+
+		d := NewPostgreVault(db)
+		revision, err := d.SaveDataIfRevisionCtx(ctx, []byte("k1"), []byte("value1"), time.Time{}, 0)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, revision)
+		require.NoError(t, d.SaveData([]byte("k1"), []byte("value2")))
+		revision, err = d.SaveDataIfRevisionCtx(ctx, []byte("k1"), []byte("value3"), time.Time{}, 2)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, revision)
+
+		_, err = d.SaveDataIfRevisionCtx(ctx, []byte("k1"), []byte("stale"), time.Time{}, 2)
+		require.True(t, errors.Is(err, secret.ErrRevisionMismatch))
+		_, err = d.SaveDataIfRevisionCtx(ctx, []byte("k1"), []byte("stale"), time.Time{}, 0)
+		require.True(t, errors.Is(err, secret.ErrRevisionMismatch))
+		_, err = d.SaveDataIfRevisionCtx(ctx, []byte("k2"), []byte("stale"), time.Time{}, 1)
+		require.True(t, errors.Is(err, secret.ErrRevisionMismatch))
+
+		value, revision, err := d.ReadDataWithRevisionCtx(ctx, []byte("k1"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value3", string(value))
+		require.EqualValues(t, 3, revision)
+		versions, err := d.VersionsCtx(ctx, []byte("k1"))
+		require.NoError(t, err)
+		require.Len(t, versions, 3)
+		_, _, err = d.ReadDataWithRevisionCtx(ctx, []byte("k2"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+	})
+	t.Run("concurrent saves of legacy value", func(t *testing.T) {
+		migrateUp(t)
+		defer migrateDown(t)
+
+		// value saved before postgres vault started to keep versions
+		_, err := db.ExecContext(ctx, "INSERT INTO postgres (key, value) VALUES ($1, $2);", hex.EncodeToString([]byte("k1")), hex.EncodeToString([]byte("legacy")))
+		require.NoError(t, err)
+		d := NewPostgreVault(db)
+		const saves = 5
+		errs := make(chan error, saves)
+		for i := 0; i < saves; i++ {
+			go func(i int) {
+				errs <- d.SaveData([]byte("k1"), []byte(fmt.Sprintf("value%d", i)))
+			}(i)
+		}
+		for i := 0; i < saves; i++ {
+			require.NoError(t, <-errs)
+		}
+
+		versions, err := d.VersionsCtx(ctx, []byte("k1"))
+		require.NoError(t, err)
+		require.Len(t, versions, saves+1)
+		for i, v := range versions {
+			require.EqualValues(t, i+1, v.Number)
+		}
+		value, err := d.ReadVersionCtx(ctx, []byte("k1"), 1)
+		require.NoError(t, err)
+		require.EqualValues(t, "legacy", value)
+	})
+}
+
+func TestPostgreVault_Batch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	db, err := sqlx.ConnectContext(ctx, "postgres", postgreURL)
+	require.NoError(t, err)
+	defer disconnectPDB(db, t)
+
+	t.Run("success", func(t *testing.T) {
+		migrateUp(t)
+		defer migrateDown(t)
+
+		d := NewPostgreVault(db)
+		require.NoError(t, d.SaveData([]byte("k1"), []byte("old value")))
+		err := d.SaveDataBatchCtx(ctx, []secret.Entry{
+			{Key: []byte("k1"), Value: []byte("value1")},
+			{Key: []byte("k2"), Value: []byte("value2")},
+		})
+		require.NoError(t, err)
+
+		values, err := d.ReadDataBatchCtx(ctx, [][]byte{[]byte("k2"), []byte("k3"), []byte("k1")})
+		require.NoError(t, err)
+		require.EqualValues(t, [][]byte{[]byte("value2"), nil, []byte("value1")}, values)
+		versions, err := d.VersionsCtx(ctx, []byte("k1"))
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+	})
+}
+
+func TestPostgreVault_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) secret.DataSaver {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		db, err := sqlx.ConnectContext(ctx, "postgres", postgreURL)
+		require.NoError(t, err)
+		migrateUp(t)
+		t.Cleanup(func() {
+			migrateDown(t)
+			disconnectPDB(db, t)
+		})
+		return NewPostgreVault(db)
+	})
+}
@@ -6,59 +6,573 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-redis/redis/v8"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
 )
 
 type redisVault struct {
-	client *redis.Client
+	client  *redis.Client
+	options options
 }
 
 // NewRedisVault create new redis client
-func NewRedisVault(rdb *redis.Client) *redisVault {
+// Every save creates a new version of the value, see MaxVersions.
+func NewRedisVault(rdb *redis.Client, opts ...Option) *redisVault {
 	rv := &redisVault{
-		client: rdb,
+		client:  rdb,
+		options: newOptions(opts),
 	}
 	return rv
 }
 
-// SaveData put data in redis storage by key and encoded value
+// SaveDataCtx put data in redis storage by key and encoded value
 // 	key to set in redis storage (can't be nil)
 // 	encoded value to storage
-func (r *redisVault) SaveData(key, encodedValue []byte) error {
-	ctx := context.Background()
+// Saved value never expires.
+func (r *redisVault) SaveDataCtx(ctx context.Context, key, encodedValue []byte) error {
+	return r.save(ctx, key, encodedValue, time.Time{})
+}
+
+// SaveDataWithExpiryCtx is SaveDataCtx for value, which expires at the time.
+// Redis removes expired value with its versions by itself.
+func (r *redisVault) SaveDataWithExpiryCtx(ctx context.Context, key, encodedValue []byte, expiresAt time.Time) error {
+	return r.save(ctx, key, encodedValue, expiresAt)
+}
+
+// ExpiryCtx returns expiration time of the value by key.
+// Redis keeps time to live, so expiration time is calculated from the current time.
+func (r *redisVault) ExpiryCtx(ctx context.Context, key []byte) (time.Time, error) {
+	if bytes.Equal(key, []byte("")) {
+		return time.Time{}, fmt.Errorf("storage: %w", secret.ErrEmptyKey)
+	}
+	ttl, err := r.client.PTTL(ctx, hex.EncodeToString(key)).Result()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("storage: redis client can't get expiry %w", err)
+	}
+	switch ttl {
+	case -2:
+		return time.Time{}, fmt.Errorf("storage: %w", secret.ErrNotFound)
+	case -1:
+		return time.Time{}, nil
+	}
+	return time.Now().Add(ttl).UTC(), nil
+}
+
+func (r *redisVault) save(ctx context.Context, key, encodedValue []byte, expiresAt time.Time) error {
 	if bytes.Equal(key, []byte("")) {
-		return errors.New("storage: key can't be nil")
+		return fmt.Errorf("storage: %w", secret.ErrEmptyKey)
 	}
+	hexKey := hex.EncodeToString(key)
 	if bytes.Equal(encodedValue, []byte("")) {
-		fmt.Println("Key was deleted")
-		_, err := r.client.Del(ctx, hex.EncodeToString(key)).Result()
+		_, err := r.client.Del(ctx, hexKey, redisVersionsKey(hexKey), redisCounterKey(hexKey), redisMetadataKey(hexKey)).Result()
 		if err != nil {
 			return fmt.Errorf("storage: %w", err)
 		}
 		return nil
 	}
-	fmt.Println(hex.EncodeToString(key))
-	err := r.client.Set(ctx, hex.EncodeToString(key), encodedValue, 0).Err()
+	_, err := r.saveIfRevision(ctx, hexKey, encodedValue, nil, expiresAt, secret.AnyRevision)
+	return err
+}
+
+// ReadDataWithRevisionCtx returns value by key with its revision.
+// Revision is the number of the latest version.
+func (r *redisVault) ReadDataWithRevisionCtx(ctx context.Context, key []byte) ([]byte, int, error) {
+	if bytes.Equal(key, []byte("")) {
+		return nil, 0, fmt.Errorf("storage: %w", secret.ErrEmptyKey)
+	}
+	hexKey := hex.EncodeToString(key)
+	var val, counter *redis.StringCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		val = pipe.Get(ctx, hexKey)
+		counter = pipe.Get(ctx, redisCounterKey(hexKey))
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, 0, fmt.Errorf("storage: redis client can't get data %w", err)
+	}
+	if errors.Is(val.Err(), redis.Nil) {
+		return nil, 0, fmt.Errorf("storage: %w", secret.ErrNotFound)
+	}
+	revision := 1 // value saved before redis vault started to keep versions
+	if counter.Err() == nil {
+		if revision, err = strconv.Atoi(counter.Val()); err != nil {
+			return nil, 0, fmt.Errorf("storage: invalid revision: %w", err)
+		}
+	}
+	return []byte(val.Val()), revision, nil
+}
+
+// SaveDataIfRevisionCtx saves value, which expires at the time, if it has the revision.
+// Revision is compared by the same script, which saves the value, so concurrent saves are detected.
+func (r *redisVault) SaveDataIfRevisionCtx(ctx context.Context, key, encodedValue []byte, expiresAt time.Time, revision int) (int, error) {
+	if bytes.Equal(key, []byte("")) {
+		return 0, fmt.Errorf("storage: %w", secret.ErrEmptyKey)
+	}
+	return r.saveIfRevision(ctx, hex.EncodeToString(key), encodedValue, nil, expiresAt, revision)
+}
+
+// SaveDataWithMetadataCtx saves value, which expires at the time, with encoded metadata, if it has the revision.
+// Both of them are saved by the same script.
+func (r *redisVault) SaveDataWithMetadataCtx(ctx context.Context, key, encodedValue, encodedMetadata []byte, expiresAt time.Time, revision int) (int, error) {
+	if bytes.Equal(key, []byte("")) {
+		return 0, fmt.Errorf("storage: %w", secret.ErrEmptyKey)
+	}
+	if encodedMetadata == nil {
+		encodedMetadata = []byte{}
+	}
+	return r.saveIfRevision(ctx, hex.EncodeToString(key), encodedValue, encodedMetadata, expiresAt, revision)
+}
+
+// saveIfRevision saves value as the new version, which expires at the time, if it has the revision.
+// Metadata of the value is replaced if encoded metadata isn't nil.
+// Returns the new revision of the value.
+func (r *redisVault) saveIfRevision(ctx context.Context, hexKey string, encodedValue, encodedMetadata []byte, expiresAt time.Time, revision int) (int, error) {
+	var expireAt int64
+	if !expiresAt.IsZero() {
+		// zero means no expiration for the script, so already expired value gets the earliest time
+		if expireAt = expiresAt.UnixNano() / int64(time.Millisecond); expireAt <= 0 {
+			expireAt = 1
+		}
+	}
+	args := []interface{}{encodedValue, time.Now().UnixNano(), r.options.maxVersions, expireAt, revision}
+	if encodedMetadata != nil {
+		args = append(args, encodedMetadata)
+	}
+	saved, err := saveVersionScript.Run(ctx, r.client,
+		[]string{hexKey, redisVersionsKey(hexKey), redisCounterKey(hexKey), redisMetadataKey(hexKey)}, args...).Int()
+	if err != nil {
+		return 0, fmt.Errorf("storage: redis client can't set data %w", err)
+	}
+	if saved < 0 {
+		return 0, fmt.Errorf("storage: cannot save data: %w", secret.ErrRevisionMismatch)
+	}
+	return saved, nil
+}
+
+// ReadDataBatchCtx returns values by keys with single MGET command.
+// Value is nil if there is no such key.
+func (r *redisVault) ReadDataBatchCtx(ctx context.Context, keys [][]byte) ([][]byte, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	hexKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if bytes.Equal(key, []byte("")) {
+			return nil, fmt.Errorf("storage: %w", secret.ErrEmptyKey)
+		}
+		hexKeys = append(hexKeys, hex.EncodeToString(key))
+	}
+	vals, err := r.client.MGet(ctx, hexKeys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("storage: redis client can't get data %w", err)
+	}
+	values := make([][]byte, len(keys))
+	for i, val := range vals {
+		if s, ok := val.(string); ok {
+			values[i] = []byte(s)
+		}
+	}
+	return values, nil
+}
+
+// SaveDataBatchCtx saves values by keys as new versions in single transaction.
+// Saved values never expire.
+func (r *redisVault) SaveDataBatchCtx(ctx context.Context, entries []secret.Entry) error {
+	for _, e := range entries {
+		if bytes.Equal(e.Key, []byte("")) {
+			return fmt.Errorf("storage: %w", secret.ErrEmptyKey)
+		}
+	}
+	now := time.Now().UnixNano()
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, e := range entries {
+			hexKey := hex.EncodeToString(e.Key)
+			// script isn't loaded by EVALSHA inside transaction, so it's sent as a whole
+			saveVersionScript.Eval(ctx, pipe,
+				[]string{hexKey, redisVersionsKey(hexKey), redisCounterKey(hexKey), redisMetadataKey(hexKey)},
+				e.Value, now, r.options.maxVersions, 0, secret.AnyRevision)
+		}
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("storage: redis client can't set data %w", err)
 	}
 	return nil
 }
 
-// ReadData get data from redis storage by key
+// ReadDataCtx get data from redis storage by key
 // 	key to get value for pair key-value from redis storage (can't be nil)
-func (r *redisVault) ReadData(key []byte) ([]byte, error) {
-	ctx := context.Background()
+func (r *redisVault) ReadDataCtx(ctx context.Context, key []byte) ([]byte, error) {
 	if bytes.Equal(key, []byte("")) {
-		return nil, errors.New("storage: key can't be nil")
+		return nil, fmt.Errorf("storage: %w", secret.ErrEmptyKey)
 	}
 	val, err := r.client.Get(ctx, hex.EncodeToString(key)).Result()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			return nil, nil
+			return nil, fmt.Errorf("storage: %w", secret.ErrNotFound)
 		}
 		return nil, fmt.Errorf("storage: redis client can't get data %w", err)
 	}
 	return []byte(val), nil
 }
+
+// DeleteDataCtx remove data from redis storage by key
+// 	key to delete pair key-value from redis storage (can't be nil)
+func (r *redisVault) DeleteDataCtx(ctx context.Context, key []byte) error {
+	if bytes.Equal(key, []byte("")) {
+		return fmt.Errorf("storage: %w", secret.ErrEmptyKey)
+	}
+	hexKey := hex.EncodeToString(key)
+	var n *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		n = pipe.Del(ctx, hexKey)
+		pipe.Del(ctx, redisVersionsKey(hexKey), redisCounterKey(hexKey), redisMetadataKey(hexKey))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("storage: redis client can't delete data %w", err)
+	}
+	if n.Val() == 0 {
+		return fmt.Errorf("storage: %w", secret.ErrNotFound)
+	}
+	return nil
+}
+
+// ListKeysCtx get all keys from redis storage
+// 	keys which are not saved by redis vault are skipped, including versions of values
+func (r *redisVault) ListKeysCtx(ctx context.Context) ([][]byte, error) {
+	var keys [][]byte
+	iter := r.client.Scan(ctx, 0, "*", 0).Iterator()
+	for iter.Next(ctx) {
+		key, err := hex.DecodeString(iter.Val())
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("storage: redis client can't scan keys %w", err)
+	}
+	return keys, nil
+}
+
+// SaveData is SaveDataCtx with background context
+func (r *redisVault) SaveData(key, encodedValue []byte) error {
+	return r.SaveDataCtx(context.Background(), key, encodedValue)
+}
+
+// ReadData is ReadDataCtx with background context
+func (r *redisVault) ReadData(key []byte) ([]byte, error) {
+	return r.ReadDataCtx(context.Background(), key)
+}
+
+// DeleteData is DeleteDataCtx with background context
+func (r *redisVault) DeleteData(key []byte) error {
+	return r.DeleteDataCtx(context.Background(), key)
+}
+
+// ListKeys is ListKeysCtx with background context
+func (r *redisVault) ListKeys() ([][]byte, error) {
+	return r.ListKeysCtx(context.Background())
+}
+
+// saveVersionScript sets the value and appends it to the list of versions atomically.
+// Version entry is "number:unix nano:value". Value saved before redis vault started
+// to keep versions becomes the version 1 with zero time.
+// Creation time is saved to metadata for the new value, update time for every value.
+// All keys of the value get the same expiration time or become persistent.
+// Value is saved only if its revision, the last version number, is the expected one,
+// the script returns -1 otherwise. Any revision is expected if it's negative.
+// Keys: value key, versions list key, counter key, metadata hash key.
+// Args: value, unix nano time, max versions, expiration unix time in milliseconds or 0, expected revision,
+// optional metadata, which replaces metadata of the value.
+// Returns the new revision.
+var saveVersionScript = redis.NewScript(`
+local expected = tonumber(ARGV[5])
+if expected >= 0 then
+	local revision = 0
+	if redis.call('EXISTS', KEYS[1]) == 1 then
+		revision = tonumber(redis.call('GET', KEYS[3]) or 1)
+	end
+	if revision ~= expected then
+		return -1
+	end
+end
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	redis.call('DEL', KEYS[4])
+	redis.call('HSET', KEYS[4], 'created_at', ARGV[2])
+end
+redis.call('HSET', KEYS[4], 'updated_at', ARGV[2])
+if ARGV[6] then
+	redis.call('HSET', KEYS[4], 'data', ARGV[6])
+end
+if redis.call('EXISTS', KEYS[3]) == 0 then
+	local legacy = redis.call('GET', KEYS[1])
+	if legacy then
+		redis.call('SET', KEYS[3], 1)
+		redis.call('RPUSH', KEYS[2], '1:0:' .. legacy)
+	end
+end
+local n = redis.call('INCR', KEYS[3])
+redis.call('SET', KEYS[1], ARGV[1])
+redis.call('RPUSH', KEYS[2], n .. ':' .. ARGV[2] .. ':' .. ARGV[1])
+local max = tonumber(ARGV[3])
+if max > 0 then
+	redis.call('LTRIM', KEYS[2], -max, -1)
+end
+local expireAt = tonumber(ARGV[4])
+for i = 1, 4 do
+	if expireAt > 0 then
+		redis.call('PEXPIREAT', KEYS[i], expireAt)
+	else
+		redis.call('PERSIST', KEYS[i])
+	end
+end
+return n
+`)
+
+// VersionsCtx returns versions of the key from the oldest to the latest.
+func (r *redisVault) VersionsCtx(ctx context.Context, key []byte) ([]secret.Version, error) {
+	entries, err := r.versions(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]secret.Version, 0, len(entries))
+	for _, e := range entries {
+		versions = append(versions, e.Version)
+	}
+	return versions, nil
+}
+
+// ReadVersionCtx returns encoded value of the version.
+func (r *redisVault) ReadVersionCtx(ctx context.Context, key []byte, version int) ([]byte, error) {
+	entries, err := r.versions(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.Number == version {
+			return e.value, nil
+		}
+	}
+	return nil, fmt.Errorf("storage: cannot read version %d: %w", version, secret.ErrNotFound)
+}
+
+// saveHistoryScript replaces the value and its versions atomically.
+// Metadata is kept like by saveVersionScript, all keys of the value get the same expiration time.
+// Keys: value key, versions list key, counter key, metadata hash key.
+// Args: unix nano time, expiration unix time in milliseconds or 0, max versions, value, revision,
+// version entries from the oldest to the latest.
+var saveHistoryScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	redis.call('DEL', KEYS[4])
+	redis.call('HSET', KEYS[4], 'created_at', ARGV[1])
+end
+redis.call('HSET', KEYS[4], 'updated_at', ARGV[1])
+redis.call('DEL', KEYS[2])
+for i = 6, #ARGV do
+	redis.call('RPUSH', KEYS[2], ARGV[i])
+end
+local max = tonumber(ARGV[3])
+if max > 0 then
+	redis.call('LTRIM', KEYS[2], -max, -1)
+end
+redis.call('SET', KEYS[3], ARGV[5])
+redis.call('SET', KEYS[1], ARGV[4])
+local expireAt = tonumber(ARGV[2])
+for i = 1, 4 do
+	if expireAt > 0 then
+		redis.call('PEXPIREAT', KEYS[i], expireAt)
+	else
+		redis.call('PERSIST', KEYS[i])
+	end
+end
+return 1
+`)
+
+// SaveHistoryCtx replaces the value by key and its versions with the history, which expires at the time.
+// Versions over the limit are removed.
+func (r *redisVault) SaveHistoryCtx(ctx context.Context, key []byte, history []secret.VersionedValue, expiresAt time.Time) error {
+	if bytes.Equal(key, []byte("")) {
+		return fmt.Errorf("storage: %w", secret.ErrEmptyKey)
+	}
+	if len(history) == 0 {
+		return errors.New("storage: history is empty")
+	}
+	var expireAt int64
+	if !expiresAt.IsZero() {
+		if expireAt = expiresAt.UnixNano() / int64(time.Millisecond); expireAt <= 0 {
+			expireAt = 1
+		}
+	}
+	latest := history[len(history)-1]
+	args := []interface{}{time.Now().UnixNano(), expireAt, r.options.maxVersions, latest.Value, latest.Number}
+	for _, v := range history {
+		var unix int64 // zero time of version saved before redis vault started to keep versions
+		if !v.CreatedAt.IsZero() {
+			unix = v.CreatedAt.UnixNano()
+		}
+		args = append(args, strconv.Itoa(v.Number)+":"+strconv.FormatInt(unix, 10)+":"+string(v.Value))
+	}
+	hexKey := hex.EncodeToString(key)
+	err := saveHistoryScript.Run(ctx, r.client,
+		[]string{hexKey, redisVersionsKey(hexKey), redisCounterKey(hexKey), redisMetadataKey(hexKey)}, args...).Err()
+	if err != nil {
+		return fmt.Errorf("storage: redis client can't set history %w", err)
+	}
+	return nil
+}
+
+type redisVersion struct {
+	secret.Version
+	value []byte
+}
+
+// versions returns versions of the key with values.
+// Value saved before redis vault started to keep versions is the version 1.
+func (r *redisVault) versions(ctx context.Context, key []byte) ([]redisVersion, error) {
+	if bytes.Equal(key, []byte("")) {
+		return nil, fmt.Errorf("storage: %w", secret.ErrEmptyKey)
+	}
+	hexKey := hex.EncodeToString(key)
+	entries, err := r.client.LRange(ctx, redisVersionsKey(hexKey), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("storage: redis client can't get versions %w", err)
+	}
+	if len(entries) == 0 {
+		val, err := r.ReadDataCtx(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return []redisVersion{{Version: secret.Version{Number: 1}, value: val}}, nil
+	}
+	versions := make([]redisVersion, 0, len(entries))
+	for _, entry := range entries {
+		v, err := parseRedisVersion(entry)
+		if err != nil {
+			return nil, fmt.Errorf("storage: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+func parseRedisVersion(entry string) (redisVersion, error) {
+	parts := strings.SplitN(entry, ":", 3)
+	if len(parts) != 3 {
+		return redisVersion{}, errors.New("invalid version entry")
+	}
+	number, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return redisVersion{}, fmt.Errorf("invalid version number: %w", err)
+	}
+	unix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return redisVersion{}, fmt.Errorf("invalid version time: %w", err)
+	}
+	v := redisVersion{Version: secret.Version{Number: number}, value: []byte(parts[2])}
+	if unix != 0 {
+		v.CreatedAt = time.Unix(0, unix).UTC()
+	}
+	return v, nil
+}
+
+// redisVersionsKey is the key of the list with versions of the value.
+// It isn't hex encoded, so it's skipped when keys are listed.
+func redisVersionsKey(hexKey string) string {
+	return "versions:" + hexKey
+}
+
+// redisMetadataKey is the key of the hash with metadata of the value.
+// It isn't hex encoded, so it's skipped when keys are listed.
+func redisMetadataKey(hexKey string) string {
+	return "metadata:" + hexKey
+}
+
+// redisCounterKey is the key of the last version number of the value.
+func redisCounterKey(hexKey string) string {
+	return "version:" + hexKey
+}
+
+// saveMetadataScript saves metadata of the existing value and its update time.
+// Metadata hash expires with the value.
+// Keys: value key, metadata hash key. Args: metadata, unix nano time.
+// Returns 0 if there is no value.
+var saveMetadataScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[2], 'data', ARGV[1], 'updated_at', ARGV[2])
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[2], ttl)
+end
+return 1
+`)
+
+// SaveMetadataCtx saves encoded metadata of the value by key.
+func (r *redisVault) SaveMetadataCtx(ctx context.Context, key, encodedMetadata []byte) error {
+	if bytes.Equal(key, []byte("")) {
+		return fmt.Errorf("storage: %w", secret.ErrEmptyKey)
+	}
+	hexKey := hex.EncodeToString(key)
+	n, err := saveMetadataScript.Run(ctx, r.client, []string{hexKey, redisMetadataKey(hexKey)},
+		encodedMetadata, time.Now().UnixNano()).Int()
+	if err != nil {
+		return fmt.Errorf("storage: redis client can't set metadata %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("storage: %w", secret.ErrNotFound)
+	}
+	return nil
+}
+
+// ReadMetadataCtx returns metadata of the value by key.
+func (r *redisVault) ReadMetadataCtx(ctx context.Context, key []byte) (secret.MetadataRecord, error) {
+	if bytes.Equal(key, []byte("")) {
+		return secret.MetadataRecord{}, fmt.Errorf("storage: %w", secret.ErrEmptyKey)
+	}
+	hexKey := hex.EncodeToString(key)
+	var exists *redis.IntCmd
+	var fields *redis.StringStringMapCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		exists = pipe.Exists(ctx, hexKey)
+		fields = pipe.HGetAll(ctx, redisMetadataKey(hexKey))
+		return nil
+	})
+	if err != nil {
+		return secret.MetadataRecord{}, fmt.Errorf("storage: redis client can't get metadata %w", err)
+	}
+	if exists.Val() == 0 {
+		return secret.MetadataRecord{}, fmt.Errorf("storage: %w", secret.ErrNotFound)
+	}
+	md := fields.Val()
+	record := secret.MetadataRecord{}
+	if data, ok := md["data"]; ok {
+		record.Data = []byte(data)
+	}
+	if record.CreatedAt, err = parseRedisTime(md["created_at"]); err != nil {
+		return secret.MetadataRecord{}, fmt.Errorf("storage: invalid creation time: %w", err)
+	}
+	if record.UpdatedAt, err = parseRedisTime(md["updated_at"]); err != nil {
+		return secret.MetadataRecord{}, fmt.Errorf("storage: invalid update time: %w", err)
+	}
+	return record, nil
+}
+
+// parseRedisTime parses unix nano time, empty string is zero time.
+func parseRedisTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	unix, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, unix).UTC(), nil
+}
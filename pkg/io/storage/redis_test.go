@@ -3,11 +3,15 @@ package storage
 import (
 	"context"
 	"encoding/hex"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 
+	"github.com/go-itools-internship/go-secret/pkg/io/storage/storagetest"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -33,7 +37,7 @@ func TestRedisVault_SaveData(t *testing.T) {
 		require.Error(t, err)
 		require.EqualValues(t, "storage: key can't be nil", err.Error())
 	})
-	t.Run("get nil value if key has been deleted", func(t *testing.T) {
+	t.Run("error if key has been deleted", func(t *testing.T) {
 		nilEncodedValue := ""
 		s := NewRedisVault(rdb)
 		err := s.SaveData([]byte(key), []byte(key))
@@ -42,8 +46,9 @@ func TestRedisVault_SaveData(t *testing.T) {
 		require.NoError(t, err)
 
 		val, err := s.ReadData([]byte(key))
-		require.NoError(t, err)
-		require.EqualValues(t, []byte(nil), val)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		require.Empty(t, val)
 	})
 }
 
@@ -60,12 +65,62 @@ func TestRedisVault_ReadData(t *testing.T) {
 		require.NoError(t, err)
 		require.EqualValues(t, "value", val)
 	})
-	t.Run("get nil value if wrong key", func(t *testing.T) {
+	t.Run("error if wrong key", func(t *testing.T) {
 		wrongKey := "wrongKey"
 		s := NewRedisVault(rdb)
 		val, err := s.ReadData([]byte(wrongKey))
+		require.Error(t, err)
+		require.EqualValues(t, "storage: key not found", err.Error())
+		require.Empty(t, val)
+	})
+}
+
+func TestRedisVault_DeleteData(t *testing.T) {
+	key := "key"
+	encodedValue := "value"
+	rdb := redis.NewClient(&redis.Options{Addr: "localhost:6379", Password: "", DB: 0})
+	defer disconnectRDB(rdb, t)
+	t.Run("success", func(t *testing.T) {
+		s := NewRedisVault(rdb)
+		err := s.SaveData([]byte(key), []byte(encodedValue))
+		require.NoError(t, err)
+		err = s.DeleteData([]byte(key))
+		require.NoError(t, err)
+		val, err := s.ReadData([]byte(key))
+		require.Error(t, err)
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		require.Empty(t, val)
+	})
+	t.Run("error if key not found", func(t *testing.T) {
+		s := NewRedisVault(rdb)
+		err := s.DeleteData([]byte("wrongKey"))
+		require.Error(t, err)
+		require.EqualValues(t, "storage: key not found", err.Error())
+	})
+	t.Run("error if key equals nil", func(t *testing.T) {
+		s := NewRedisVault(rdb)
+		err := s.DeleteData([]byte(""))
+		require.Error(t, err)
+		require.EqualValues(t, "storage: key can't be nil", err.Error())
+	})
+}
+
+func TestRedisVault_ListKeys(t *testing.T) {
+	rdb := redis.NewClient(&redis.Options{Addr: "localhost:6379", Password: "", DB: 0})
+	defer disconnectRDB(rdb, t)
+	t.Run("success", func(t *testing.T) {
+		s := NewRedisVault(rdb)
+		require.NoError(t, s.SaveData([]byte("list-key-1"), []byte("value")))
+		require.NoError(t, s.SaveData([]byte("list-key-2"), []byte("value")))
+		defer func() {
+			require.NoError(t, s.DeleteData([]byte("list-key-1")))
+			require.NoError(t, s.DeleteData([]byte("list-key-2")))
+		}()
+
+		keys, err := s.ListKeys()
 		require.NoError(t, err)
-		require.EqualValues(t, []byte(nil), val)
+		require.Contains(t, keys, []byte("list-key-1"))
+		require.Contains(t, keys, []byte("list-key-2"))
 	})
 }
 
@@ -75,3 +130,223 @@ func disconnectRDB(rdb *redis.Client, t *testing.T) {
 		t.Log("can't disconnect redis db")
 	}
 }
+
+func TestRedisVault_Versions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rdb := redis.NewClient(&redis.Options{Addr: "localhost:6379", Password: "", DB: 0})
+	defer disconnectRDB(rdb, t)
+	t.Run("success", func(t *testing.T) {
+		key := []byte("versions-key")
+		s := NewRedisVault(rdb, MaxVersions(2))
+		for _, v := range []string{"value1", "value2", "value3"} {
+			require.NoError(t, s.SaveData(key, []byte(v)))
+		}
+		defer func() {
+			require.NoError(t, s.DeleteData(key))
+		}()
+
+		versions, err := s.VersionsCtx(ctx, key)
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		require.EqualValues(t, 2, versions[0].Number)
+		require.EqualValues(t, 3, versions[1].Number)
+
+		data, err := s.ReadVersionCtx(ctx, key, 2)
+		require.NoError(t, err)
+		require.EqualValues(t, "value2", string(data))
+		_, err = s.ReadVersionCtx(ctx, key, 1)
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+
+		keys, err := s.ListKeys()
+		require.NoError(t, err)
+		require.Contains(t, keys, key)
+	})
+	t.Run("save history", func(t *testing.T) {
+		key := []byte("history-key")
+		s := NewRedisVault(rdb, MaxVersions(2))
+		created := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+		history := []secret.VersionedValue{
+			{Version: secret.Version{Number: 1}, Value: []byte("value1")},
+			{Version: secret.Version{Number: 4, CreatedAt: created}, Value: []byte("value4")},
+			{Version: secret.Version{Number: 7, CreatedAt: created.Add(time.Hour)}, Value: []byte("value7")},
+		}
+		require.NoError(t, s.SaveHistoryCtx(ctx, key, history, time.Time{}))
+		defer func() {
+			require.NoError(t, s.DeleteData(key))
+		}()
+
+		versions, err := s.VersionsCtx(ctx, key)
+		require.NoError(t, err)
+		require.EqualValues(t, []secret.Version{history[1].Version, history[2].Version}, versions)
+		data, err := s.ReadVersionCtx(ctx, key, 4)
+		require.NoError(t, err)
+		require.EqualValues(t, "value4", string(data))
+		data, revision, err := s.ReadDataWithRevisionCtx(ctx, key)
+		require.NoError(t, err)
+		require.EqualValues(t, "value7", string(data))
+		require.EqualValues(t, 7, revision)
+	})
+}
+
+func TestRedisVault_Expiry(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rdb := redis.NewClient(&redis.Options{Addr: "localhost:6379", Password: "", DB: 0})
+	defer disconnectRDB(rdb, t)
+	t.Run("success", func(t *testing.T) {
+		key := []byte("expiry-key")
+		s := NewRedisVault(rdb)
+		require.NoError(t, s.SaveDataWithExpiryCtx(ctx, key, []byte("value"), time.Now().Add(time.Hour)))
+		defer func() {
+			require.NoError(t, s.DeleteData(key))
+		}()
+		expiry, err := s.ExpiryCtx(ctx, key)
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(time.Hour), expiry, time.Minute)
+		ttl, err := s.client.PTTL(ctx, redisVersionsKey(hex.EncodeToString(key))).Result()
+		require.NoError(t, err)
+		require.True(t, ttl > 0)
+
+		require.NoError(t, s.SaveData(key, []byte("value")))
+		expiry, err = s.ExpiryCtx(ctx, key)
+		require.NoError(t, err)
+		require.True(t, expiry.IsZero())
+	})
+	t.Run("expired value isn't returned", func(t *testing.T) {
+		key := []byte("expired-key")
+		s := NewRedisVault(rdb)
+		require.NoError(t, s.SaveDataWithExpiryCtx(ctx, key, []byte("value"), time.Now().Add(-time.Second)))
+		_, err := s.ReadData(key)
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+	})
+}
+
+func TestRedisVault_Metadata(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rdb := redis.NewClient(&redis.Options{Addr: "localhost:6379", Password: "", DB: 0})
+	defer disconnectRDB(rdb, t)
+	t.Run("success", func(t *testing.T) {
+		key := []byte("metadata-key")
+		s := NewRedisVault(rdb)
+		require.NoError(t, s.SaveDataWithExpiryCtx(ctx, key, []byte("value"), time.Now().Add(time.Hour)))
+		defer func() {
+			require.NoError(t, s.DeleteData(key))
+		}()
+		require.NoError(t, s.SaveMetadataCtx(ctx, key, []byte(`{"owner":"team-a"}`)))
+		require.NoError(t, s.SaveDataWithExpiryCtx(ctx, key, []byte("new value"), time.Now().Add(time.Hour)))
+
+		record, err := s.ReadMetadataCtx(ctx, key)
+		require.NoError(t, err)
+		require.EqualValues(t, `{"owner":"team-a"}`, string(record.Data))
+		require.False(t, record.CreatedAt.IsZero())
+		require.True(t, record.UpdatedAt.After(record.CreatedAt))
+		ttl, err := s.client.PTTL(ctx, redisMetadataKey(hex.EncodeToString(key))).Result()
+		require.NoError(t, err)
+		require.True(t, ttl > 0)
+	})
+	t.Run("save data with metadata", func(t *testing.T) {
+		key := []byte("metadata-with-value")
+		s := NewRedisVault(rdb)
+		revision, err := s.SaveDataWithMetadataCtx(ctx, key, []byte("value"), []byte(`{"owner":"team-a"}`), time.Time{}, 0)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, s.DeleteData(key))
+		}()
+		require.EqualValues(t, 1, revision)
+
+		// neither value nor metadata is saved if revision doesn't match
+		_, err = s.SaveDataWithMetadataCtx(ctx, key, []byte("new value"), []byte(`{"owner":"team-b"}`), time.Time{}, 0)
+		require.True(t, errors.Is(err, secret.ErrRevisionMismatch))
+		value, err := s.ReadData(key)
+		require.NoError(t, err)
+		require.EqualValues(t, "value", value)
+		record, err := s.ReadMetadataCtx(ctx, key)
+		require.NoError(t, err)
+		require.EqualValues(t, `{"owner":"team-a"}`, string(record.Data))
+	})
+	t.Run("not found", func(t *testing.T) {
+		s := NewRedisVault(rdb)
+		err := s.SaveMetadataCtx(ctx, []byte("missing-key"), []byte(`{"owner":"team-a"}`))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		_, err = s.ReadMetadataCtx(ctx, []byte("missing-key"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+	})
+}
+
+func TestRedisVault_Revision(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rdb := redis.NewClient(&redis.Options{Addr: "localhost:6379", Password: "", DB: 0})
+	defer disconnectRDB(rdb, t)
+	t.Run("success", func(t *testing.T) {
+		key := []byte("revision-key")
+		s := NewRedisVault(rdb)
+		revision, err := s.SaveDataIfRevisionCtx(ctx, key, []byte("value 1"), time.Time{}, 0)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, s.DeleteData(key))
+		}()
+		require.EqualValues(t, 1, revision)
+		revision, err = s.SaveDataIfRevisionCtx(ctx, key, []byte("value 2"), time.Time{}, revision)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, revision)
+
+		_, err = s.SaveDataIfRevisionCtx(ctx, key, []byte("stale"), time.Time{}, 1)
+		require.True(t, errors.Is(err, secret.ErrRevisionMismatch))
+		_, err = s.SaveDataIfRevisionCtx(ctx, key, []byte("stale"), time.Time{}, 0)
+		require.True(t, errors.Is(err, secret.ErrRevisionMismatch))
+
+		value, revision, err := s.ReadDataWithRevisionCtx(ctx, key)
+		require.NoError(t, err)
+		require.EqualValues(t, "value 2", string(value))
+		require.EqualValues(t, 2, revision)
+	})
+	t.Run("not found", func(t *testing.T) {
+		s := NewRedisVault(rdb)
+		_, _, err := s.ReadDataWithRevisionCtx(ctx, []byte("missing-key"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		_, err = s.SaveDataIfRevisionCtx(ctx, []byte("missing-key"), []byte("value"), time.Time{}, 1)
+		require.True(t, errors.Is(err, secret.ErrRevisionMismatch))
+	})
+}
+
+func TestRedisVault_Batch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rdb := redis.NewClient(&redis.Options{Addr: "localhost:6379", Password: "", DB: 0})
+	defer disconnectRDB(rdb, t)
+	t.Run("success", func(t *testing.T) {
+		s := NewRedisVault(rdb)
+		err := s.SaveDataBatchCtx(ctx, []secret.Entry{
+			{Key: []byte("batch-key-1"), Value: []byte("value 1")},
+			{Key: []byte("batch-key-2"), Value: []byte("value 2")},
+		})
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, s.DeleteData([]byte("batch-key-1")))
+			require.NoError(t, s.DeleteData([]byte("batch-key-2")))
+		}()
+
+		values, err := s.ReadDataBatchCtx(ctx, [][]byte{[]byte("batch-key-2"), []byte("missing-key"), []byte("batch-key-1")})
+		require.NoError(t, err)
+		require.EqualValues(t, [][]byte{[]byte("value 2"), nil, []byte("value 1")}, values)
+		versions, err := s.VersionsCtx(ctx, []byte("batch-key-1"))
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+	})
+}
+
+func TestRedisVault_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) secret.DataSaver {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		rdb := redis.NewClient(&redis.Options{Addr: "localhost:6379", Password: "", DB: 0})
+		require.NoError(t, rdb.FlushDB(ctx).Err())
+		t.Cleanup(func() {
+			disconnectRDB(rdb, t)
+		})
+		return NewRedisVault(rdb)
+	})
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// ReadDataWithRevisionCtx returns value by key with its revision.
+// Revision is the number of the latest version.
+func (f *fileVault) ReadDataWithRevisionCtx(ctx context.Context, key []byte) ([]byte, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, fmt.Errorf("filevault: %w", err)
+	}
+	if len(key) == 0 {
+		return nil, 0, fmt.Errorf("filevault: %w", secret.ErrEmptyKey)
+	}
+	if err := f.read(); err != nil {
+		return nil, 0, fmt.Errorf("filevault: unable to read file while reading: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	hexKey := hex.EncodeToString(key)
+	data, ok := f.value(hexKey)
+	if !ok {
+		return nil, 0, fmt.Errorf("filevault: cannot read data: %w", secret.ErrNotFound)
+	}
+	return cloneBytes(data), f.revision(hexKey), nil
+}
+
+// SaveDataIfRevisionCtx saves value, which expires at the time, if it has the revision.
+// Revision is compared under exclusive file lock, so concurrent saves of other processes are detected.
+func (f *fileVault) SaveDataIfRevisionCtx(ctx context.Context, key, encodedValue []byte, expiresAt time.Time, revision int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, fmt.Errorf("filevault: %w", err)
+	}
+	return f.saveIfRevision(key, encodedValue, expiresAt, revision)
+}
+
+// saveIfRevision saves value by key as the new version, which expires at the time, if it has the revision.
+// Returns the new revision of the value.
+func (f *fileVault) saveIfRevision(key, encodedValue []byte, expiresAt time.Time, revision int) (int, error) {
+	if len(key) == 0 {
+		return 0, fmt.Errorf("filevault: %w", secret.ErrEmptyKey)
+	}
+	var saved int
+	err := f.update(func() error {
+		hexKey := hex.EncodeToString(key)
+		if revision != secret.AnyRevision && f.revision(hexKey) != revision {
+			return secret.ErrRevisionMismatch
+		}
+		saved = f.put(hexKey, encodedValue, expiresAt)
+		return nil
+	})
+	if errors.Is(err, secret.ErrRevisionMismatch) {
+		return 0, fmt.Errorf("filevault: cannot save data: %w", err)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("filevault: unable to save data: %w", err)
+	}
+	return saved, nil
+}
+
+// put saves value by key as the new version, which expires at the time, and returns its revision.
+// Versions of expired value are dropped, so history starts again.
+// Mutex should be locked by caller.
+func (f *fileVault) put(hexKey string, encodedValue []byte, expiresAt time.Time) int {
+	if f.expired(hexKey) {
+		f.remove(hexKey)
+	}
+	_, exists := f.storage[hexKey]
+	f.touch(hexKey, !exists)
+	f.addVersion(hexKey, encodedValue)
+	if expiresAt.IsZero() {
+		delete(f.expiry, hexKey)
+	} else {
+		f.expiry[hexKey] = expiresAt.UTC()
+	}
+	return f.revision(hexKey)
+}
+
+// revision returns the number of the latest version or 0 if there is no value by key.
+// Mutex should be locked by caller.
+func (f *fileVault) revision(hexKey string) int {
+	history := f.history(hexKey)
+	if len(history) == 0 {
+		return 0
+	}
+	return history[len(history)-1].Number
+}
@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+)
+
+// sealedFormat is the format of the file vault encrypted as a whole.
+// Sealed file is a JSON document with "format" field, which can't be a key of plain file vault,
+// because keys of plain file vault are hex encoded.
+const sealedFormat = "sealed-v1"
+
+// ErrSealed is returned when sealed file vault is opened without cipher key.
+var ErrSealed = errors.New("file vault is sealed: cipher key is required")
+
+// sealedDocument is the content of sealed file vault.
+// Payload is plain file vault document encrypted with the key derived from passphrase by KDF params.
+type sealedDocument struct {
+	Format  string           `json:"format"`
+	KDF     crypto.KDFParams `json:"kdf"`
+	Payload []byte           `json:"payload"`
+}
+
+type sealer interface {
+	Encode(value []byte) ([]byte, error)
+	Decode(encodedValue []byte) ([]byte, error)
+}
+
+type sealOptions struct {
+	kdfCost    int
+	randReader io.Reader
+	vault      options
+}
+
+var defaultSealOptions = sealOptions{
+	kdfCost:    crypto.DefaultScryptCost,
+	randReader: rand.Reader,
+	vault:      defaultOptions,
+}
+
+type SealOption func(o *sealOptions)
+
+// SealKDFCost sets log2 of scrypt N parameter for new sealed vaults.
+// It's ignored for existing sealed vaults, because their params are read from the file.
+func SealKDFCost(cost int) SealOption {
+	return func(o *sealOptions) {
+		o.kdfCost = cost
+	}
+}
+
+// SealRandReader sets reader for salts and nonces. Default: crypto/rand.Reader.
+func SealRandReader(r io.Reader) SealOption {
+	return func(o *sealOptions) {
+		o.randReader = r
+	}
+}
+
+// SealMaxVersions is MaxVersions for sealed file vault.
+func SealMaxVersions(n int) SealOption {
+	return func(o *sealOptions) {
+		MaxVersions(n)(&o.vault)
+	}
+}
+
+// NewSealedFileVault creates storage in the file by path, which is encrypted as a whole with passphrase.
+// Sealed file hides the number of secrets, their sizes and change patterns.
+// File is created if it doesn't exist. Plain file vault with data should be converted by SealFileVault first.
+func NewSealedFileVault(path string, passphrase []byte, opts ...SealOption) (*fileVault, error) {
+	options := defaultSealOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	f := newFileVault(path, options.vault)
+	f.passphrase = passphrase
+	f.sealOpts = options
+	if err := f.update(func() error { return nil }); err != nil {
+		return nil, fmt.Errorf("filevault: %w", err)
+	}
+	return f, nil
+}
+
+// IsSealedFile reports whether the file by path is sealed file vault.
+func IsSealedFile(path string) (bool, error) {
+	data, unlock, err := lockFile(filepath.Clean(path), false)
+	if err != nil {
+		return false, fmt.Errorf("filevault: %w", err)
+	}
+	defer unlock()
+	_, sealed, err := parseSealed(data)
+	if err != nil {
+		return false, fmt.Errorf("filevault: %w", err)
+	}
+	return sealed, nil
+}
+
+// SealFileVault encrypts plain file vault by path as a whole with passphrase.
+func SealFileVault(path string, passphrase []byte, opts ...SealOption) error {
+	options := defaultSealOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	f := newFileVault(path, options.vault)
+	err := f.update(func() error {
+		f.passphrase = passphrase
+		f.sealOpts = options
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("filevault: unable to seal file: %w", err)
+	}
+	return nil
+}
+
+// UnsealFileVault decrypts sealed file vault by path, so it becomes plain file vault.
+func UnsealFileVault(path string, passphrase []byte) error {
+	f := newFileVault(path, defaultOptions)
+	f.passphrase = passphrase
+	err := f.update(func() error {
+		f.passphrase = nil
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("filevault: unable to unseal file: %w", err)
+	}
+	return nil
+}
+
+// Sealed reports whether the file vault is sealed.
+func (f *fileVault) Sealed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.passphrase != nil
+}
+
+// ResealCtx encrypts sealed file vault with the new passphrase.
+// KDF params of the file are kept, the salt is new.
+func (f *fileVault) ResealCtx(ctx context.Context, passphrase []byte) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("filevault: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.memory || f.passphrase == nil {
+		return errors.New("filevault: file vault isn't sealed")
+	}
+	data, unlock, err := lockFile(f.path, true)
+	if err != nil {
+		return fmt.Errorf("filevault: %w", err)
+	}
+	defer unlock()
+	if err := f.load(data); err != nil {
+		return fmt.Errorf("filevault: %w", err)
+	}
+	params := f.kdf
+	params.Salt = make([]byte, len(f.kdf.Salt))
+	if _, err := io.ReadFull(f.sealOpts.randReader, params.Salt); err != nil {
+		return fmt.Errorf("filevault: can't read salt: %w", err)
+	}
+	cr, err := crypto.NewCryptographerKDF(passphrase, params, f.sealOpts.randReader)
+	if err != nil {
+		return fmt.Errorf("filevault: %w", err)
+	}
+	oldPassphrase, oldKDF, oldSealer := f.passphrase, f.kdf, f.sealer
+	f.passphrase, f.kdf, f.sealer = passphrase, params, cr
+	if err := f.write(); err != nil {
+		// file is still sealed with the old passphrase
+		f.passphrase, f.kdf, f.sealer = oldPassphrase, oldKDF, oldSealer
+		return fmt.Errorf("filevault: unable to reseal file: %w", err)
+	}
+	return nil
+}
+
+// parseSealed returns sealed document if data is sealed file vault.
+func parseSealed(data []byte) (sealedDocument, bool, error) {
+	var doc sealedDocument
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&fields); err != nil {
+		if errors.Is(err, io.EOF) {
+			return doc, false, nil
+		}
+		return doc, false, fmt.Errorf("unable to decode data: %w", err)
+	}
+	if _, ok := fields["format"]; !ok {
+		return doc, false, nil
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return doc, false, fmt.Errorf("unable to decode sealed data: %w", err)
+	}
+	if doc.Format != sealedFormat {
+		return doc, false, fmt.Errorf("unsupported file vault format %q", doc.Format)
+	}
+	return doc, true, nil
+}
+
+// unseal decrypts payload of sealed document.
+// Cipher key is derived again only if KDF params of the file were changed.
+func (f *fileVault) unseal(doc sealedDocument) ([]byte, error) {
+	if f.sealer == nil || !sameKDF(f.kdf, doc.KDF) {
+		cr, err := crypto.NewCryptographerKDF(f.passphrase, doc.KDF, f.sealOpts.randReader)
+		if err != nil {
+			return nil, err
+		}
+		f.sealer, f.kdf = cr, doc.KDF
+	}
+	data, err := f.sealer.Decode(doc.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt file: %w", err)
+	}
+	return data, nil
+}
+
+// seal encrypts plain file vault document.
+// Cipher key with new random salt is derived for new sealed vault.
+func (f *fileVault) seal(data []byte) ([]byte, error) {
+	if f.sealer == nil {
+		params, err := crypto.NewScryptParams(f.sealOpts.randReader, f.sealOpts.kdfCost)
+		if err != nil {
+			return nil, err
+		}
+		cr, err := crypto.NewCryptographerKDF(f.passphrase, params, f.sealOpts.randReader)
+		if err != nil {
+			return nil, err
+		}
+		f.sealer, f.kdf = cr, params
+	}
+	payload, err := f.sealer.Encode(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encrypt file: %w", err)
+	}
+	return json.Marshal(sealedDocument{Format: sealedFormat, KDF: f.kdf, Payload: payload})
+}
+
+func sameKDF(a, b crypto.KDFParams) bool {
+	return a.Name == b.Name && bytes.Equal(a.Salt, b.Salt) && a.N == b.N && a.R == b.R && a.P == b.P
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/io/storage/storagetest"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func TestSealedFileVault(t *testing.T) {
+	defer func() {
+		require.NoError(t, os.Remove(testFilename))
+	}()
+
+	vault, err := NewSealedFileVault(testFilename, []byte("passphrase"), SealKDFCost(4))
+	require.NoError(t, err)
+	require.NoError(t, vault.SaveData([]byte("key name"), []byte("secret value")))
+
+	t.Run("whole file is encrypted", func(t *testing.T) {
+		data, err := ioutil.ReadFile(testFilename)
+		require.NoError(t, err)
+		var doc sealedDocument
+		require.NoError(t, json.Unmarshal(data, &doc))
+		require.EqualValues(t, sealedFormat, doc.Format)
+		require.EqualValues(t, 16, doc.KDF.N)
+		require.False(t, strings.Contains(string(data), "6b6579206e616d65"))
+
+		sealed, err := IsSealedFile(testFilename)
+		require.NoError(t, err)
+		require.True(t, sealed)
+	})
+	t.Run("read with the same passphrase", func(t *testing.T) {
+		other, err := NewSealedFileVault(testFilename, []byte("passphrase"))
+		require.NoError(t, err)
+		got, err := other.ReadData([]byte("key name"))
+		require.NoError(t, err)
+		require.EqualValues(t, "secret value", string(got))
+	})
+	t.Run("error with wrong passphrase", func(t *testing.T) {
+		_, err := NewSealedFileVault(testFilename, []byte("wrong"))
+		require.Error(t, err)
+	})
+	t.Run("error without passphrase", func(t *testing.T) {
+		_, err := NewFileVault(testFilename)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrSealed))
+	})
+	t.Run("unseal and seal again", func(t *testing.T) {
+		require.NoError(t, UnsealFileVault(testFilename, []byte("passphrase")))
+		sealed, err := IsSealedFile(testFilename)
+		require.NoError(t, err)
+		require.False(t, sealed)
+
+		plain, err := NewFileVault(testFilename)
+		require.NoError(t, err)
+		got, err := plain.ReadData([]byte("key name"))
+		require.NoError(t, err)
+		require.EqualValues(t, "secret value", string(got))
+
+		_, err = NewSealedFileVault(testFilename, []byte("passphrase"))
+		require.Error(t, err)
+		require.EqualValues(t, "filevault: file vault isn't sealed: convert it first", err.Error())
+
+		require.NoError(t, SealFileVault(testFilename, []byte("new passphrase"), SealKDFCost(4)))
+		vault, err := NewSealedFileVault(testFilename, []byte("new passphrase"))
+		require.NoError(t, err)
+		keys, err := vault.ListKeys()
+		require.NoError(t, err)
+		require.EqualValues(t, [][]byte{[]byte("key name")}, keys)
+	})
+	t.Run("reseal with new passphrase", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "sealed.txt")
+		vault, err := NewSealedFileVault(path, []byte("old"), SealKDFCost(4))
+		require.NoError(t, err)
+		require.NoError(t, vault.SaveData([]byte("key"), []byte("value")))
+		require.True(t, vault.Sealed())
+
+		require.NoError(t, vault.ResealCtx(context.Background(), []byte("new")))
+		_, err = NewSealedFileVault(path, []byte("old"))
+		require.True(t, errors.Is(err, secret.ErrDecrypt))
+		other, err := NewSealedFileVault(path, []byte("new"))
+		require.NoError(t, err)
+		got, err := other.ReadData([]byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value", string(got))
+		// the vault keeps working with the new passphrase
+		require.NoError(t, vault.SaveData([]byte("key"), []byte("new value")))
+		got, err = other.ReadData([]byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, "new value", string(got))
+	})
+	t.Run("error if plain file vault is resealed", func(t *testing.T) {
+		plain, err := NewFileVault(filepath.Join(t.TempDir(), "plain.txt"))
+		require.NoError(t, err)
+		require.False(t, plain.Sealed())
+		require.EqualError(t, plain.ResealCtx(context.Background(), []byte("new")), "filevault: file vault isn't sealed")
+	})
+}
+
+func TestSealedFileVault_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) secret.DataSaver {
+		vault, err := NewSealedFileVault(filepath.Join(t.TempDir(), "sealed.json"), []byte("passphrase"), SealKDFCost(4))
+		require.NoError(t, err)
+		return vault
+	})
+}
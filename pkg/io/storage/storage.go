@@ -4,6 +4,8 @@ Package storage provides functions for storing and retrieving data.
 package storage
 
 import (
+	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -11,80 +13,324 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
 )
 
 type fileVault struct {
-	storage map[string][]byte
-	path    string
-}
+	mu       sync.Mutex
+	storage  map[string][]byte
+	versions map[string][]fileVersion
+	expiry   map[string]time.Time
+	metadata map[string]fileMetadata
+	path     string
+	options  options
+	// memory vault isn't backed by the file, see NewMemoryVault
+	memory bool
 
-func NewFileVault(path string) (*fileVault, error) {
-	storage := make(map[string][]byte)
+	// passphrase is set for sealed file vault, see sealed.go
+	passphrase []byte
+	sealOpts   sealOptions
+	kdf        crypto.KDFParams
+	sealer     sealer
+}
 
-	f, err := os.Open(filepath.Clean(path))
-	if err != nil {
+// NewFileVault creates storage in the file by path.
+// File with empty storage is created if it doesn't exist.
+// File is locked while it's read or written, so it can be shared by several processes.
+// Every save creates a new version of the value, see MaxVersions.
+func NewFileVault(path string, opts ...Option) (*fileVault, error) {
+	f := newFileVault(path, newOptions(opts))
+	if _, err := os.Stat(f.path); err != nil {
 		if !os.IsNotExist(err) {
 			return nil, fmt.Errorf("filevault: unable to open file: %w", err)
 		}
-		if f, err = os.Create(path); err != nil {
+		if err := f.update(func() error { return nil }); err != nil {
 			return nil, fmt.Errorf("filevault: unable to create file: %w", err)
 		}
-		if _, err := fmt.Fprintf(f, `{}`); err != nil {
-			return nil, fmt.Errorf("filevault: unable to init file: %w", err)
-		}
+		return f, nil
 	}
-	defer func() {
-		if cerr := f.Close(); err == nil {
-			err = fmt.Errorf("filevault: %w", cerr)
+	if err := f.read(); err != nil {
+		return nil, fmt.Errorf("filevault: %w", err)
+	}
+	return f, nil
+}
+
+func newFileVault(path string, options options) *fileVault {
+	return &fileVault{
+		storage:  make(map[string][]byte),
+		versions: make(map[string][]fileVersion),
+		expiry:   make(map[string]time.Time),
+		metadata: make(map[string]fileMetadata),
+		path:     filepath.Clean(path),
+		options:  options,
+	}
+}
+
+// SaveData saves value by key, which never expires.
+func (f *fileVault) SaveData(key, encodedValue []byte) error {
+	return f.save(key, encodedValue, time.Time{})
+}
+
+func (f *fileVault) ReadData(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("filevault: %w", secret.ErrEmptyKey)
+	}
+	if err := f.read(); err != nil {
+		return nil, fmt.Errorf("filevault: unable to read file while reading: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.value(hex.EncodeToString(key))
+	if !ok {
+		return nil, fmt.Errorf("filevault: cannot read data: %w", secret.ErrNotFound)
+	}
+
+	return cloneBytes(data), nil
+}
+
+// DeleteData removes value by key from the file.
+// Returns an error if the key is not found in the file.
+func (f *fileVault) DeleteData(key []byte) error {
+	if len(key) == 0 {
+		return fmt.Errorf("filevault: %w", secret.ErrEmptyKey)
+	}
+	err := f.update(func() error {
+		hexKey := hex.EncodeToString(key)
+		if _, ok := f.value(hexKey); !ok {
+			return secret.ErrNotFound
 		}
-	}()
+		f.remove(hexKey)
+		return nil
+	})
+	if errors.Is(err, secret.ErrNotFound) {
+		return fmt.Errorf("filevault: cannot delete data: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("filevault: unable to save data while deleting: %w", err)
+	}
+	return nil
+}
 
-	if err := json.NewDecoder(f).Decode(&storage); err != nil {
-		if !errors.Is(err, io.EOF) {
-			return nil, fmt.Errorf("filevault: unable to decode data: %w", err)
+// ListKeys returns all keys stored in the file except expired ones.
+func (f *fileVault) ListKeys() ([][]byte, error) {
+	if err := f.read(); err != nil {
+		return nil, fmt.Errorf("filevault: unable to read file while listing: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([][]byte, 0, len(f.storage))
+	for hexKey := range f.storage {
+		if f.expired(hexKey) {
+			continue
+		}
+		key, err := hex.DecodeString(hexKey)
+		if err != nil {
+			return nil, fmt.Errorf("filevault: unable to decode key %q: %w", hexKey, err)
 		}
+		keys = append(keys, key)
 	}
+	return keys, nil
+}
 
-	return &fileVault{storage: storage, path: path}, nil
+// SaveDataCtx is SaveData which checks context first.
+// File operations can't be interrupted, so context is checked only before them.
+func (f *fileVault) SaveDataCtx(ctx context.Context, key, encodedValue []byte) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("filevault: %w", err)
+	}
+	return f.SaveData(key, encodedValue)
 }
 
-func (f *fileVault) SaveData(key, encodedValue []byte) error {
-	file, err := os.OpenFile(f.path, os.O_WRONLY, 0600)
+// ReadDataCtx is ReadData which checks context first.
+func (f *fileVault) ReadDataCtx(ctx context.Context, key []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("filevault: %w", err)
+	}
+	return f.ReadData(key)
+}
+
+// DeleteDataCtx is DeleteData which checks context first.
+func (f *fileVault) DeleteDataCtx(ctx context.Context, key []byte) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("filevault: %w", err)
+	}
+	return f.DeleteData(key)
+}
+
+// ListKeysCtx is ListKeys which checks context first.
+func (f *fileVault) ListKeysCtx(ctx context.Context) ([][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("filevault: %w", err)
+	}
+	return f.ListKeys()
+}
+
+// read replaces in-memory storage with the file content under shared lock.
+func (f *fileVault) read() error {
+	if f.memory {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, unlock, err := lockFile(f.path, false)
 	if err != nil {
-		return fmt.Errorf("filevault: unable to open file while saving: %w", err)
+		return err
 	}
-	defer func() {
-		if cerr := file.Close(); err == nil {
-			err = fmt.Errorf("filevault: %w", cerr)
+	defer unlock()
+	return f.load(data)
+}
+
+// update reloads in-memory storage from the file, applies fn to it and writes the result back.
+// The whole read-modify-write is done under exclusive lock, so changes of other processes aren't lost.
+// Storage isn't written if fn returns an error, so fn should return errors before it changes the storage.
+func (f *fileVault) update(fn func() error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.memory {
+		return fn()
+	}
+	data, unlock, err := lockFile(f.path, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := f.load(data); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return f.write()
+}
+
+// load replaces in-memory storage with the file content.
+// Sealed file is decrypted first.
+func (f *fileVault) load(data []byte) error {
+	doc, sealed, err := parseSealed(data)
+	if err != nil {
+		return err
+	}
+	if sealed {
+		if f.passphrase == nil {
+			return ErrSealed
 		}
-	}()
+		if data, err = f.unseal(doc); err != nil {
+			return err
+		}
+	}
+	storage := make(map[string][]byte)
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&storage); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("unable to decode data: %w", err)
+	}
+	versions := make(map[string][]fileVersion)
+	if err := popEntry(storage, versionsKey, &versions); err != nil {
+		return err
+	}
+	expiry := make(map[string]time.Time)
+	if err := popEntry(storage, expiryKey, &expiry); err != nil {
+		return err
+	}
+	metadata := make(map[string]fileMetadata)
+	if err := popEntry(storage, metadataKey, &metadata); err != nil {
+		return err
+	}
+	// empty plain file can be used as sealed one without conversion
+	if !sealed && f.passphrase != nil && len(storage) > 0 {
+		return errors.New("file vault isn't sealed: convert it first")
+	}
+	f.storage = storage
+	f.versions = versions
+	f.expiry = expiry
+	f.metadata = metadata
+	return nil
+}
+
+// popEntry decodes reserved entry of the document into v and removes it from the document.
+// v is left as is if there is no such entry.
+func popEntry(doc map[string][]byte, name string, v interface{}) error {
+	data, ok := doc[name]
+	if !ok {
+		return nil
+	}
+	delete(doc, name)
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("unable to decode %s: %w", name, err)
+	}
+	return nil
+}
 
-	f.storage[hex.EncodeToString(key)] = encodedValue
-	if err = json.NewEncoder(file).Encode(f.storage); err != nil {
-		return fmt.Errorf("filevault: unable to encode data while saving: %w", err)
+// putEntry encodes v as reserved entry of the document, if v has any items.
+func putEntry(doc map[string][]byte, name string, n int, v interface{}) error {
+	if n == 0 {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("unable to encode %s: %w", name, err)
 	}
+	doc[name] = data
 	return nil
 }
 
-func (f *fileVault) ReadData(key []byte) ([]byte, error) {
-	file, err := os.OpenFile(f.path, os.O_RDONLY, 0600)
+// write replaces the file with in-memory storage.
+// Data is written to temporary file which is synced and renamed over the file,
+// so the file is never left partially written.
+func (f *fileVault) write() (err error) {
+	dir, name := filepath.Split(f.path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, name+".tmp*")
 	if err != nil {
-		return nil, fmt.Errorf("filevault: unable to open file while reading: %w", err)
+		return fmt.Errorf("unable to create temporary file: %w", err)
 	}
 	defer func() {
-		if cerr := file.Close(); err == nil {
-			err = fmt.Errorf("filevault: %w", cerr)
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
 		}
 	}()
 
-	if err := json.NewDecoder(file).Decode(&f.storage); err != nil {
-		return nil, fmt.Errorf("filevault: unable to decode while reading: %w", err)
+	if err := tmp.Chmod(0600); err != nil {
+		return fmt.Errorf("unable to change file mode: %w", err)
 	}
-
-	data, ok := f.storage[hex.EncodeToString(key)]
-	if !ok {
-		return nil, fmt.Errorf("filevault: cannot read data: not found")
+	doc := make(map[string][]byte, len(f.storage)+3)
+	for k, v := range f.storage {
+		doc[k] = v
 	}
-
-	return data, nil
+	if err := putEntry(doc, versionsKey, len(f.versions), f.versions); err != nil {
+		return err
+	}
+	if err := putEntry(doc, expiryKey, len(f.expiry), f.expiry); err != nil {
+		return err
+	}
+	if err := putEntry(doc, metadataKey, len(f.metadata), f.metadata); err != nil {
+		return err
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("unable to encode data: %w", err)
+	}
+	if f.passphrase != nil {
+		if data, err = f.seal(data); err != nil {
+			return err
+		}
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("unable to write data: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("unable to sync file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to close file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("unable to replace file: %w", err)
+	}
+	return syncDir(dir)
 }
@@ -1,12 +1,23 @@
 package storage
 
 import (
+	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/io/storage/storagetest"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
 )
 
 const testFilename = "testfile.json"
@@ -58,4 +69,402 @@ func TestFileVault(t *testing.T) {
 		require.NoError(t, err)
 		require.EqualValues(t, want, got)
 	})
+	t.Run("ListKeys", func(t *testing.T) {
+		require.NoError(t, fileVault.SaveData([]byte("f3"), []byte("value")))
+
+		got, err := fileVault.ListKeys()
+		require.NoError(t, err)
+		require.ElementsMatch(t, [][]byte{[]byte("f2"), []byte("f3")}, got)
+	})
+
+	t.Run("DeleteData", func(t *testing.T) {
+		require.NoError(t, fileVault.DeleteData([]byte("f3")))
+
+		_, err := fileVault.ReadData([]byte("f3"))
+		require.Error(t, err)
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+
+		got, err := fileVault.ListKeys()
+		require.NoError(t, err)
+		require.ElementsMatch(t, [][]byte{[]byte("f2")}, got)
+	})
+
+	t.Run("DeleteData error if key not found", func(t *testing.T) {
+		err := fileVault.DeleteData([]byte("f3"))
+		require.Error(t, err)
+		require.EqualValues(t, "filevault: cannot delete data: key not found", err.Error())
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+	})
+}
+
+func TestFileVault_Write(t *testing.T) {
+	defer func() {
+		require.NoError(t, os.Remove(testFilename))
+	}()
+
+	t.Run("shorter data truncates the file", func(t *testing.T) {
+		fileVault, err := NewFileVault(testFilename)
+		require.NoError(t, err)
+		require.NoError(t, fileVault.SaveData([]byte("long key"), bytes.Repeat([]byte("v"), 100)))
+		require.NoError(t, fileVault.DeleteData([]byte("long key")))
+
+		data, err := ioutil.ReadFile(testFilename)
+		require.NoError(t, err)
+		require.EqualValues(t, "{}\n", string(data))
+	})
+	t.Run("changes of another vault are not lost", func(t *testing.T) {
+		first, err := NewFileVault(testFilename)
+		require.NoError(t, err)
+		second, err := NewFileVault(testFilename)
+		require.NoError(t, err)
+
+		require.NoError(t, first.SaveData([]byte("first"), []byte("value")))
+		require.NoError(t, second.SaveData([]byte("second"), []byte("value")))
+
+		got, err := first.ReadData([]byte("second"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value", string(got))
+		keys, err := second.ListKeys()
+		require.NoError(t, err)
+		require.ElementsMatch(t, [][]byte{[]byte("first"), []byte("second")}, keys)
+	})
+	t.Run("concurrent writes", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				fileVault, err := NewFileVault(testFilename)
+				require.NoError(t, err)
+				require.NoError(t, fileVault.SaveData([]byte(fmt.Sprintf("key %d", i)), []byte("value")))
+			}(i)
+		}
+		wg.Wait()
+
+		fileVault, err := NewFileVault(testFilename)
+		require.NoError(t, err)
+		keys, err := fileVault.ListKeys()
+		require.NoError(t, err)
+		require.Len(t, keys, 12)
+	})
+}
+
+func TestFileVault_Versions(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "file.json")
+	key := []byte("key")
+
+	t.Run("history", func(t *testing.T) {
+		fileVault, err := NewFileVault(path, MaxVersions(2))
+		require.NoError(t, err)
+		for _, v := range []string{"value 1", "value 2", "value 3"} {
+			require.NoError(t, fileVault.SaveData(key, []byte(v)))
+		}
+
+		versions, err := fileVault.VersionsCtx(ctx, key)
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		require.EqualValues(t, 2, versions[0].Number)
+		require.EqualValues(t, 3, versions[1].Number)
+		require.False(t, versions[1].CreatedAt.IsZero())
+
+		got, err := fileVault.ReadVersionCtx(ctx, key, 2)
+		require.NoError(t, err)
+		require.EqualValues(t, "value 2", string(got))
+		got, err = fileVault.ReadVersionCtx(ctx, key, 3)
+		require.NoError(t, err)
+		require.EqualValues(t, "value 3", string(got))
+		_, err = fileVault.ReadVersionCtx(ctx, key, 1)
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+
+		// another vault reads versions from the file
+		another, err := NewFileVault(path)
+		require.NoError(t, err)
+		got, err = another.ReadVersionCtx(ctx, key, 2)
+		require.NoError(t, err)
+		require.EqualValues(t, "value 2", string(got))
+	})
+	t.Run("delete removes versions", func(t *testing.T) {
+		fileVault, err := NewFileVault(path)
+		require.NoError(t, err)
+		require.NoError(t, fileVault.DeleteData(key))
+		_, err = fileVault.VersionsCtx(ctx, key)
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		require.NoError(t, fileVault.SaveData(key, []byte("value")))
+		versions, err := fileVault.VersionsCtx(ctx, key)
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+		require.EqualValues(t, 1, versions[0].Number)
+	})
+	t.Run("save history", func(t *testing.T) {
+		fileVault, err := NewFileVault(filepath.Join(t.TempDir(), "history.json"), MaxVersions(2))
+		require.NoError(t, err)
+		created := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+		history := []secret.VersionedValue{
+			{Version: secret.Version{Number: 1}, Value: []byte("value 1")},
+			{Version: secret.Version{Number: 4, CreatedAt: created}, Value: []byte("value 4")},
+			{Version: secret.Version{Number: 7, CreatedAt: created.Add(time.Hour)}, Value: []byte("value 7")},
+		}
+		require.NoError(t, fileVault.SaveHistoryCtx(ctx, key, history, time.Time{}))
+
+		versions, err := fileVault.VersionsCtx(ctx, key)
+		require.NoError(t, err)
+		require.EqualValues(t, []secret.Version{history[1].Version, history[2].Version}, versions)
+		got, err := fileVault.ReadVersionCtx(ctx, key, 4)
+		require.NoError(t, err)
+		require.EqualValues(t, "value 4", string(got))
+		got, revision, err := fileVault.ReadDataWithRevisionCtx(ctx, key)
+		require.NoError(t, err)
+		require.EqualValues(t, "value 7", string(got))
+		require.EqualValues(t, 7, revision)
+
+		// next save continues the history
+		require.NoError(t, fileVault.SaveData(key, []byte("value 8")))
+		versions, err = fileVault.VersionsCtx(ctx, key)
+		require.NoError(t, err)
+		require.EqualValues(t, 8, versions[len(versions)-1].Number)
+	})
+	t.Run("value saved without versions is the version 1", func(t *testing.T) {
+		legacyPath := filepath.Join(t.TempDir(), "legacy.json")
+		data, err := json.Marshal(map[string][]byte{hex.EncodeToString(key): []byte("legacy")})
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(legacyPath, data, 0600))
+
+		fileVault, err := NewFileVault(legacyPath)
+		require.NoError(t, err)
+		require.NoError(t, fileVault.SaveData(key, []byte("value")))
+		versions, err := fileVault.VersionsCtx(ctx, key)
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		require.True(t, versions[0].CreatedAt.IsZero())
+		got, err := fileVault.ReadVersionCtx(ctx, key, 1)
+		require.NoError(t, err)
+		require.EqualValues(t, "legacy", string(got))
+	})
+}
+
+func TestFileVault_Expiry(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "file.json")
+	fileVault, err := NewFileVault(path)
+	require.NoError(t, err)
+
+	t.Run("expired value isn't returned", func(t *testing.T) {
+		key := []byte("expired")
+		require.NoError(t, fileVault.SaveDataWithExpiryCtx(ctx, key, []byte("value"), time.Now().Add(-time.Second)))
+
+		_, err := fileVault.ReadData(key)
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		_, err = fileVault.ExpiryCtx(ctx, key)
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		_, err = fileVault.VersionsCtx(ctx, key)
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		keys, err := fileVault.ListKeys()
+		require.NoError(t, err)
+		require.NotContains(t, keys, key)
+		err = fileVault.DeleteData(key)
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+
+		// history of expired value starts again
+		require.NoError(t, fileVault.SaveData(key, []byte("new value")))
+		versions, err := fileVault.VersionsCtx(ctx, key)
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+	})
+	t.Run("value expires at the time", func(t *testing.T) {
+		key := []byte("expiring")
+		expiresAt := time.Now().Add(time.Hour).UTC().Round(0)
+		require.NoError(t, fileVault.SaveDataWithExpiryCtx(ctx, key, []byte("value"), expiresAt))
+
+		got, err := fileVault.ReadData(key)
+		require.NoError(t, err)
+		require.EqualValues(t, "value", string(got))
+		expiry, err := fileVault.ExpiryCtx(ctx, key)
+		require.NoError(t, err)
+		require.True(t, expiresAt.Equal(expiry))
+
+		// plain save makes value persistent
+		require.NoError(t, fileVault.SaveData(key, []byte("value")))
+		expiry, err = fileVault.ExpiryCtx(ctx, key)
+		require.NoError(t, err)
+		require.True(t, expiry.IsZero())
+	})
+	t.Run("reap expired values", func(t *testing.T) {
+		require.NoError(t, fileVault.SaveDataWithExpiryCtx(ctx, []byte("first"), []byte("value"), time.Now().Add(-time.Second)))
+		require.NoError(t, fileVault.SaveDataWithExpiryCtx(ctx, []byte("second"), []byte("value"), time.Now().Add(-time.Second)))
+		require.NoError(t, fileVault.SaveDataWithExpiryCtx(ctx, []byte("third"), []byte("value"), time.Now().Add(time.Hour)))
+
+		n, err := fileVault.ReapCtx(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, n)
+		n, err = fileVault.ReapCtx(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 0, n)
+
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		fileData := make(map[string][]byte)
+		require.NoError(t, json.Unmarshal(data, &fileData))
+		require.NotContains(t, fileData, hex.EncodeToString([]byte("first")))
+		require.Contains(t, fileData, hex.EncodeToString([]byte("third")))
+	})
+}
+
+func TestFileVault_Metadata(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "file.json")
+	fileVault, err := NewFileVault(path)
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		key := []byte("key")
+		require.NoError(t, fileVault.SaveData(key, []byte("value")))
+		record, err := fileVault.ReadMetadataCtx(ctx, key)
+		require.NoError(t, err)
+		require.Empty(t, record.Data)
+		require.False(t, record.CreatedAt.IsZero())
+		require.True(t, record.CreatedAt.Equal(record.UpdatedAt))
+		createdAt := record.CreatedAt
+
+		require.NoError(t, fileVault.SaveMetadataCtx(ctx, key, []byte(`{"owner":"team-a"}`)))
+		// saving the value keeps metadata and creation time
+		require.NoError(t, fileVault.SaveData(key, []byte("new value")))
+
+		// metadata is read from the file by another vault
+		other, err := NewFileVault(path)
+		require.NoError(t, err)
+		record, err = other.ReadMetadataCtx(ctx, key)
+		require.NoError(t, err)
+		require.EqualValues(t, `{"owner":"team-a"}`, string(record.Data))
+		require.True(t, createdAt.Equal(record.CreatedAt))
+		require.True(t, record.UpdatedAt.After(createdAt))
+	})
+	t.Run("metadata is removed with the value", func(t *testing.T) {
+		key := []byte("removed")
+		require.NoError(t, fileVault.SaveData(key, []byte("value")))
+		require.NoError(t, fileVault.SaveMetadataCtx(ctx, key, []byte(`{"owner":"team-a"}`)))
+		require.NoError(t, fileVault.DeleteData(key))
+
+		_, err := fileVault.ReadMetadataCtx(ctx, key)
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		require.NoError(t, fileVault.SaveData(key, []byte("value")))
+		record, err := fileVault.ReadMetadataCtx(ctx, key)
+		require.NoError(t, err)
+		require.Empty(t, record.Data)
+	})
+	t.Run("save data with metadata", func(t *testing.T) {
+		key := []byte("with-metadata")
+		revision, err := fileVault.SaveDataWithMetadataCtx(ctx, key, []byte("value"), []byte(`{"owner":"team-a"}`), time.Time{}, 0)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, revision)
+
+		// neither value nor metadata is saved if revision doesn't match
+		_, err = fileVault.SaveDataWithMetadataCtx(ctx, key, []byte("new value"), []byte(`{"owner":"team-b"}`), time.Time{}, 0)
+		require.True(t, errors.Is(err, secret.ErrRevisionMismatch))
+
+		other, err := NewFileVault(path)
+		require.NoError(t, err)
+		value, err := other.ReadData(key)
+		require.NoError(t, err)
+		require.EqualValues(t, "value", value)
+		record, err := other.ReadMetadataCtx(ctx, key)
+		require.NoError(t, err)
+		require.EqualValues(t, `{"owner":"team-a"}`, string(record.Data))
+	})
+	t.Run("not found", func(t *testing.T) {
+		err := fileVault.SaveMetadataCtx(ctx, []byte("missing"), []byte(`{"owner":"team-a"}`))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		_, err = fileVault.ReadMetadataCtx(ctx, []byte("missing"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+
+		require.NoError(t, fileVault.SaveDataWithExpiryCtx(ctx, []byte("expired"), []byte("value"), time.Now().Add(-time.Second)))
+		_, err = fileVault.ReadMetadataCtx(ctx, []byte("expired"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+	})
+}
+
+func TestFileVault_Revision(t *testing.T) {
+	ctx := context.Background()
+	fileVault, err := NewFileVault(filepath.Join(t.TempDir(), "file.json"))
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		key := []byte("key")
+		revision, err := fileVault.SaveDataIfRevisionCtx(ctx, key, []byte("value 1"), time.Time{}, 0)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, revision)
+		revision, err = fileVault.SaveDataIfRevisionCtx(ctx, key, []byte("value 2"), time.Time{}, revision)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, revision)
+		require.NoError(t, fileVault.SaveData(key, []byte("value 3")))
+
+		value, revision, err := fileVault.ReadDataWithRevisionCtx(ctx, key)
+		require.NoError(t, err)
+		require.EqualValues(t, "value 3", string(value))
+		require.EqualValues(t, 3, revision)
+		revision, err = fileVault.SaveDataIfRevisionCtx(ctx, key, []byte("value 4"), time.Time{}, secret.AnyRevision)
+		require.NoError(t, err)
+		require.EqualValues(t, 4, revision)
+	})
+	t.Run("revision mismatch", func(t *testing.T) {
+		key := []byte("mismatch")
+		require.NoError(t, fileVault.SaveData(key, []byte("value 1")))
+		require.NoError(t, fileVault.SaveData(key, []byte("value 2")))
+
+		_, err := fileVault.SaveDataIfRevisionCtx(ctx, key, []byte("stale"), time.Time{}, 1)
+		require.True(t, errors.Is(err, secret.ErrRevisionMismatch))
+		_, err = fileVault.SaveDataIfRevisionCtx(ctx, key, []byte("stale"), time.Time{}, 0)
+		require.True(t, errors.Is(err, secret.ErrRevisionMismatch))
+		_, err = fileVault.SaveDataIfRevisionCtx(ctx, []byte("missing"), []byte("stale"), time.Time{}, 1)
+		require.True(t, errors.Is(err, secret.ErrRevisionMismatch))
+
+		value, revision, err := fileVault.ReadDataWithRevisionCtx(ctx, key)
+		require.NoError(t, err)
+		require.EqualValues(t, "value 2", string(value))
+		require.EqualValues(t, 2, revision)
+		_, _, err = fileVault.ReadDataWithRevisionCtx(ctx, []byte("missing"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+	})
+	t.Run("revision starts again after expiration", func(t *testing.T) {
+		key := []byte("expired")
+		require.NoError(t, fileVault.SaveData(key, []byte("value 1")))
+		require.NoError(t, fileVault.SaveDataWithExpiryCtx(ctx, key, []byte("value 2"), time.Now().Add(-time.Second)))
+
+		revision, err := fileVault.SaveDataIfRevisionCtx(ctx, key, []byte("value 3"), time.Time{}, 0)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, revision)
+	})
+}
+
+func TestFileVault_Batch(t *testing.T) {
+	ctx := context.Background()
+	fileVault, err := NewFileVault(filepath.Join(t.TempDir(), "file.json"))
+	require.NoError(t, err)
+
+	require.NoError(t, fileVault.SaveData([]byte("first"), []byte("old value")))
+	err = fileVault.SaveDataBatchCtx(ctx, []secret.Entry{
+		{Key: []byte("first"), Value: []byte("value 1")},
+		{Key: []byte("second"), Value: []byte("value 2")},
+	})
+	require.NoError(t, err)
+
+	values, err := fileVault.ReadDataBatchCtx(ctx, [][]byte{[]byte("second"), []byte("missing"), []byte("first")})
+	require.NoError(t, err)
+	require.EqualValues(t, [][]byte{[]byte("value 2"), nil, []byte("value 1")}, values)
+	versions, err := fileVault.VersionsCtx(ctx, []byte("first"))
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+
+	err = fileVault.SaveDataBatchCtx(ctx, []secret.Entry{{Key: []byte("third"), Value: []byte("value")}, {Key: nil, Value: []byte("value")}})
+	require.True(t, errors.Is(err, secret.ErrEmptyKey))
+	_, err = fileVault.ReadData([]byte("third"))
+	require.True(t, errors.Is(err, secret.ErrNotFound))
+}
+
+func TestFileVault_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) secret.DataSaver {
+		fileVault, err := NewFileVault(filepath.Join(t.TempDir(), "file.json"))
+		require.NoError(t, err)
+		return fileVault
+	})
 }
@@ -0,0 +1,214 @@
+// Package storagetest provides the conformance test suite for data savers.
+//
+// Every backend of the storage package passes it, and third-party backends can check themselves the same way:
+//
+//    func TestMyVault(t *testing.T) {
+//        storagetest.Run(t, func(t *testing.T) secret.DataSaver {
+//            return NewMyVault(t.TempDir())
+//        })
+//    }
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// Factory returns new empty data saver for the test.
+// Data saver, which should be closed, is closed by the factory with t.Cleanup.
+type Factory func(t *testing.T) secret.DataSaver
+
+// Run runs the conformance tests of data saver as subtests of t.
+// Every subtest gets its own data saver from the factory.
+//
+// Data saver should:
+//    return the value saved by the latest save of the key;
+//    return errors wrapping secret.ErrEmptyKey for empty keys;
+//    return errors wrapping secret.ErrNotFound when the key is read or deleted, but there is no value by it;
+//    accept any bytes in keys and values, including zero bytes and invalid UTF-8;
+//    not share saved and returned values with the caller;
+//    be safe for concurrent use.
+// Empty values aren't checked: some data savers delete the key when it's saved with empty value.
+// Data saver, which implements secret.DataSaverCtx, should return errors wrapping the context error for done context.
+func Run(t *testing.T, factory Factory) {
+	t.Run("save and read", func(t *testing.T) {
+		ds := factory(t)
+		require.NoError(t, ds.SaveData([]byte("key"), []byte("value")))
+		got, err := ds.ReadData([]byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value", got)
+	})
+	t.Run("overwrite", func(t *testing.T) {
+		ds := factory(t)
+		require.NoError(t, ds.SaveData([]byte("key"), []byte("value 1")))
+		require.NoError(t, ds.SaveData([]byte("key"), []byte("value 2")))
+		got, err := ds.ReadData([]byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value 2", got)
+		keys, err := ds.ListKeys()
+		require.NoError(t, err)
+		require.EqualValues(t, [][]byte{[]byte("key")}, keys)
+	})
+	t.Run("error if key is empty", func(t *testing.T) {
+		ds := factory(t)
+		for _, key := range [][]byte{nil, {}} {
+			err := ds.SaveData(key, []byte("value"))
+			require.True(t, errors.Is(err, secret.ErrEmptyKey), "save: %v", err)
+			_, err = ds.ReadData(key)
+			require.True(t, errors.Is(err, secret.ErrEmptyKey), "read: %v", err)
+			err = ds.DeleteData(key)
+			require.True(t, errors.Is(err, secret.ErrEmptyKey), "delete: %v", err)
+		}
+	})
+	t.Run("error if key is not found", func(t *testing.T) {
+		ds := factory(t)
+		got, err := ds.ReadData([]byte("missing"))
+		require.True(t, errors.Is(err, secret.ErrNotFound), "read: %v", err)
+		require.Empty(t, got)
+		err = ds.DeleteData([]byte("missing"))
+		require.True(t, errors.Is(err, secret.ErrNotFound), "delete: %v", err)
+	})
+	t.Run("delete", func(t *testing.T) {
+		ds := factory(t)
+		require.NoError(t, ds.SaveData([]byte("key"), []byte("value")))
+		require.NoError(t, ds.SaveData([]byte("other"), []byte("value")))
+		require.NoError(t, ds.DeleteData([]byte("key")))
+
+		_, err := ds.ReadData([]byte("key"))
+		require.True(t, errors.Is(err, secret.ErrNotFound), "read: %v", err)
+		err = ds.DeleteData([]byte("key"))
+		require.True(t, errors.Is(err, secret.ErrNotFound), "delete: %v", err)
+		keys, err := ds.ListKeys()
+		require.NoError(t, err)
+		require.EqualValues(t, [][]byte{[]byte("other")}, keys)
+
+		require.NoError(t, ds.SaveData([]byte("key"), []byte("new value")))
+		got, err := ds.ReadData([]byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, "new value", got)
+	})
+	t.Run("list keys", func(t *testing.T) {
+		ds := factory(t)
+		keys, err := ds.ListKeys()
+		require.NoError(t, err)
+		require.Empty(t, keys)
+		for _, key := range []string{"c", "a", "b"} {
+			require.NoError(t, ds.SaveData([]byte(key), []byte("value")))
+		}
+		keys, err = ds.ListKeys()
+		require.NoError(t, err)
+		require.ElementsMatch(t, [][]byte{[]byte("a"), []byte("b"), []byte("c")}, keys)
+	})
+	t.Run("binary keys and values", func(t *testing.T) {
+		ds := factory(t)
+		all := make([]byte, 256)
+		for i := range all {
+			all[i] = byte(i)
+		}
+		keys := [][]byte{{0}, {0, 0}, {1}, {1, 0}, {0xff, 0xfe}, []byte("team/key with spaces"), []byte("ключ"), all}
+		for i, key := range keys {
+			require.NoError(t, ds.SaveData(key, append([]byte{byte(i)}, all...)))
+		}
+		for i, key := range keys {
+			got, err := ds.ReadData(key)
+			require.NoError(t, err)
+			require.EqualValues(t, append([]byte{byte(i)}, all...), got, "key %x", key)
+		}
+		got, err := ds.ListKeys()
+		require.NoError(t, err)
+		require.ElementsMatch(t, keys, got)
+	})
+	t.Run("large value", func(t *testing.T) {
+		ds := factory(t)
+		value := make([]byte, 1<<20)
+		rand.New(rand.NewSource(1)).Read(value)
+		require.NoError(t, ds.SaveData([]byte("key"), value))
+		got, err := ds.ReadData([]byte("key"))
+		require.NoError(t, err)
+		require.True(t, bytes.Equal(value, got), "value of %d bytes is read as %d bytes", len(value), len(got))
+	})
+	t.Run("values aren't shared with caller", func(t *testing.T) {
+		ds := factory(t)
+		value := []byte("value")
+		require.NoError(t, ds.SaveData([]byte("key"), value))
+		value[0] = 'X'
+		got, err := ds.ReadData([]byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value", got)
+		got[0] = 'X'
+		got, err = ds.ReadData([]byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value", got)
+	})
+	t.Run("concurrent access", func(t *testing.T) {
+		ds := factory(t)
+		const workers, saves = 10, 5
+		errs := make(chan error, workers*saves*3)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				key := []byte(fmt.Sprintf("key %d", w))
+				for i := 0; i < saves; i++ {
+					value := []byte(fmt.Sprintf("value %d-%d", w, i))
+					if err := ds.SaveData(key, value); err != nil {
+						errs <- fmt.Errorf("save %s: %w", key, err)
+						continue
+					}
+					if err := ds.SaveData([]byte("shared"), value); err != nil {
+						errs <- fmt.Errorf("save shared: %w", err)
+					}
+					got, err := ds.ReadData(key)
+					if err != nil {
+						errs <- fmt.Errorf("read %s: %w", key, err)
+					} else if !bytes.Equal(value, got) {
+						errs <- fmt.Errorf("read %s: got %q, want %q", key, got, value)
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		keys, err := ds.ListKeys()
+		require.NoError(t, err)
+		require.Len(t, keys, workers+1)
+		for w := 0; w < workers; w++ {
+			got, err := ds.ReadData([]byte(fmt.Sprintf("key %d", w)))
+			require.NoError(t, err)
+			require.EqualValues(t, fmt.Sprintf("value %d-%d", w, saves-1), got)
+		}
+		got, err := ds.ReadData([]byte("shared"))
+		require.NoError(t, err)
+		require.Regexp(t, `^value \d+-\d+$`, string(got))
+	})
+	t.Run("done context", func(t *testing.T) {
+		ds, ok := factory(t).(secret.DataSaverCtx)
+		if !ok {
+			t.Skip("data saver doesn't accept context")
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := ds.SaveDataCtx(ctx, []byte("key"), []byte("value"))
+		require.True(t, errors.Is(err, context.Canceled), "save: %v", err)
+		_, err = ds.ReadDataCtx(ctx, []byte("key"))
+		require.True(t, errors.Is(err, context.Canceled), "read: %v", err)
+		_, err = ds.ListKeysCtx(ctx)
+		require.True(t, errors.Is(err, context.Canceled), "list: %v", err)
+		_, err = ds.ReadDataCtx(context.Background(), []byte("key"))
+		require.True(t, errors.Is(err, secret.ErrNotFound), "value shouldn't be saved with done context: %v", err)
+	})
+}
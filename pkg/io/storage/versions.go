@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// DefaultMaxVersions is the default number of versions kept for every key.
+const DefaultMaxVersions = 10
+
+type options struct {
+	maxVersions int
+}
+
+var defaultOptions = options{
+	maxVersions: DefaultMaxVersions,
+}
+
+// Option configures storage.
+type Option func(o *options)
+
+// MaxVersions sets how many versions are kept for every key, older versions are removed on save.
+// All versions are kept if n isn't positive. Default: DefaultMaxVersions.
+func MaxVersions(n int) Option {
+	return func(o *options) {
+		o.maxVersions = n
+	}
+}
+
+func newOptions(opts []Option) options {
+	options := defaultOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// versionsKey is the entry of file vault document with versions of values.
+// It can't be a key of data, because keys of data are hex encoded.
+const versionsKey = "versions"
+
+// fileVersion is the version of the value in file vault.
+// Value of the latest version isn't duplicated, it's the value of the key in storage.
+type fileVersion struct {
+	Number    int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Value     []byte    `json:"value,omitempty"`
+}
+
+// VersionsCtx returns versions of the key from the oldest to the latest.
+func (f *fileVault) VersionsCtx(ctx context.Context, key []byte) ([]secret.Version, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("filevault: %w", err)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("filevault: %w", secret.ErrEmptyKey)
+	}
+	if err := f.read(); err != nil {
+		return nil, fmt.Errorf("filevault: unable to read file while reading versions: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	history := f.history(hex.EncodeToString(key))
+	if len(history) == 0 {
+		return nil, fmt.Errorf("filevault: cannot read versions: %w", secret.ErrNotFound)
+	}
+	versions := make([]secret.Version, 0, len(history))
+	for _, v := range history {
+		versions = append(versions, secret.Version{Number: v.Number, CreatedAt: v.CreatedAt})
+	}
+	return versions, nil
+}
+
+// ReadVersionCtx returns encoded value of the version.
+func (f *fileVault) ReadVersionCtx(ctx context.Context, key []byte, version int) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("filevault: %w", err)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("filevault: %w", secret.ErrEmptyKey)
+	}
+	if err := f.read(); err != nil {
+		return nil, fmt.Errorf("filevault: unable to read file while reading version: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	hexKey := hex.EncodeToString(key)
+	history := f.history(hexKey)
+	for i, v := range history {
+		if v.Number != version {
+			continue
+		}
+		if i == len(history)-1 {
+			return cloneBytes(f.storage[hexKey]), nil
+		}
+		return cloneBytes(v.Value), nil
+	}
+	return nil, fmt.Errorf("filevault: cannot read version %d: %w", version, secret.ErrNotFound)
+}
+
+// SaveHistoryCtx replaces the value by key and its versions with the history, which expires at the time.
+// Versions over the limit are removed.
+func (f *fileVault) SaveHistoryCtx(ctx context.Context, key []byte, history []secret.VersionedValue, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("filevault: %w", err)
+	}
+	if len(key) == 0 {
+		return fmt.Errorf("filevault: %w", secret.ErrEmptyKey)
+	}
+	if len(history) == 0 {
+		return errors.New("filevault: history is empty")
+	}
+	err := f.update(func() error {
+		hexKey := hex.EncodeToString(key)
+		_, exists := f.value(hexKey)
+		if !exists {
+			f.remove(hexKey)
+		}
+		f.touch(hexKey, !exists)
+		if max := f.options.maxVersions; max > 0 && len(history) > max {
+			history = history[len(history)-max:]
+		}
+		versions := make([]fileVersion, 0, len(history))
+		for _, v := range history {
+			versions = append(versions, fileVersion{Number: v.Number, CreatedAt: v.CreatedAt.UTC(), Value: cloneBytes(v.Value)})
+		}
+		// value of the latest version is the value of the key
+		versions[len(versions)-1].Value = nil
+		f.versions[hexKey] = versions
+		f.storage[hexKey] = cloneBytes(history[len(history)-1].Value)
+		if expiresAt.IsZero() {
+			delete(f.expiry, hexKey)
+		} else {
+			f.expiry[hexKey] = expiresAt.UTC()
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("filevault: unable to save history: %w", err)
+	}
+	return nil
+}
+
+// history returns versions of the key.
+// Value saved before file vault started to keep versions is the version 1.
+// Mutex should be locked by caller.
+func (f *fileVault) history(hexKey string) []fileVersion {
+	if _, ok := f.value(hexKey); !ok {
+		return nil
+	}
+	if history := f.versions[hexKey]; len(history) > 0 {
+		return history
+	}
+	return []fileVersion{{Number: 1}}
+}
+
+// addVersion saves value as the new latest version of the key.
+// Previous latest value is moved to the history and versions over the limit are removed.
+// Mutex should be locked by caller.
+func (f *fileVault) addVersion(hexKey string, value []byte) {
+	history := f.history(hexKey)
+	number := 1
+	if len(history) > 0 {
+		last := &history[len(history)-1]
+		last.Value = f.storage[hexKey]
+		number = last.Number + 1
+	}
+	history = append(history, fileVersion{Number: number, CreatedAt: time.Now().UTC()})
+	if max := f.options.maxVersions; max > 0 && len(history) > max {
+		history = append([]fileVersion(nil), history[len(history)-max:]...)
+	}
+	f.versions[hexKey] = history
+	f.storage[hexKey] = cloneBytes(value)
+}
@@ -0,0 +1,24 @@
+package keyring
+
+import (
+	"os"
+	"strings"
+)
+
+// FromEnv reads keys from environment variables with the prefix.
+// Id is the rest of variable name in lower case,
+// so GO_SECRET_KEY_PAYMENTS is the key "payments" for the prefix GO_SECRET_KEY_.
+func FromEnv(prefix string) Static {
+	keys := make(Static)
+	for _, kv := range os.Environ() {
+		name, value := kv, ""
+		if i := strings.IndexByte(kv, '='); i >= 0 {
+			name, value = kv[:i], kv[i+1:]
+		}
+		if len(name) <= len(prefix) || !strings.HasPrefix(name, prefix) || value == "" {
+			continue
+		}
+		keys[strings.ToLower(name[len(prefix):])] = value
+	}
+	return keys
+}
@@ -0,0 +1,116 @@
+package keyring
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExitUnknownKey is the exit code of key plugin if it doesn't have key with the id.
+const ExitUnknownKey = 2
+
+// execKeyID is the pattern of key ids passed to key plugin.
+// Key id comes from the request, so it can't look like an option of the plugin.
+var execKeyID = regexp.MustCompile(`^[A-Za-z0-9._][A-Za-z0-9._-]*$`)
+
+type execKeyring struct {
+	command string
+	args    []string
+	options execOptions
+
+	mu    sync.Mutex
+	cache map[string]cachedKey
+}
+
+type cachedKey struct {
+	key     string
+	expires time.Time
+}
+
+type execOptions struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+	now      func() time.Time
+}
+
+// ExecOption configures key plugin.
+type ExecOption func(o *execOptions)
+
+// ExecTimeout sets max time to wait for the plugin. Default: 10 seconds.
+func ExecTimeout(d time.Duration) ExecOption {
+	return func(o *execOptions) {
+		o.timeout = d
+	}
+}
+
+// ExecCacheTTL sets how long received keys are kept in memory. Default: 5 minutes.
+// Keys aren't cached if ttl is zero.
+func ExecCacheTTL(d time.Duration) ExecOption {
+	return func(o *execOptions) {
+		o.cacheTTL = d
+	}
+}
+
+// NewExec creates keyring, which requests keys from external plugin, for example client of KMS.
+// Plugin is run as command with args, "--" and key id as the last arguments.
+// Key id should contain only letters, digits, ".", "_" and "-" and can't start with "-".
+// It should print the key to stdout and exit with code 0,
+// or exit with ExitUnknownKey code if it doesn't have the key.
+func NewExec(command string, args []string, opts ...ExecOption) Keyring {
+	options := execOptions{
+		timeout:  10 * time.Second,
+		cacheTTL: 5 * time.Minute,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &execKeyring{
+		command: command,
+		args:    args,
+		options: options,
+		cache:   make(map[string]cachedKey),
+	}
+}
+
+func (e *execKeyring) Key(ctx context.Context, id string) (string, error) {
+	if !execKeyID.MatchString(id) {
+		return "", fmt.Errorf("%w %q: key id has invalid characters", ErrUnknownKey, id)
+	}
+	e.mu.Lock()
+	c, ok := e.cache[id]
+	e.mu.Unlock()
+	if ok && e.options.now().Before(c.expires) {
+		return c.key, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.options.timeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.command, append(append([]string{}, e.args...), "--", id)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == ExitUnknownKey {
+			return "", fmt.Errorf("%w %q", ErrUnknownKey, id)
+		}
+		return "", fmt.Errorf("keyring: key plugin failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	key := strings.TrimRight(stdout.String(), "\r\n")
+	if key == "" {
+		return "", fmt.Errorf("keyring: key plugin returned empty key %q", id)
+	}
+	if e.options.cacheTTL > 0 {
+		e.mu.Lock()
+		e.cache[id] = cachedKey{key: key, expires: e.options.now().Add(e.options.cacheTTL)}
+		e.mu.Unlock()
+	}
+	return key, nil
+}
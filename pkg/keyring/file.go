@@ -0,0 +1,40 @@
+package keyring
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadFile reads keys from the file.
+// File has "id key" pair per line, empty lines and lines started with "#" are skipped.
+// Key is the rest of the line, so it can contain spaces.
+func LoadFile(path string) (Static, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: cannot open key file: %w", err)
+	}
+	defer f.Close()
+
+	keys := make(Static)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 || strings.TrimSpace(fields[1]) == "" {
+			return nil, fmt.Errorf("keyring: key file line %d: expected \"id key\"", n)
+		}
+		if _, ok := keys[fields[0]]; ok {
+			return nil, fmt.Errorf("keyring: key file line %d: duplicated id %q", n, fields[0])
+		}
+		keys[fields[0]] = strings.TrimSpace(fields[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("keyring: cannot read key file: %w", err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,52 @@
+/*
+Package keyring provides cipher keys held by the server, so clients can reference them by id
+instead of sending passphrases with every request.
+Keys can be loaded from a key file, from environment variables or requested from external command.
+*/
+package keyring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownKey is returned if keyring doesn't have key with the id.
+var ErrUnknownKey = errors.New("unknown key id")
+
+// Keyring provides cipher keys by id.
+type Keyring interface {
+	// Key returns cipher key by id.
+	// Returns ErrUnknownKey if there is no such key.
+	Key(ctx context.Context, id string) (string, error)
+}
+
+// Static is the keyring with keys by id.
+type Static map[string]string
+
+// Key returns cipher key by id.
+func (s Static) Key(ctx context.Context, id string) (string, error) {
+	key, ok := s[id]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	return key, nil
+}
+
+type chain []Keyring
+
+// Chain returns keyring that looks for the key in keyrings in order.
+func Chain(keyrings ...Keyring) Keyring {
+	return chain(keyrings)
+}
+
+func (c chain) Key(ctx context.Context, id string) (string, error) {
+	for _, kr := range c {
+		key, err := kr.Key(ctx, id)
+		if errors.Is(err, ErrUnknownKey) {
+			continue
+		}
+		return key, err
+	}
+	return "", fmt.Errorf("%w %q", ErrUnknownKey, id)
+}
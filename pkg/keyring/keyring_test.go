@@ -0,0 +1,122 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain(t *testing.T) {
+	kr := Chain(Static{"a": "key-a"}, Static{"a": "other-key-a", "b": "key-b"})
+
+	key, err := kr.Key(context.Background(), "a")
+	require.NoError(t, err)
+	require.EqualValues(t, "key-a", key)
+
+	key, err = kr.Key(context.Background(), "b")
+	require.NoError(t, err)
+	require.EqualValues(t, "key-b", key)
+
+	_, err = kr.Key(context.Background(), "c")
+	require.True(t, errors.Is(err, ErrUnknownKey))
+	require.EqualError(t, err, `unknown key id "c"`)
+}
+
+func TestLoadFile(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "keys")
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+	t.Run("success", func(t *testing.T) {
+		keys, err := LoadFile(write(t, "# keys\npayments key with spaces\n\nbilling 1234\n"))
+		require.NoError(t, err)
+		require.EqualValues(t, Static{"payments": "key with spaces", "billing": "1234"}, keys)
+	})
+	t.Run("error when key is empty", func(t *testing.T) {
+		_, err := LoadFile(write(t, "payments\n"))
+		require.EqualError(t, err, `keyring: key file line 1: expected "id key"`)
+	})
+	t.Run("error when id is duplicated", func(t *testing.T) {
+		_, err := LoadFile(write(t, "payments 1\npayments 2\n"))
+		require.EqualError(t, err, `keyring: key file line 2: duplicated id "payments"`)
+	})
+	t.Run("error when file doesn't exist", func(t *testing.T) {
+		_, err := LoadFile("not-existing-file")
+		require.Error(t, err)
+	})
+}
+
+func TestFromEnv(t *testing.T) {
+	require.NoError(t, os.Setenv("GO_SECRET_TEST_KEY_PAYMENTS", "key-1"))
+	require.NoError(t, os.Setenv("GO_SECRET_TEST_KEY_", "key-2"))
+	defer func() {
+		require.NoError(t, os.Unsetenv("GO_SECRET_TEST_KEY_PAYMENTS"))
+		require.NoError(t, os.Unsetenv("GO_SECRET_TEST_KEY_"))
+	}()
+
+	require.EqualValues(t, Static{"payments": "key-1"}, FromEnv("GO_SECRET_TEST_KEY_"))
+}
+
+func TestNewExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test plugin is shell script")
+	}
+	calls := filepath.Join(t.TempDir(), "calls")
+	script := `echo call >> ` + calls + `; case "$1" in payments) echo key-of-$1;; empty) ;; fail) echo broken >&2; exit 1;; *) exit 2;; esac`
+
+	t.Run("success", func(t *testing.T) {
+		kr := NewExec("sh", []string{"-c", script})
+		key, err := kr.Key(context.Background(), "payments")
+		require.NoError(t, err)
+		require.EqualValues(t, "key-of-payments", key)
+
+		// key is cached
+		key, err = kr.Key(context.Background(), "payments")
+		require.NoError(t, err)
+		require.EqualValues(t, "key-of-payments", key)
+		data, err := ioutil.ReadFile(calls)
+		require.NoError(t, err)
+		require.EqualValues(t, "call\n", string(data))
+	})
+	t.Run("error when key is unknown", func(t *testing.T) {
+		_, err := NewExec("sh", []string{"-c", script}).Key(context.Background(), "billing")
+		require.True(t, errors.Is(err, ErrUnknownKey))
+	})
+	t.Run("error when plugin fails", func(t *testing.T) {
+		_, err := NewExec("sh", []string{"-c", script}).Key(context.Background(), "fail")
+		require.Error(t, err)
+		require.False(t, errors.Is(err, ErrUnknownKey))
+		require.Contains(t, err.Error(), "broken")
+
+		_, err = NewExec("sh", []string{"-c", script}).Key(context.Background(), "empty")
+		require.EqualError(t, err, `keyring: key plugin returned empty key "empty"`)
+	})
+	t.Run("error when key id looks like option", func(t *testing.T) {
+		args := filepath.Join(t.TempDir(), "args")
+		kr := NewExec("sh", []string{"-c", `echo "$0" "$@" > ` + args + `; echo key`})
+		for _, id := range []string{"--config=/etc/shadow", "-v", "a b", "../key", "key;id", ""} {
+			_, err := kr.Key(context.Background(), id)
+			require.True(t, errors.Is(err, ErrUnknownKey), id)
+		}
+		_, err := os.Stat(args)
+		require.True(t, os.IsNotExist(err), "plugin shouldn't be run with invalid key id")
+
+		_, err = kr.Key(context.Background(), "team-a.key_1")
+		require.NoError(t, err)
+		data, err := ioutil.ReadFile(args)
+		require.NoError(t, err)
+		require.EqualValues(t, "-- team-a.key_1\n", string(data))
+	})
+	t.Run("error when plugin is too slow", func(t *testing.T) {
+		_, err := NewExec("sleep", nil, ExecTimeout(10*time.Millisecond)).Key(context.Background(), "1")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// GetDataBatchCtx returns decrypted values by keys in the same order.
+// Values are read at once if data saver supports it, otherwise they are read one by one.
+func (p *provider) GetDataBatchCtx(ctx context.Context, keys [][]byte) ([]secret.Result, error) {
+	results := make([]secret.Result, len(keys))
+	bs, ok := p.dataSaver.(secret.BatchDataSaver)
+	if !ok {
+		for i, key := range keys {
+			results[i].Value, results[i].Err = p.GetDataCtx(ctx, key)
+		}
+		return results, nil
+	}
+
+	encodedKeys := make([][]byte, 0, len(keys))
+	indexes := make([]int, 0, len(keys))
+	for i, key := range keys {
+		if len(key) == 0 {
+			results[i].Err = fmt.Errorf("provider, GetDataBatch method: %w", secret.ErrEmptyKey)
+			continue
+		}
+		encodedKey, err := p.cryptographer.EncodeKey(key)
+		if err != nil {
+			results[i].Err = fmt.Errorf("provider, GetDataBatch method: encode key error: %w", err)
+			continue
+		}
+		encodedKeys = append(encodedKeys, encodedKey)
+		indexes = append(indexes, i)
+	}
+	if len(encodedKeys) == 0 {
+		return results, nil
+	}
+	data, err := bs.ReadDataBatchCtx(ctx, encodedKeys)
+	if err != nil {
+		return nil, fmt.Errorf("provider, GetDataBatch method: read data error: %w", err)
+	}
+	for j, i := range indexes {
+		if data[j] == nil {
+			results[i].Err = fmt.Errorf("provider, GetDataBatch method: read data error: %w", secret.ErrNotFound)
+			continue
+		}
+		if results[i].Value, err = p.cryptographer.Decode(data[j]); err != nil {
+			results[i].Err = fmt.Errorf("provider, GetDataBatch method: decode error: %w", err)
+		}
+	}
+	return results, nil
+}
+
+// SetDataBatchCtx encrypts values and sets them by keys.
+// Values are set at once if data saver supports it, otherwise they are set one by one until the first error.
+func (p *provider) SetDataBatchCtx(ctx context.Context, entries []secret.Entry) error {
+	bs, ok := p.dataSaver.(secret.BatchDataSaver)
+	if !ok {
+		for _, e := range entries {
+			if err := p.SetDataCtx(ctx, e.Key, e.Value); err != nil {
+				return fmt.Errorf("provider, SetDataBatch method: %w", err)
+			}
+		}
+		return nil
+	}
+
+	encoded := make([]secret.Entry, 0, len(entries))
+	for _, e := range entries {
+		if len(e.Key) == 0 {
+			return fmt.Errorf("provider, SetDataBatch method: %w", secret.ErrEmptyKey)
+		}
+		encodedValue, err := p.cryptographer.Encode(e.Value)
+		if err != nil {
+			return fmt.Errorf("provider, SetDataBatch method: encode value error: %w", err)
+		}
+		encodedKey, err := p.cryptographer.EncodeKey(e.Key)
+		if err != nil {
+			return fmt.Errorf("provider, SetDataBatch method: encode key error: %w", err)
+		}
+		encoded = append(encoded, secret.Entry{Key: encodedKey, Value: encodedValue})
+	}
+	if err := bs.SaveDataBatchCtx(ctx, encoded); err != nil {
+		return fmt.Errorf("provider, SetDataBatch method: save error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// batchDataSaver counts batch calls of data saver.
+type batchDataSaver struct {
+	*mapDataSaver
+	batches int
+}
+
+func (b *batchDataSaver) ReadDataBatchCtx(_ context.Context, keys [][]byte) ([][]byte, error) {
+	b.batches++
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = b.data[string(key)]
+	}
+	return values, nil
+}
+
+func (b *batchDataSaver) SaveDataBatchCtx(_ context.Context, entries []secret.Entry) error {
+	b.batches++
+	for _, e := range entries {
+		b.data[string(e.Key)] = e.Value
+	}
+	return nil
+}
+
+func TestProvider_Batch(t *testing.T) {
+	ctx := context.Background()
+	cr := crypto.NewCryptographer([]byte("key"), rand.Reader)
+	entries := []secret.Entry{
+		{Key: []byte("first"), Value: []byte("value 1")},
+		{Key: []byte("second"), Value: []byte("value 2")},
+	}
+	keys := [][]byte{[]byte("second"), []byte("missing"), []byte("first")}
+
+	t.Run("success", func(t *testing.T) {
+		ds := &batchDataSaver{mapDataSaver: newMapDataSaver()}
+		p := NewProvider(cr, ds)
+		require.NoError(t, p.SetDataBatchCtx(ctx, entries))
+
+		results, err := p.GetDataBatchCtx(ctx, keys)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, ds.batches)
+		require.Len(t, results, 3)
+		require.EqualValues(t, "value 2", string(results[0].Value))
+		require.True(t, errors.Is(results[1].Err, secret.ErrNotFound))
+		require.EqualValues(t, "value 1", string(results[2].Value))
+
+		// value of another cipher key can't be decrypted
+		results, err = NewProvider(crypto.NewCryptographer([]byte("other key"), rand.Reader), ds).GetDataBatchCtx(ctx, [][]byte{[]byte("first")})
+		require.NoError(t, err)
+		require.Error(t, results[0].Err)
+	})
+	t.Run("data saver without batches", func(t *testing.T) {
+		p := NewProvider(cr, newMapDataSaver())
+		require.NoError(t, p.SetDataBatchCtx(ctx, entries))
+
+		results, err := p.GetDataBatchCtx(ctx, keys)
+		require.NoError(t, err)
+		require.EqualValues(t, "value 2", string(results[0].Value))
+		require.Error(t, results[1].Err)
+		require.EqualValues(t, "value 1", string(results[2].Value))
+	})
+	t.Run("error if key is empty", func(t *testing.T) {
+		ds := &batchDataSaver{mapDataSaver: newMapDataSaver()}
+		p := NewProvider(cr, ds)
+		err := p.SetDataBatchCtx(ctx, []secret.Entry{{Key: nil, Value: []byte("value")}})
+		require.True(t, errors.Is(err, secret.ErrEmptyKey))
+
+		results, err := p.GetDataBatchCtx(ctx, [][]byte{nil})
+		require.NoError(t, err)
+		require.True(t, errors.Is(results[0].Err, secret.ErrEmptyKey))
+		require.EqualValues(t, 0, ds.batches)
+	})
+}
@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// SetDataWithExpiryCtx is SetDataCtx for value, which expires at the time.
+// Value never expires if the time is zero.
+// Returns secret.ErrExpiryNotSupported if data saver can't expire values.
+func (p *provider) SetDataWithExpiryCtx(ctx context.Context, key, value []byte, expiresAt time.Time) error {
+	if expiresAt.IsZero() {
+		return p.SetDataCtx(ctx, key, value)
+	}
+	if len(key) == 0 {
+		return fmt.Errorf("provider, SetDataWithExpiry method: %w", secret.ErrEmptyKey)
+	}
+	es, ok := p.dataSaver.(secret.ExpiringDataSaver)
+	if !ok {
+		return fmt.Errorf("provider, SetDataWithExpiry method: %w", secret.ErrExpiryNotSupported)
+	}
+	encodedValue, err := p.cryptographer.Encode(value)
+	if err != nil {
+		return fmt.Errorf("provider, SetDataWithExpiry method: encode value error: %w", err)
+	}
+	encodedKey, err := p.cryptographer.EncodeKey(key)
+	if err != nil {
+		return fmt.Errorf("provider, SetDataWithExpiry method: encode key error: %w", err)
+	}
+	if err := es.SaveDataWithExpiryCtx(ctx, encodedKey, encodedValue, expiresAt); err != nil {
+		return fmt.Errorf("provider, SetDataWithExpiry method: save error: %w", err)
+	}
+	return nil
+}
+
+// expiry returns expiration time of the value by encoded key.
+// Zero time is returned if data saver can't expire values.
+func (p *provider) expiry(ctx context.Context, encodedKey []byte) (time.Time, error) {
+	es, ok := p.dataSaver.(secret.ExpiringDataSaver)
+	if !ok {
+		return time.Time{}, nil
+	}
+	return es.ExpiryCtx(ctx, encodedKey)
+}
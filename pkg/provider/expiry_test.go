@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// expiringDataSaver keeps expiration times of values in memory.
+type expiringDataSaver struct {
+	*mapDataSaver
+	expiry map[string]time.Time
+}
+
+func newExpiringDataSaver() *expiringDataSaver {
+	return &expiringDataSaver{mapDataSaver: newMapDataSaver(), expiry: map[string]time.Time{}}
+}
+
+func (e *expiringDataSaver) SaveData(key, encodedValue []byte) error {
+	return e.SaveDataWithExpiryCtx(context.Background(), key, encodedValue, time.Time{})
+}
+
+func (e *expiringDataSaver) SaveDataWithExpiryCtx(_ context.Context, key, encodedValue []byte, expiresAt time.Time) error {
+	if err := e.mapDataSaver.SaveData(key, encodedValue); err != nil {
+		return err
+	}
+	e.expiry[string(key)] = expiresAt
+	return nil
+}
+
+func (e *expiringDataSaver) ExpiryCtx(_ context.Context, key []byte) (time.Time, error) {
+	if _, ok := e.data[string(key)]; !ok {
+		return time.Time{}, secret.ErrNotFound
+	}
+	return e.expiry[string(key)], nil
+}
+
+func TestProvider_SetDataWithExpiry(t *testing.T) {
+	ctx := context.Background()
+	cr := crypto.NewCryptographer([]byte("key"), rand.Reader)
+	expiresAt := time.Now().Add(time.Hour)
+
+	t.Run("success", func(t *testing.T) {
+		ds := newExpiringDataSaver()
+		p := NewProvider(cr, ds)
+		require.NoError(t, p.SetDataWithExpiryCtx(ctx, []byte("key"), []byte("value"), expiresAt))
+
+		got, err := p.GetData([]byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value", string(got))
+		encodedKey, err := cr.EncodeKey([]byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, expiresAt, ds.expiry[string(encodedKey)])
+	})
+	t.Run("error if data saver can't expire values", func(t *testing.T) {
+		p := NewProvider(cr, newMapDataSaver())
+		err := p.SetDataWithExpiryCtx(ctx, []byte("key"), []byte("value"), expiresAt)
+		require.True(t, errors.Is(err, secret.ErrExpiryNotSupported))
+
+		require.NoError(t, p.SetDataWithExpiryCtx(ctx, []byte("key"), []byte("value"), time.Time{}))
+	})
+	t.Run("rotation keeps expiration time", func(t *testing.T) {
+		ds := newExpiringDataSaver()
+		require.NoError(t, NewProvider(cr, ds).SetDataWithExpiryCtx(ctx, []byte("key"), []byte("value"), expiresAt))
+
+		newCr := crypto.NewCryptographer([]byte("new key"), rand.Reader)
+		n, err := NewProvider(cr, ds).Rotate(newCr)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, n)
+		encodedKey, err := newCr.EncodeKey([]byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, expiresAt, ds.expiry[string(encodedKey)])
+	})
+}
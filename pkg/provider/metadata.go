@@ -0,0 +1,200 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// metadataDocument is metadata encoded by provider.
+// Creation and update times aren't encoded, because they are kept by data saver.
+type metadataDocument struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Owner       string            `json:"owner,omitempty"`
+}
+
+// SetMetadataCtx replaces labels, description and owner of the value by key.
+// Metadata is encrypted with EncryptMetadata option.
+// Returns secret.ErrMetadataNotSupported if data saver doesn't keep metadata.
+func (p *provider) SetMetadataCtx(ctx context.Context, key []byte, md secret.Metadata) error {
+	ms, encodedKey, err := p.metadataSaver(key)
+	if err != nil {
+		return fmt.Errorf("provider, SetMetadata method: %w", err)
+	}
+	data, err := p.encodeMetadata(md)
+	if err != nil {
+		return fmt.Errorf("provider, SetMetadata method: %w", err)
+	}
+	if err := ms.SaveMetadataCtx(ctx, encodedKey, data); err != nil {
+		return fmt.Errorf("provider, SetMetadata method: save error: %w", err)
+	}
+	return nil
+}
+
+// SetDataWithMetadataCtx sets value, which expires at the time, with labels, description and owner at once,
+// only if value by key has the revision. Value never expires if the time is zero.
+// Returns secret.ErrMetadataNotSupported if data saver can't save them at once.
+func (p *provider) SetDataWithMetadataCtx(ctx context.Context, key, value []byte, md secret.Metadata, expiresAt time.Time, revision int) (int, error) {
+	if len(key) == 0 {
+		return 0, fmt.Errorf("provider, SetDataWithMetadata method: %w", secret.ErrEmptyKey)
+	}
+	ms, ok := p.dataSaver.(secret.MetadataValueDataSaver)
+	if !ok {
+		return 0, fmt.Errorf("provider, SetDataWithMetadata method: %w", secret.ErrMetadataNotSupported)
+	}
+	encodedKey, err := p.cryptographer.EncodeKey(key)
+	if err != nil {
+		return 0, fmt.Errorf("provider, SetDataWithMetadata method: encode key error: %w", err)
+	}
+	encodedValue, err := p.cryptographer.Encode(value)
+	if err != nil {
+		return 0, fmt.Errorf("provider, SetDataWithMetadata method: encode value error: %w", err)
+	}
+	data, err := p.encodeMetadata(md)
+	if err != nil {
+		return 0, fmt.Errorf("provider, SetDataWithMetadata method: %w", err)
+	}
+	saved, err := ms.SaveDataWithMetadataCtx(ctx, encodedKey, encodedValue, data, expiresAt, revision)
+	if err != nil {
+		return 0, fmt.Errorf("provider, SetDataWithMetadata method: save error: %w", err)
+	}
+	return saved, nil
+}
+
+// GetMetadataCtx returns metadata of the value by key.
+func (p *provider) GetMetadataCtx(ctx context.Context, key []byte) (secret.Metadata, error) {
+	ms, encodedKey, err := p.metadataSaver(key)
+	if err != nil {
+		return secret.Metadata{}, fmt.Errorf("provider, GetMetadata method: %w", err)
+	}
+	record, err := ms.ReadMetadataCtx(ctx, encodedKey)
+	if err != nil {
+		return secret.Metadata{}, fmt.Errorf("provider, GetMetadata method: read error: %w", p.notFound(ctx, err))
+	}
+	md, err := p.decodeMetadata(record.Data)
+	if err != nil {
+		return secret.Metadata{}, fmt.Errorf("provider, GetMetadata method: %w", err)
+	}
+	md.CreatedAt = record.CreatedAt
+	md.UpdatedAt = record.UpdatedAt
+	return md, nil
+}
+
+// ListKeysByLabelsCtx returns keys, which metadata matches all the labels.
+// All keys are returned if there are no labels.
+func (p *provider) ListKeysByLabelsCtx(ctx context.Context, labels map[string]string) ([][]byte, error) {
+	keys, err := p.ListKeysCtx(ctx)
+	if err != nil || len(labels) == 0 {
+		return keys, err
+	}
+	if _, ok := p.dataSaver.(secret.MetadataDataSaver); !ok {
+		return nil, fmt.Errorf("provider, ListKeysByLabels method: %w", secret.ErrMetadataNotSupported)
+	}
+	matched := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		md, err := p.GetMetadataCtx(ctx, key)
+		if errors.Is(err, secret.ErrNotFound) {
+			// key was deleted or expired after listing
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("provider, ListKeysByLabels method: %w", err)
+		}
+		if md.MatchLabels(labels) {
+			matched = append(matched, key)
+		}
+	}
+	return matched, nil
+}
+
+// metadataSaver returns the data saver with metadata and encoded key.
+func (p *provider) metadataSaver(key []byte) (secret.MetadataDataSaver, []byte, error) {
+	if len(key) == 0 {
+		return nil, nil, secret.ErrEmptyKey
+	}
+	ms, ok := p.dataSaver.(secret.MetadataDataSaver)
+	if !ok {
+		return nil, nil, secret.ErrMetadataNotSupported
+	}
+	encodedKey, err := p.cryptographer.EncodeKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode key error: %w", err)
+	}
+	return ms, encodedKey, nil
+}
+
+// encodeMetadata encodes metadata to JSON, which is encrypted with EncryptMetadata option.
+func (p *provider) encodeMetadata(md secret.Metadata) ([]byte, error) {
+	data, err := json.Marshal(metadataDocument{Labels: md.Labels, Description: md.Description, Owner: md.Owner})
+	if err != nil {
+		return nil, fmt.Errorf("can't encode metadata: %w", err)
+	}
+	if !p.encryptMetadata {
+		return data, nil
+	}
+	if data, err = p.cryptographer.Encode(data); err != nil {
+		return nil, fmt.Errorf("encrypt metadata error: %w", err)
+	}
+	return data, nil
+}
+
+// decodeMetadata decodes metadata saved by encodeMetadata.
+// Plain metadata is JSON object, everything else is decrypted first.
+func (p *provider) decodeMetadata(data []byte) (secret.Metadata, error) {
+	if len(data) == 0 {
+		return secret.Metadata{}, nil
+	}
+	if !isPlainMetadata(data) {
+		var err error
+		if data, err = p.cryptographer.Decode(data); err != nil {
+			return secret.Metadata{}, fmt.Errorf("decrypt metadata error: %w", err)
+		}
+	}
+	var doc metadataDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return secret.Metadata{}, fmt.Errorf("can't decode metadata: %w", err)
+	}
+	return secret.Metadata{Labels: doc.Labels, Description: doc.Description, Owner: doc.Owner}, nil
+}
+
+func isPlainMetadata(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("{"))
+}
+
+// copyMetadata copies metadata of the value by encoded key to the value of target provider.
+// Encrypted metadata is encrypted again with the cipher key of target provider.
+func (p *provider) copyMetadata(ctx context.Context, target *provider, encodedKey, targetKey []byte) error {
+	ms, ok := p.dataSaver.(secret.MetadataDataSaver)
+	if !ok {
+		return nil
+	}
+	record, err := ms.ReadMetadataCtx(ctx, encodedKey)
+	if err != nil {
+		return fmt.Errorf("read metadata error: %w", err)
+	}
+	data := record.Data
+	if len(data) == 0 {
+		return nil
+	}
+	if !isPlainMetadata(data) {
+		md, err := p.decodeMetadata(data)
+		if err != nil {
+			return err
+		}
+		encrypted := *target
+		encrypted.encryptMetadata = true
+		if data, err = encrypted.encodeMetadata(md); err != nil {
+			return err
+		}
+	}
+	if err := ms.SaveMetadataCtx(ctx, targetKey, data); err != nil {
+		return fmt.Errorf("save metadata error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// metadataDataSaver keeps metadata of values in memory.
+type metadataDataSaver struct {
+	*mapDataSaver
+	metadata map[string]secret.MetadataRecord
+}
+
+func newMetadataDataSaver() *metadataDataSaver {
+	return &metadataDataSaver{mapDataSaver: newMapDataSaver(), metadata: map[string]secret.MetadataRecord{}}
+}
+
+func (m *metadataDataSaver) DeleteData(key []byte) error {
+	if err := m.mapDataSaver.DeleteData(key); err != nil {
+		return err
+	}
+	delete(m.metadata, string(key))
+	return nil
+}
+
+func (m *metadataDataSaver) SaveMetadataCtx(_ context.Context, key, encodedMetadata []byte) error {
+	if _, ok := m.data[string(key)]; !ok {
+		return secret.ErrNotFound
+	}
+	m.metadata[string(key)] = secret.MetadataRecord{Data: encodedMetadata, UpdatedAt: time.Now()}
+	return nil
+}
+
+func (m *metadataDataSaver) SaveDataWithMetadataCtx(_ context.Context, key, encodedValue, encodedMetadata []byte, _ time.Time, _ int) (int, error) {
+	if err := m.fail("SaveDataWithMetadata", key); err != nil {
+		return 0, err
+	}
+	m.data[string(key)] = encodedValue
+	m.metadata[string(key)] = secret.MetadataRecord{Data: encodedMetadata, UpdatedAt: time.Now()}
+	return 0, nil
+}
+
+func (m *metadataDataSaver) ReadMetadataCtx(_ context.Context, key []byte) (secret.MetadataRecord, error) {
+	if _, ok := m.data[string(key)]; !ok {
+		return secret.MetadataRecord{}, secret.ErrNotFound
+	}
+	return m.metadata[string(key)], nil
+}
+
+func TestProvider_Metadata(t *testing.T) {
+	ctx := context.Background()
+	cr := crypto.NewCryptographer([]byte("key"), rand.Reader)
+	md := secret.Metadata{Labels: map[string]string{"env": "prod"}, Description: "database password", Owner: "team-a"}
+
+	t.Run("success", func(t *testing.T) {
+		ds := newMetadataDataSaver()
+		p := NewProvider(cr, ds)
+		require.NoError(t, p.SetData([]byte("key"), []byte("value")))
+		require.NoError(t, p.SetMetadataCtx(ctx, []byte("key"), md))
+
+		got, err := p.GetMetadataCtx(ctx, []byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, md.Labels, got.Labels)
+		require.EqualValues(t, md.Description, got.Description)
+		require.EqualValues(t, md.Owner, got.Owner)
+		require.False(t, got.UpdatedAt.IsZero())
+		encodedKey, err := cr.EncodeKey([]byte("key"))
+		require.NoError(t, err)
+		require.Contains(t, string(ds.metadata[string(encodedKey)].Data), "team-a")
+	})
+	t.Run("encrypted metadata", func(t *testing.T) {
+		ds := newMetadataDataSaver()
+		p := NewProvider(cr, ds, EncryptMetadata())
+		require.NoError(t, p.SetData([]byte("key"), []byte("value")))
+		require.NoError(t, p.SetMetadataCtx(ctx, []byte("key"), md))
+
+		encodedKey, err := cr.EncodeKey([]byte("key"))
+		require.NoError(t, err)
+		require.NotContains(t, string(ds.metadata[string(encodedKey)].Data), "team-a")
+		// provider without the option reads encrypted metadata as well
+		got, err := NewProvider(cr, ds).GetMetadataCtx(ctx, []byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, md.Owner, got.Owner)
+	})
+	t.Run("list keys by labels", func(t *testing.T) {
+		ds := newMetadataDataSaver()
+		p := NewProvider(cr, ds)
+		require.NoError(t, p.SetData([]byte("prod"), []byte("value")))
+		require.NoError(t, p.SetMetadataCtx(ctx, []byte("prod"), md))
+		require.NoError(t, p.SetData([]byte("dev"), []byte("value")))
+		require.NoError(t, p.SetMetadataCtx(ctx, []byte("dev"), secret.Metadata{Labels: map[string]string{"env": "dev"}}))
+		require.NoError(t, p.SetData([]byte("unlabeled"), []byte("value")))
+
+		keys, err := p.ListKeysByLabelsCtx(ctx, map[string]string{"env": "prod"})
+		require.NoError(t, err)
+		require.ElementsMatch(t, [][]byte{[]byte("prod")}, keys)
+		keys, err = p.ListKeysByLabelsCtx(ctx, map[string]string{"env": ""})
+		require.NoError(t, err)
+		require.ElementsMatch(t, [][]byte{[]byte("prod"), []byte("dev")}, keys)
+		keys, err = p.ListKeysByLabelsCtx(ctx, nil)
+		require.NoError(t, err)
+		require.Len(t, keys, 3)
+	})
+	t.Run("rotation keeps metadata", func(t *testing.T) {
+		ds := newMetadataDataSaver()
+		require.NoError(t, NewProvider(cr, ds).SetData([]byte("plain"), []byte("value")))
+		require.NoError(t, NewProvider(cr, ds).SetMetadataCtx(ctx, []byte("plain"), md))
+		require.NoError(t, NewProvider(cr, ds).SetData([]byte("encrypted"), []byte("value")))
+		require.NoError(t, NewProvider(cr, ds, EncryptMetadata()).SetMetadataCtx(ctx, []byte("encrypted"), md))
+
+		newCr := crypto.NewCryptographer([]byte("new key"), rand.Reader)
+		n, err := NewProvider(cr, ds).Rotate(newCr)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, n)
+		for _, key := range []string{"plain", "encrypted"} {
+			got, err := NewProvider(newCr, ds).GetMetadataCtx(ctx, []byte(key))
+			require.NoError(t, err)
+			require.EqualValues(t, md.Owner, got.Owner)
+		}
+		encodedKey, err := newCr.EncodeKey([]byte("encrypted"))
+		require.NoError(t, err)
+		require.NotContains(t, string(ds.metadata[string(encodedKey)].Data), "team-a")
+	})
+	t.Run("set data with metadata", func(t *testing.T) {
+		ds := newMetadataDataSaver()
+		p := NewProvider(cr, ds)
+		_, err := p.SetDataWithMetadataCtx(ctx, []byte("key"), []byte("value"), md, time.Time{}, secret.AnyRevision)
+		require.NoError(t, err)
+
+		value, err := p.GetData([]byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value", value)
+		got, err := p.GetMetadataCtx(ctx, []byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, md.Owner, got.Owner)
+
+		ds.failOn = func(method string, key []byte) bool {
+			return method == "SaveDataWithMetadata"
+		}
+		_, err = p.SetDataWithMetadataCtx(ctx, []byte("key"), []byte("new value"), secret.Metadata{Owner: "team-b"}, time.Time{}, secret.AnyRevision)
+		require.EqualError(t, err, "provider, SetDataWithMetadata method: save error: crash")
+		value, err = p.GetData([]byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value", value)
+	})
+	t.Run("error if data saver doesn't keep metadata", func(t *testing.T) {
+		p := NewProvider(cr, newMapDataSaver())
+		require.NoError(t, p.SetData([]byte("key"), []byte("value")))
+		err := p.SetMetadataCtx(ctx, []byte("key"), md)
+		require.True(t, errors.Is(err, secret.ErrMetadataNotSupported))
+		_, err = p.SetDataWithMetadataCtx(ctx, []byte("key"), []byte("new value"), md, time.Time{}, secret.AnyRevision)
+		require.True(t, errors.Is(err, secret.ErrMetadataNotSupported))
+		_, err = p.GetMetadataCtx(ctx, []byte("key"))
+		require.True(t, errors.Is(err, secret.ErrMetadataNotSupported))
+		_, err = p.ListKeysByLabelsCtx(ctx, map[string]string{"env": "prod"})
+		require.True(t, errors.Is(err, secret.ErrMetadataNotSupported))
+	})
+}
@@ -32,6 +32,29 @@ func (_m *MockCryptographer) Decode(encodedValue []byte) ([]byte, error) {
 	return r0, r1
 }
 
+// DecodeKey provides a mock function with given fields: encodedKey
+func (_m *MockCryptographer) DecodeKey(encodedKey []byte) ([]byte, error) {
+	ret := _m.Called(encodedKey)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func([]byte) []byte); ok {
+		r0 = rf(encodedKey)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]byte) error); ok {
+		r1 = rf(encodedKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Encode provides a mock function with given fields: value
 func (_m *MockCryptographer) Encode(value []byte) ([]byte, error) {
 	ret := _m.Called(value)
@@ -54,3 +77,26 @@ func (_m *MockCryptographer) Encode(value []byte) ([]byte, error) {
 
 	return r0, r1
 }
+
+// EncodeKey provides a mock function with given fields: key
+func (_m *MockCryptographer) EncodeKey(key []byte) ([]byte, error) {
+	ret := _m.Called(key)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func([]byte) []byte); ok {
+		r0 = rf(key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]byte) error); ok {
+		r1 = rf(key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
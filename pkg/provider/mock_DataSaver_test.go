@@ -9,6 +9,43 @@ type MockDataSaver struct {
 	mock.Mock
 }
 
+// DeleteData provides a mock function with given fields: key
+func (_m *MockDataSaver) DeleteData(key []byte) error {
+	ret := _m.Called(key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]byte) error); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListKeys provides a mock function with given fields:
+func (_m *MockDataSaver) ListKeys() ([][]byte, error) {
+	ret := _m.Called()
+
+	var r0 [][]byte
+	if rf, ok := ret.Get(0).(func() [][]byte); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([][]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ReadData provides a mock function with given fields: key
 func (_m *MockDataSaver) ReadData(key []byte) ([]byte, error) {
 	ret := _m.Called(key)
@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// errOtherPrefix is returned by prefixCryptographer for key names without its prefix.
+// Provider skips such keys while listing, the same way it skips keys of another cipher key.
+var errOtherPrefix = errors.New("key name has another prefix")
+
+// prefixCryptographer puts the prefix before key names, see KeyPrefix.
+// Values are encoded by the wrapped cryptographer as is.
+type prefixCryptographer struct {
+	secret.Cryptographer
+	prefix []byte
+}
+
+func (c *prefixCryptographer) EncodeKey(key []byte) ([]byte, error) {
+	prefixed := make([]byte, 0, len(c.prefix)+len(key))
+	prefixed = append(prefixed, c.prefix...)
+	return c.Cryptographer.EncodeKey(append(prefixed, key...))
+}
+
+func (c *prefixCryptographer) DecodeKey(encodedKey []byte) ([]byte, error) {
+	key, err := c.Cryptographer.DecodeKey(encodedKey)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(key, c.prefix) {
+		return nil, errOtherPrefix
+	}
+	return key[len(c.prefix):], nil
+}
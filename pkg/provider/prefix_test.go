@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+)
+
+func TestProvider_KeyPrefix(t *testing.T) {
+	cr := crypto.NewCryptographer([]byte("key"), rand.Reader)
+	ds := newMapDataSaver()
+	teamA := NewProvider(cr, ds, KeyPrefix("team-a/"))
+	teamB := NewProvider(cr, ds, KeyPrefix("team-b/"))
+	plain := NewProvider(cr, ds)
+
+	require.NoError(t, teamA.SetData([]byte("db"), []byte("value a")))
+	require.NoError(t, teamB.SetData([]byte("db"), []byte("value b")))
+
+	t.Run("keys are separated by prefix", func(t *testing.T) {
+		got, err := teamA.GetData([]byte("db"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value a", got)
+		got, err = teamB.GetData([]byte("db"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value b", got)
+	})
+	t.Run("keys are listed without prefix", func(t *testing.T) {
+		keys, err := teamA.ListKeys()
+		require.NoError(t, err)
+		require.EqualValues(t, [][]byte{[]byte("db")}, keys)
+	})
+	t.Run("prefix is part of the key without prefix option", func(t *testing.T) {
+		got, err := plain.GetData([]byte("team-b/db"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value b", got)
+		keys, err := plain.ListKeys()
+		require.NoError(t, err)
+		require.ElementsMatch(t, [][]byte{[]byte("team-a/db"), []byte("team-b/db")}, keys)
+	})
+	t.Run("deleted only in own namespace", func(t *testing.T) {
+		require.NoError(t, teamA.DeleteData([]byte("db")))
+		_, err := teamA.GetData([]byte("db"))
+		require.Error(t, err)
+		_, err = teamB.GetData([]byte("db"))
+		require.NoError(t, err)
+	})
+}
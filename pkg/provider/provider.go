@@ -2,44 +2,95 @@
 package provider
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 
 	"github.com/go-itools-internship/go-secret/pkg/secret"
 )
 
 type provider struct {
-	cryptographer secret.Cryptographer
-	dataSaver     secret.DataSaver
+	cryptographer   secret.Cryptographer
+	dataSaver       secret.DataSaver
+	encryptMetadata bool
+	keyPrefix       []byte
+	// verified is set when the cipher key is known to the data saver, see knownCipherKey
+	verified int32
 }
 
-func NewProvider(cryptographer secret.Cryptographer, dataSaver secret.DataSaver) *provider {
-	return &provider{cryptographer: cryptographer, dataSaver: dataSaver}
+// NewProvider creates provider for the data saver with the cryptographer.
+// Only EncryptMetadata and KeyPrefix options are used, other options are about key derivation, see Open.
+func NewProvider(cryptographer secret.Cryptographer, dataSaver secret.DataSaver, opts ...Option) *provider {
+	options := defaultOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if len(options.keyPrefix) > 0 {
+		cryptographer = &prefixCryptographer{Cryptographer: cryptographer, prefix: options.keyPrefix}
+	}
+	return &provider{cryptographer: cryptographer, dataSaver: dataSaver, encryptMetadata: options.encryptMetadata, keyPrefix: options.keyPrefix}
+}
+
+// options returns options of NewProvider to create provider with the same behavior for another cryptographer.
+func (p *provider) options() []Option {
+	opts := []Option{KeyPrefix(string(p.keyPrefix))}
+	if p.encryptMetadata {
+		opts = append(opts, EncryptMetadata())
+	}
+	return opts
 }
 
 func (p *provider) SetData(key, value []byte) error {
+	return p.SetDataCtx(context.Background(), key, value)
+}
+
+func (p *provider) GetData(key []byte) ([]byte, error) {
+	return p.GetDataCtx(context.Background(), key)
+}
+
+func (p *provider) DeleteData(key []byte) error {
+	return p.DeleteDataCtx(context.Background(), key)
+}
+
+// ListKeys returns decrypted names of all keys stored in the data saver.
+// Keys that can't be decrypted with provider cipher key are skipped,
+// because they belong to another cipher key.
+func (p *provider) ListKeys() ([][]byte, error) {
+	return p.ListKeysCtx(context.Background())
+}
+
+// SetDataCtx encrypts the value and the key name and saves them.
+func (p *provider) SetDataCtx(ctx context.Context, key, value []byte) error {
+	if len(key) == 0 {
+		return fmt.Errorf("provider, SetData method: %w", secret.ErrEmptyKey)
+	}
 	encodedValue, err := p.cryptographer.Encode(value)
 	if err != nil {
 		return fmt.Errorf("provider, SetData method: encode value error: %w", err)
 	}
-	encodedKey, err := p.cryptographer.Encode(key)
+	encodedKey, err := p.cryptographer.EncodeKey(key)
 	if err != nil {
 		return fmt.Errorf("provider, SetData method: encode key error: %w", err)
 	}
-	saveError := p.dataSaver.SaveData(encodedKey, encodedValue)
+	saveError := p.saverCtx().SaveDataCtx(ctx, encodedKey, encodedValue)
 	if saveError != nil {
 		return fmt.Errorf("provider, SetData method: save error: %w", saveError)
 	}
 	return nil
 }
 
-func (p *provider) GetData(key []byte) ([]byte, error) {
-	encodedKey, err := p.cryptographer.Encode(key)
+// GetDataCtx reads the value by encrypted key name and decrypts it.
+func (p *provider) GetDataCtx(ctx context.Context, key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("provider, GetData method: %w", secret.ErrEmptyKey)
+	}
+	encodedKey, err := p.cryptographer.EncodeKey(key)
 	if err != nil {
 		return nil, fmt.Errorf("provider, GetData method: encode key error: %w", err)
 	}
-	data, err := p.dataSaver.ReadData(encodedKey)
+	data, err := p.saverCtx().ReadDataCtx(ctx, encodedKey)
 	if err != nil {
-		return nil, fmt.Errorf("provider, GetData method: read data error: %w", err)
+		return nil, fmt.Errorf("provider, GetData method: read data error: %w", p.notFound(ctx, err))
 	}
 	decode, err := p.cryptographer.Decode(data)
 	if err != nil {
@@ -47,3 +98,92 @@ func (p *provider) GetData(key []byte) ([]byte, error) {
 	}
 	return decode, nil
 }
+
+// DeleteDataCtx removes the value by encrypted key name.
+func (p *provider) DeleteDataCtx(ctx context.Context, key []byte) error {
+	if len(key) == 0 {
+		return fmt.Errorf("provider, DeleteData method: %w", secret.ErrEmptyKey)
+	}
+	encodedKey, err := p.cryptographer.EncodeKey(key)
+	if err != nil {
+		return fmt.Errorf("provider, DeleteData method: encode key error: %w", err)
+	}
+	err = p.saverCtx().DeleteDataCtx(ctx, encodedKey)
+	if err != nil {
+		return fmt.Errorf("provider, DeleteData method: delete error: %w", p.notFound(ctx, err))
+	}
+	return nil
+}
+
+// ListKeysCtx decrypts names of all keys and skips those of other cipher keys.
+func (p *provider) ListKeysCtx(ctx context.Context) ([][]byte, error) {
+	encodedKeys, err := p.saverCtx().ListKeysCtx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("provider, ListKeys method: list keys error: %w", err)
+	}
+	keys := make([][]byte, 0, len(encodedKeys))
+	for _, encodedKey := range encodedKeys {
+		key, err := p.cryptographer.DecodeKey(encodedKey)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// saverCtx returns the data saver of provider with context support.
+func (p *provider) saverCtx() secret.DataSaverCtx {
+	return secret.DataSaverWithContext(p.dataSaver)
+}
+
+// Migrate re-encrypts entries saved by the previous formats, where key names and values
+// were encrypted with a nonce derived from the cipher key or weren't wrapped into the envelope.
+// Every legacy entry is saved again with the current format and the legacy entry is deleted.
+// Entries that already have the current format or belong to another cipher key are skipped,
+// so migration can be safely repeated if it was interrupted.
+// Returns number of migrated entries.
+func (p *provider) Migrate() (int, error) {
+	return p.MigrateCtx(context.Background())
+}
+
+// MigrateCtx returns number of entries migrated before ctx is done with the error.
+// They are kept migrated, so the next call continues with the rest.
+func (p *provider) MigrateCtx(ctx context.Context) (int, error) {
+	ds := p.saverCtx()
+	encodedKeys, err := ds.ListKeysCtx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("provider, Migrate method: list keys error: %w", err)
+	}
+	migrated := 0
+	for _, encodedKey := range encodedKeys {
+		key, err := p.cryptographer.DecodeKey(encodedKey)
+		if err == nil {
+			current, err := p.cryptographer.EncodeKey(key)
+			if err != nil {
+				return migrated, fmt.Errorf("provider, Migrate method: encode key error: %w", err)
+			}
+			if bytes.Equal(current, encodedKey) {
+				continue
+			}
+		} else if key, err = p.cryptographer.Decode(encodedKey); err != nil {
+			continue
+		}
+		data, err := ds.ReadDataCtx(ctx, encodedKey)
+		if err != nil {
+			return migrated, fmt.Errorf("provider, Migrate method: read data error: %w", err)
+		}
+		value, err := p.cryptographer.Decode(data)
+		if err != nil {
+			return migrated, fmt.Errorf("provider, Migrate method: decode error: %w", err)
+		}
+		if err := p.SetDataCtx(ctx, key, value); err != nil {
+			return migrated, fmt.Errorf("provider, Migrate method: %w", err)
+		}
+		if err := ds.DeleteDataCtx(ctx, encodedKey); err != nil {
+			return migrated, fmt.Errorf("provider, Migrate method: delete legacy data error: %w", err)
+		}
+		migrated++
+	}
+	return migrated, nil
+}
@@ -1,11 +1,13 @@
 package provider
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	_ "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -19,7 +21,7 @@ func TestProvider_SetData(t *testing.T) {
 		mockDs := new(MockDataSaver)
 
 		mockCr.On("Encode", value).Return(encodedValue, nil)
-		mockCr.On("Encode", key).Return(encodedKey, nil)
+		mockCr.On("EncodeKey", key).Return(encodedKey, nil)
 		mockDs.On("SaveData", encodedKey, encodedValue).Return(nil)
 
 		p := NewProvider(mockCr, mockDs)
@@ -56,7 +58,7 @@ func TestProvider_SetData(t *testing.T) {
 		mockDs := new(MockDataSaver)
 
 		mockCr.On("Encode", value).Return(encodedValue, nil)
-		mockCr.On("Encode", key).Return(encodedKey, nil)
+		mockCr.On("EncodeKey", key).Return(encodedKey, nil)
 		mockDs.On("SaveData", encodedKey, encodedValue).Return(fmt.Errorf("test"))
 
 		p := NewProvider(mockCr, mockDs)
@@ -78,7 +80,7 @@ func TestProvider_GetData(t *testing.T) {
 		mockDs := new(MockDataSaver)
 
 		mockDs.On("ReadData", key).Return(encodedValue, nil)
-		mockCr.On("Encode", key).Return(encodedKey, nil)
+		mockCr.On("EncodeKey", key).Return(encodedKey, nil)
 		mockCr.On("Decode", encodedValue).Return(value, nil)
 
 		p := NewProvider(mockCr, mockDs)
@@ -96,7 +98,7 @@ func TestProvider_GetData(t *testing.T) {
 		mockCr := new(MockCryptographer)
 		mockDs := new(MockDataSaver)
 
-		mockCr.On("Encode", encodedKey).Return(key, nil)
+		mockCr.On("EncodeKey", encodedKey).Return(key, nil)
 		mockDs.On("ReadData", key).Return(nil, fmt.Errorf("test"))
 
 		p := NewProvider(mockCr, mockDs)
@@ -115,7 +117,7 @@ func TestProvider_GetData(t *testing.T) {
 		mockCr := new(MockCryptographer)
 		mockDs := new(MockDataSaver)
 
-		mockCr.On("Encode", key).Return(encodedKey, nil)
+		mockCr.On("EncodeKey", key).Return(encodedKey, nil)
 		mockDs.On("ReadData", key).Return(encodedValue, nil)
 		mockCr.On("Decode", encodedValue).Return(value, fmt.Errorf("test"))
 
@@ -127,3 +129,187 @@ func TestProvider_GetData(t *testing.T) {
 		mockCr.AssertExpectations(t)
 	})
 }
+
+func TestProvider_DeleteData(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		key := []byte{1, 1, 1}
+		encodedKey := []byte{0, 1}
+		mockCr := new(MockCryptographer)
+		mockDs := new(MockDataSaver)
+
+		mockCr.On("EncodeKey", key).Return(encodedKey, nil)
+		mockDs.On("DeleteData", encodedKey).Return(nil)
+
+		p := NewProvider(mockCr, mockDs)
+		err := p.DeleteData(key)
+		require.NoError(t, err)
+
+		mockCr.AssertExpectations(t)
+		mockDs.AssertExpectations(t)
+	})
+
+	t.Run("delete data error", func(t *testing.T) {
+		key := []byte{1, 1, 1}
+		encodedKey := []byte{0, 1}
+		mockCr := new(MockCryptographer)
+		mockDs := new(MockDataSaver)
+
+		mockCr.On("EncodeKey", key).Return(encodedKey, nil)
+		mockDs.On("DeleteData", encodedKey).Return(fmt.Errorf("test"))
+
+		p := NewProvider(mockCr, mockDs)
+		err := p.DeleteData(key)
+		require.Error(t, err, "error assert failed")
+		require.EqualValues(t, "provider, DeleteData method: delete error: test", err.Error())
+
+		mockDs.AssertExpectations(t)
+	})
+}
+
+func TestProvider_ListKeys(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		encodedKeys := [][]byte{{0, 1}, {0, 2}}
+		mockCr := new(MockCryptographer)
+		mockDs := new(MockDataSaver)
+
+		mockDs.On("ListKeys").Return(encodedKeys, nil)
+		mockCr.On("DecodeKey", []byte{0, 1}).Return([]byte("first"), nil)
+		mockCr.On("DecodeKey", []byte{0, 2}).Return([]byte("second"), nil)
+
+		p := NewProvider(mockCr, mockDs)
+		keys, err := p.ListKeys()
+		require.NoError(t, err)
+		require.EqualValues(t, [][]byte{[]byte("first"), []byte("second")}, keys)
+
+		mockCr.AssertExpectations(t)
+		mockDs.AssertExpectations(t)
+	})
+
+	t.Run("skip keys encoded with another cipher key", func(t *testing.T) {
+		encodedKeys := [][]byte{{0, 1}, {0, 2}}
+		mockCr := new(MockCryptographer)
+		mockDs := new(MockDataSaver)
+
+		mockDs.On("ListKeys").Return(encodedKeys, nil)
+		mockCr.On("DecodeKey", []byte{0, 1}).Return(nil, fmt.Errorf("test"))
+		mockCr.On("DecodeKey", []byte{0, 2}).Return([]byte("second"), nil)
+
+		p := NewProvider(mockCr, mockDs)
+		keys, err := p.ListKeys()
+		require.NoError(t, err)
+		require.EqualValues(t, [][]byte{[]byte("second")}, keys)
+
+		mockCr.AssertExpectations(t)
+	})
+
+	t.Run("list keys error", func(t *testing.T) {
+		mockCr := new(MockCryptographer)
+		mockDs := new(MockDataSaver)
+
+		mockDs.On("ListKeys").Return(nil, fmt.Errorf("test"))
+
+		p := NewProvider(mockCr, mockDs)
+		keys, err := p.ListKeys()
+		require.Error(t, err, "error assert failed")
+		require.EqualValues(t, "provider, ListKeys method: list keys error: test", err.Error())
+		require.Empty(t, keys)
+
+		mockDs.AssertExpectations(t)
+	})
+}
+
+func TestProvider_Migrate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		currentKey := []byte{0, 1}
+		legacyKey := []byte{0, 2}
+		foreignKey := []byte{0, 3}
+		unwrappedKey := []byte{0, 5}
+		legacyValue := []byte{1, 2}
+		unwrappedValue := []byte{1, 4}
+		newKey := []byte{0, 4}
+		newValue := []byte{1, 3}
+		newUnwrappedKey := []byte{0, 6}
+		newUnwrappedValue := []byte{1, 5}
+		mockCr := new(MockCryptographer)
+		mockDs := new(MockDataSaver)
+
+		mockDs.On("ListKeys").Return([][]byte{currentKey, legacyKey, foreignKey, unwrappedKey}, nil)
+		mockCr.On("DecodeKey", currentKey).Return([]byte("current"), nil)
+		mockCr.On("EncodeKey", []byte("current")).Return(currentKey, nil)
+		mockCr.On("DecodeKey", unwrappedKey).Return([]byte("unwrapped"), nil)
+		mockCr.On("EncodeKey", []byte("unwrapped")).Return(newUnwrappedKey, nil)
+		mockDs.On("ReadData", unwrappedKey).Return(unwrappedValue, nil)
+		mockCr.On("Decode", unwrappedValue).Return([]byte("unwrapped value"), nil)
+		mockCr.On("Encode", []byte("unwrapped value")).Return(newUnwrappedValue, nil)
+		mockDs.On("SaveData", newUnwrappedKey, newUnwrappedValue).Return(nil)
+		mockDs.On("DeleteData", unwrappedKey).Return(nil)
+		mockCr.On("DecodeKey", legacyKey).Return(nil, fmt.Errorf("test"))
+		mockCr.On("DecodeKey", foreignKey).Return(nil, fmt.Errorf("test"))
+		mockCr.On("Decode", legacyKey).Return([]byte("legacy"), nil)
+		mockCr.On("Decode", foreignKey).Return(nil, fmt.Errorf("test"))
+		mockDs.On("ReadData", legacyKey).Return(legacyValue, nil)
+		mockCr.On("Decode", legacyValue).Return([]byte("value"), nil)
+		mockCr.On("Encode", []byte("value")).Return(newValue, nil)
+		mockCr.On("EncodeKey", []byte("legacy")).Return(newKey, nil)
+		mockDs.On("SaveData", newKey, newValue).Return(nil)
+		mockDs.On("DeleteData", legacyKey).Return(nil)
+
+		p := NewProvider(mockCr, mockDs)
+		n, err := p.Migrate()
+		require.NoError(t, err)
+		require.EqualValues(t, 2, n)
+
+		mockCr.AssertExpectations(t)
+		mockDs.AssertExpectations(t)
+	})
+
+	t.Run("save error", func(t *testing.T) {
+		legacyKey := []byte{0, 2}
+		legacyValue := []byte{1, 2}
+		mockCr := new(MockCryptographer)
+		mockDs := new(MockDataSaver)
+
+		mockDs.On("ListKeys").Return([][]byte{legacyKey}, nil)
+		mockCr.On("DecodeKey", legacyKey).Return(nil, fmt.Errorf("test"))
+		mockCr.On("Decode", legacyKey).Return([]byte("legacy"), nil)
+		mockDs.On("ReadData", legacyKey).Return(legacyValue, nil)
+		mockCr.On("Decode", legacyValue).Return([]byte("value"), nil)
+		mockCr.On("Encode", []byte("value")).Return([]byte{1, 3}, nil)
+		mockCr.On("EncodeKey", []byte("legacy")).Return([]byte{0, 4}, nil)
+		mockDs.On("SaveData", []byte{0, 4}, []byte{1, 3}).Return(fmt.Errorf("test"))
+
+		p := NewProvider(mockCr, mockDs)
+		n, err := p.Migrate()
+		require.Error(t, err)
+		require.EqualValues(t, "provider, Migrate method: provider, SetData method: save error: test", err.Error())
+		require.EqualValues(t, 0, n)
+
+		mockDs.AssertNotCalled(t, "DeleteData", legacyKey)
+	})
+	t.Run("error if context is canceled", func(t *testing.T) {
+		mockCr := new(MockCryptographer)
+		mockDs := new(MockDataSaver)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := NewProvider(mockCr, mockDs).MigrateCtx(ctx)
+		require.True(t, errors.Is(err, context.Canceled))
+		mockDs.AssertNotCalled(t, "ListKeys")
+	})
+}
+
+func TestProvider_SetDataCtx(t *testing.T) {
+	t.Run("error if context is canceled", func(t *testing.T) {
+		mockCr := new(MockCryptographer)
+		mockDs := new(MockDataSaver)
+		mockCr.On("Encode", []byte("value")).Return([]byte{1}, nil)
+		mockCr.On("EncodeKey", []byte("key")).Return([]byte{2}, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := NewProvider(mockCr, mockDs).SetDataCtx(ctx, []byte("key"), []byte("value"))
+		require.Error(t, err)
+		require.True(t, errors.Is(err, context.Canceled))
+		mockDs.AssertNotCalled(t, "SaveData", mock.Anything, mock.Anything)
+	})
+}
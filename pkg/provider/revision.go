@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// GetDataWithRevisionCtx returns decrypted value by key with its revision.
+// Returns secret.ErrRevisionNotSupported if data saver doesn't keep revisions.
+func (p *provider) GetDataWithRevisionCtx(ctx context.Context, key []byte) ([]byte, int, error) {
+	rs, encodedKey, err := p.revisioned(key)
+	if err != nil {
+		return nil, 0, fmt.Errorf("provider, GetDataWithRevision method: %w", err)
+	}
+	data, revision, err := rs.ReadDataWithRevisionCtx(ctx, encodedKey)
+	if err != nil {
+		return nil, 0, fmt.Errorf("provider, GetDataWithRevision method: read data error: %w", p.notFound(ctx, err))
+	}
+	value, err := p.cryptographer.Decode(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("provider, GetDataWithRevision method: decode error: %w", err)
+	}
+	return value, revision, nil
+}
+
+// SetDataIfRevisionCtx sets value, which expires at the time, only if value by key has the revision.
+// Value never expires if the time is zero.
+// Returns the new revision or secret.ErrRevisionMismatch.
+func (p *provider) SetDataIfRevisionCtx(ctx context.Context, key, value []byte, expiresAt time.Time, revision int) (int, error) {
+	rs, encodedKey, err := p.revisioned(key)
+	if err != nil {
+		return 0, fmt.Errorf("provider, SetDataIfRevision method: %w", err)
+	}
+	encodedValue, err := p.cryptographer.Encode(value)
+	if err != nil {
+		return 0, fmt.Errorf("provider, SetDataIfRevision method: encode value error: %w", err)
+	}
+	saved, err := rs.SaveDataIfRevisionCtx(ctx, encodedKey, encodedValue, expiresAt, revision)
+	if err != nil {
+		return 0, fmt.Errorf("provider, SetDataIfRevision method: save error: %w", err)
+	}
+	return saved, nil
+}
+
+// revisioned returns the data saver with revisions and encoded key.
+func (p *provider) revisioned(key []byte) (secret.RevisionedDataSaver, []byte, error) {
+	if len(key) == 0 {
+		return nil, nil, secret.ErrEmptyKey
+	}
+	rs, ok := p.dataSaver.(secret.RevisionedDataSaver)
+	if !ok {
+		return nil, nil, secret.ErrRevisionNotSupported
+	}
+	encodedKey, err := p.cryptographer.EncodeKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode key error: %w", err)
+	}
+	return rs, encodedKey, nil
+}
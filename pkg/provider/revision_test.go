@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// revisionedDataSaver keeps revisions of values in memory.
+type revisionedDataSaver struct {
+	*mapDataSaver
+	revisions map[string]int
+}
+
+func newRevisionedDataSaver() *revisionedDataSaver {
+	return &revisionedDataSaver{mapDataSaver: newMapDataSaver(), revisions: map[string]int{}}
+}
+
+func (r *revisionedDataSaver) ReadDataWithRevisionCtx(_ context.Context, key []byte) ([]byte, int, error) {
+	data, ok := r.data[string(key)]
+	if !ok {
+		return nil, 0, secret.ErrNotFound
+	}
+	return data, r.revisions[string(key)], nil
+}
+
+func (r *revisionedDataSaver) SaveDataIfRevisionCtx(_ context.Context, key, encodedValue []byte, _ time.Time, revision int) (int, error) {
+	if revision != secret.AnyRevision && r.revisions[string(key)] != revision {
+		return 0, secret.ErrRevisionMismatch
+	}
+	if err := r.SaveData(key, encodedValue); err != nil {
+		return 0, err
+	}
+	r.revisions[string(key)]++
+	return r.revisions[string(key)], nil
+}
+
+func TestProvider_Revision(t *testing.T) {
+	ctx := context.Background()
+	cr := crypto.NewCryptographer([]byte("key"), rand.Reader)
+
+	t.Run("success", func(t *testing.T) {
+		p := NewProvider(cr, newRevisionedDataSaver())
+		revision, err := p.SetDataIfRevisionCtx(ctx, []byte("key"), []byte("value 1"), time.Time{}, 0)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, revision)
+		revision, err = p.SetDataIfRevisionCtx(ctx, []byte("key"), []byte("value 2"), time.Time{}, revision)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, revision)
+
+		value, revision, err := p.GetDataWithRevisionCtx(ctx, []byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value 2", string(value))
+		require.EqualValues(t, 2, revision)
+	})
+	t.Run("revision mismatch", func(t *testing.T) {
+		p := NewProvider(cr, newRevisionedDataSaver())
+		_, err := p.SetDataIfRevisionCtx(ctx, []byte("key"), []byte("value"), time.Time{}, 1)
+		require.True(t, errors.Is(err, secret.ErrRevisionMismatch))
+		_, _, err = p.GetDataWithRevisionCtx(ctx, []byte("key"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+	})
+	t.Run("error if data saver doesn't keep revisions", func(t *testing.T) {
+		p := NewProvider(cr, newMapDataSaver())
+		_, err := p.SetDataIfRevisionCtx(ctx, []byte("key"), []byte("value"), time.Time{}, secret.AnyRevision)
+		require.True(t, errors.Is(err, secret.ErrRevisionNotSupported))
+		_, _, err = p.GetDataWithRevisionCtx(ctx, []byte("key"))
+		require.True(t, errors.Is(err, secret.ErrRevisionNotSupported))
+	})
+}
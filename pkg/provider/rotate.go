@@ -0,0 +1,263 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// rotationKey is the storage key of the rotation journal.
+// Journal is saved as is, so it can't be decrypted as a key name and is never listed by provider.
+var rotationKey = []byte("go-secret:rotation")
+
+// rotationCheck is encrypted by both cipher keys to recognize them when rotation is resumed.
+var rotationCheck = []byte("go-secret:rotation")
+
+const (
+	rotationPhaseCopy   = "copy"
+	rotationPhaseDelete = "delete"
+	rotationPhaseReseal = "reseal"
+)
+
+// rotation is the journal of unfinished rotation.
+// Rotation is done in two phases:
+// 	copy - every entry of the old cipher key is saved again with the new one, old entries are kept;
+// 	delete - entries of the old cipher key are deleted;
+// 	reseal - sealed data saver is encrypted with the new passphrase, it's skipped for other data savers.
+// All phases can be repeated safely, so interrupted rotation is resumed from the phase saved in journal.
+// Sealed data saver is opened with the new passphrase to resume the reseal phase.
+type rotation struct {
+	From  []byte `json:"from"`
+	To    []byte `json:"to"`
+	Phase string `json:"phase"`
+}
+
+// Rotate opens the data saver with old passphrase and re-encrypts all its entries with new passphrase.
+// New cipher key is derived with the same params as the old one.
+// Sealed data saver is resealed with new passphrase, see secret.SealedDataSaver.
+// Returns number of re-encrypted entries.
+func Rotate(ds secret.DataSaver, oldPassphrase, newPassphrase []byte, opts ...Option) (int, error) {
+	return RotateCtx(context.Background(), ds, oldPassphrase, newPassphrase, opts...)
+}
+
+// RotateCtx derives both cipher keys with KDF params of the data saver and rotates entries of the old one.
+func RotateCtx(ctx context.Context, ds secret.DataSaver, oldPassphrase, newPassphrase []byte, opts ...Option) (int, error) {
+	options := defaultOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	params, err := kdfParams(ds, options)
+	if err != nil {
+		return 0, fmt.Errorf("provider, Rotate method: %w", err)
+	}
+	oldCr, err := crypto.NewCryptographerKDF(oldPassphrase, params, options.randReader)
+	if err != nil {
+		return 0, fmt.Errorf("provider, Rotate method: %w", err)
+	}
+	newCr, err := crypto.NewCryptographerKDF(newPassphrase, params, options.randReader)
+	if err != nil {
+		return 0, fmt.Errorf("provider, Rotate method: %w", err)
+	}
+	return NewProvider(oldCr, ds, opts...).rotate(ctx, newCr, newPassphrase)
+}
+
+// Rotate re-encrypts all entries of provider cipher key with the new cryptographer.
+// Progress is saved in the journal, so rotation interrupted by crash is resumed
+// by calling Rotate again with the same cipher keys.
+// Entries of other cipher keys are kept as is.
+// All versions of entries are re-encrypted with their numbers and creation times, see secret.HistoryDataSaver.
+// Data saver, which keeps versions, but can't save them, isn't rotated, because versions would be lost.
+// Sealed data saver can't be rotated without the new passphrase, use package Rotate for it.
+// Returns number of re-encrypted entries.
+func (p *provider) Rotate(newCryptographer secret.Cryptographer) (int, error) {
+	return p.RotateCtx(context.Background(), newCryptographer)
+}
+
+// RotateCtx stops when ctx is done, the journal keeps progress for the next call.
+func (p *provider) RotateCtx(ctx context.Context, newCryptographer secret.Cryptographer) (int, error) {
+	return p.rotate(ctx, newCryptographer, nil)
+}
+
+// rotate reseals sealed data saver with the new passphrase after entries are rotated.
+func (p *provider) rotate(ctx context.Context, newCryptographer secret.Cryptographer, newPassphrase []byte) (int, error) {
+	sealed, isSealed := p.dataSaver.(secret.SealedDataSaver)
+	isSealed = isSealed && sealed.Sealed()
+	if isSealed && newPassphrase == nil {
+		return 0, errors.New("provider, Rotate method: sealed data saver should be rotated with the new passphrase")
+	}
+	_, versioned := p.dataSaver.(secret.VersionedDataSaver)
+	hs, hasHistory := p.dataSaver.(secret.HistoryDataSaver)
+	if versioned && !hasHistory {
+		return 0, errors.New("provider, Rotate method: data saver keeps versions, but can't save them: rotation would lose versions")
+	}
+	ds := p.saverCtx()
+	// target has the same key prefix, so entries are kept in the namespace of provider
+	target := NewProvider(newCryptographer, p.dataSaver, p.options()...)
+	j, err := p.startRotation(ctx, target.cryptographer)
+	if err != nil {
+		return 0, fmt.Errorf("provider, Rotate method: %w", err)
+	}
+	encodedKeys, err := ds.ListKeysCtx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("provider, Rotate method: list keys error: %w", err)
+	}
+	rotated := 0
+	if j.Phase == rotationPhaseCopy {
+		for _, encodedKey := range encodedKeys {
+			key, err := p.cryptographer.DecodeKey(encodedKey)
+			if err != nil {
+				continue
+			}
+			// re-encrypted value expires at the same time
+			expiresAt, err := p.expiry(ctx, encodedKey)
+			if err != nil {
+				return rotated, fmt.Errorf("provider, Rotate method: read expiry error: %w", err)
+			}
+			targetKey, err := target.cryptographer.EncodeKey(key)
+			if err != nil {
+				return rotated, fmt.Errorf("provider, Rotate method: encode key error: %w", err)
+			}
+			if hasHistory {
+				err = p.copyHistory(ctx, hs, target, encodedKey, targetKey, expiresAt)
+			} else {
+				err = p.copyValue(ctx, target, key, encodedKey, expiresAt)
+			}
+			if err != nil {
+				return rotated, fmt.Errorf("provider, Rotate method: %w", err)
+			}
+			if err := p.copyMetadata(ctx, target, encodedKey, targetKey); err != nil {
+				return rotated, fmt.Errorf("provider, Rotate method: %w", err)
+			}
+			rotated++
+		}
+		j.Phase = rotationPhaseDelete
+		if err := writeRotation(ctx, ds, j); err != nil {
+			return rotated, fmt.Errorf("provider, Rotate method: %w", err)
+		}
+	}
+	if j.Phase == rotationPhaseDelete {
+		for _, encodedKey := range encodedKeys {
+			if _, err := p.cryptographer.DecodeKey(encodedKey); err != nil {
+				continue
+			}
+			if err := ds.DeleteDataCtx(ctx, encodedKey); err != nil {
+				return rotated, fmt.Errorf("provider, Rotate method: delete old data error: %w", err)
+			}
+		}
+		// old cipher key isn't known anymore
+		if err := p.deleteVerifier(ctx); err != nil {
+			return rotated, fmt.Errorf("provider, Rotate method: %w", err)
+		}
+		if isSealed {
+			j.Phase = rotationPhaseReseal
+			if err := writeRotation(ctx, ds, j); err != nil {
+				return rotated, fmt.Errorf("provider, Rotate method: %w", err)
+			}
+		}
+	}
+	if j.Phase == rotationPhaseReseal {
+		if !isSealed {
+			return rotated, errors.New("provider, Rotate method: data saver isn't sealed, but rotation is in reseal phase")
+		}
+		if err := sealed.ResealCtx(ctx, newPassphrase); err != nil {
+			return rotated, fmt.Errorf("provider, Rotate method: reseal error: %w", err)
+		}
+	}
+	if err := ds.DeleteDataCtx(ctx, rotationKey); err != nil {
+		return rotated, fmt.Errorf("provider, Rotate method: delete journal error: %w", err)
+	}
+	return rotated, nil
+}
+
+// copyValue saves the value by encoded key re-encrypted for the target.
+func (p *provider) copyValue(ctx context.Context, target *provider, key, encodedKey []byte, expiresAt time.Time) error {
+	data, err := p.saverCtx().ReadDataCtx(ctx, encodedKey)
+	if err != nil {
+		return fmt.Errorf("read data error: %w", err)
+	}
+	value, err := p.cryptographer.Decode(data)
+	if err != nil {
+		return fmt.Errorf("decode error: %w", err)
+	}
+	return target.SetDataWithExpiryCtx(ctx, key, value, expiresAt)
+}
+
+// copyHistory saves all versions of the value by encoded key re-encrypted for the target.
+// Versions keep their order, numbers and creation times.
+func (p *provider) copyHistory(ctx context.Context, hs secret.HistoryDataSaver, target *provider, encodedKey, targetKey []byte, expiresAt time.Time) error {
+	versions, err := hs.VersionsCtx(ctx, encodedKey)
+	if err != nil {
+		return fmt.Errorf("read versions error: %w", err)
+	}
+	history := make([]secret.VersionedValue, 0, len(versions))
+	for _, v := range versions {
+		data, err := hs.ReadVersionCtx(ctx, encodedKey, v.Number)
+		if err != nil {
+			return fmt.Errorf("read version %d error: %w", v.Number, err)
+		}
+		value, err := p.cryptographer.Decode(data)
+		if err != nil {
+			return fmt.Errorf("decode version %d error: %w", v.Number, err)
+		}
+		encodedValue, err := target.cryptographer.Encode(value)
+		if err != nil {
+			return fmt.Errorf("encode version %d error: %w", v.Number, err)
+		}
+		history = append(history, secret.VersionedValue{Version: v, Value: encodedValue})
+	}
+	if err := hs.SaveHistoryCtx(ctx, targetKey, history, expiresAt); err != nil {
+		return fmt.Errorf("save history error: %w", err)
+	}
+	return nil
+}
+
+// startRotation returns journal of unfinished rotation with the same cipher keys or creates new one.
+func (p *provider) startRotation(ctx context.Context, newCryptographer secret.Cryptographer) (rotation, error) {
+	from, err := p.cryptographer.EncodeKey(rotationCheck)
+	if err != nil {
+		return rotation{}, fmt.Errorf("encode key error: %w", err)
+	}
+	to, err := newCryptographer.EncodeKey(rotationCheck)
+	if err != nil {
+		return rotation{}, fmt.Errorf("encode key error: %w", err)
+	}
+	if bytes.Equal(from, to) {
+		return rotation{}, errors.New("old and new cipher keys are the same")
+	}
+	data, found, err := readRecord(ctx, p.saverCtx(), rotationKey, "rotation journal")
+	if err != nil {
+		return rotation{}, err
+	}
+	if found {
+		var j rotation
+		if err := json.Unmarshal(data, &j); err != nil {
+			return rotation{}, fmt.Errorf("can't decode rotation journal: %w", err)
+		}
+		if !bytes.Equal(j.From, from) || !bytes.Equal(j.To, to) {
+			return rotation{}, errors.New("another rotation is in progress: finish it with the same cipher keys first")
+		}
+		return j, nil
+	}
+	j := rotation{From: from, To: to, Phase: rotationPhaseCopy}
+	if err := writeRotation(ctx, p.saverCtx(), j); err != nil {
+		return rotation{}, err
+	}
+	return j, nil
+}
+
+func writeRotation(ctx context.Context, ds secret.DataSaverCtx, j rotation) error {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return fmt.Errorf("can't encode rotation journal: %w", err)
+	}
+	if err := ds.SaveDataCtx(ctx, rotationKey, data); err != nil {
+		return fmt.Errorf("can't save rotation journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// mapDataSaver keeps data in memory and can fail on chosen call to emulate crash.
+type mapDataSaver struct {
+	data   map[string][]byte
+	failOn func(method string, key []byte) bool
+}
+
+func newMapDataSaver() *mapDataSaver {
+	return &mapDataSaver{data: make(map[string][]byte)}
+}
+
+func (m *mapDataSaver) fail(method string, key []byte) error {
+	if m.failOn != nil && m.failOn(method, key) {
+		return errors.New("crash")
+	}
+	return nil
+}
+
+func (m *mapDataSaver) SaveData(key, encodedValue []byte) error {
+	if err := m.fail("SaveData", key); err != nil {
+		return err
+	}
+	m.data[string(key)] = encodedValue
+	return nil
+}
+
+func (m *mapDataSaver) ReadData(key []byte) ([]byte, error) {
+	v, ok := m.data[string(key)]
+	if !ok {
+		return nil, secret.ErrNotFound
+	}
+	return v, nil
+}
+
+func (m *mapDataSaver) DeleteData(key []byte) error {
+	if err := m.fail("DeleteData", key); err != nil {
+		return err
+	}
+	if _, ok := m.data[string(key)]; !ok {
+		return secret.ErrNotFound
+	}
+	delete(m.data, string(key))
+	return nil
+}
+
+func (m *mapDataSaver) ListKeys() ([][]byte, error) {
+	keys := make([][]byte, 0, len(m.data))
+	for k := range m.data {
+		keys = append(keys, []byte(k))
+	}
+	return keys, nil
+}
+
+// sealedDataSaver is mapDataSaver sealed with the passphrase.
+type sealedDataSaver struct {
+	*mapDataSaver
+	passphrase string
+}
+
+func (s *sealedDataSaver) Sealed() bool {
+	return true
+}
+
+func (s *sealedDataSaver) ResealCtx(_ context.Context, passphrase []byte) error {
+	if err := s.fail("ResealCtx", nil); err != nil {
+		return err
+	}
+	s.passphrase = string(passphrase)
+	return nil
+}
+
+func listNames(t *testing.T, p *provider) []string {
+	keys, err := p.ListKeys()
+	require.NoError(t, err)
+	names := make([]string, 0, len(keys))
+	for _, k := range keys {
+		names = append(names, string(k))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestProvider_Rotate(t *testing.T) {
+	oldCr := crypto.NewCryptographer([]byte("old"), rand.Reader)
+	newCr := crypto.NewCryptographer([]byte("new"), rand.Reader)
+	foreignCr := crypto.NewCryptographer([]byte("foreign"), rand.Reader)
+	fill := func(t *testing.T, ds *mapDataSaver) {
+		oldP := NewProvider(oldCr, ds)
+		require.NoError(t, oldP.SetData([]byte("first"), []byte("value 1")))
+		require.NoError(t, oldP.SetData([]byte("second"), []byte("value 2")))
+		require.NoError(t, NewProvider(foreignCr, ds).SetData([]byte("foreign"), []byte("value 3")))
+	}
+	check := func(t *testing.T, ds *mapDataSaver) {
+		newP := NewProvider(newCr, ds)
+		require.EqualValues(t, []string{"first", "second"}, listNames(t, newP))
+		got, err := newP.GetData([]byte("second"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value 2", string(got))
+		require.Empty(t, listNames(t, NewProvider(oldCr, ds)))
+		require.EqualValues(t, []string{"foreign"}, listNames(t, NewProvider(foreignCr, ds)))
+		_, found := ds.data[string(rotationKey)]
+		require.False(t, found)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ds := newMapDataSaver()
+		fill(t, ds)
+
+		n, err := NewProvider(oldCr, ds).Rotate(newCr)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, n)
+		check(t, ds)
+	})
+	t.Run("resume after crash in copy phase", func(t *testing.T) {
+		ds := newMapDataSaver()
+		fill(t, ds)
+		saves := 0
+		ds.failOn = func(method string, key []byte) bool {
+			if method == "SaveData" && string(key) != string(rotationKey) {
+				saves++
+				return saves == 2
+			}
+			return false
+		}
+
+		_, err := NewProvider(oldCr, ds).Rotate(newCr)
+		require.Error(t, err)
+		require.EqualValues(t, []string{"first", "second"}, listNames(t, NewProvider(oldCr, ds)))
+
+		ds.failOn = nil
+		n, err := NewProvider(oldCr, ds).Rotate(newCr)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, n)
+		check(t, ds)
+	})
+	t.Run("resume after crash in delete phase", func(t *testing.T) {
+		ds := newMapDataSaver()
+		fill(t, ds)
+		ds.failOn = func(method string, key []byte) bool {
+			return method == "DeleteData" && string(key) != string(rotationKey)
+		}
+
+		_, err := NewProvider(oldCr, ds).Rotate(newCr)
+		require.Error(t, err)
+		require.EqualValues(t, "provider, Rotate method: delete old data error: crash", err.Error())
+
+		ds.failOn = nil
+		n, err := NewProvider(oldCr, ds).Rotate(newCr)
+		require.NoError(t, err)
+		require.EqualValues(t, 0, n)
+		check(t, ds)
+	})
+	t.Run("error if another rotation is in progress", func(t *testing.T) {
+		ds := newMapDataSaver()
+		fill(t, ds)
+		ds.failOn = func(method string, key []byte) bool {
+			return method == "DeleteData"
+		}
+		_, err := NewProvider(oldCr, ds).Rotate(newCr)
+		require.Error(t, err)
+
+		ds.failOn = nil
+		_, err = NewProvider(oldCr, ds).Rotate(foreignCr)
+		require.Error(t, err)
+		require.EqualValues(t, "provider, Rotate method: another rotation is in progress: finish it with the same cipher keys first", err.Error())
+	})
+	t.Run("error if context is canceled", func(t *testing.T) {
+		ds := newMapDataSaver()
+		fill(t, ds)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := NewProvider(oldCr, ds).RotateCtx(ctx, newCr)
+		require.True(t, errors.Is(err, context.Canceled))
+		require.EqualValues(t, []string{"first", "second"}, listNames(t, NewProvider(oldCr, ds)))
+	})
+	t.Run("error if keys are the same", func(t *testing.T) {
+		ds := newMapDataSaver()
+		_, err := NewProvider(oldCr, ds).Rotate(crypto.NewCryptographer([]byte("old"), rand.Reader))
+		require.Error(t, err)
+		require.EqualValues(t, "provider, Rotate method: old and new cipher keys are the same", err.Error())
+	})
+}
+
+// historyDataSaver keeps every saved value of the key with its creation time in memory.
+type historyDataSaver struct {
+	*mapDataSaver
+	history map[string][]secret.VersionedValue
+}
+
+func (h *historyDataSaver) SaveData(key, encodedValue []byte) error {
+	history := h.history[string(key)]
+	v := secret.VersionedValue{Version: secret.Version{Number: len(history) + 1, CreatedAt: time.Now().UTC()}, Value: encodedValue}
+	h.history[string(key)] = append(history, v)
+	return h.mapDataSaver.SaveData(key, encodedValue)
+}
+
+func (h *historyDataSaver) DeleteData(key []byte) error {
+	delete(h.history, string(key))
+	return h.mapDataSaver.DeleteData(key)
+}
+
+func (h *historyDataSaver) VersionsCtx(_ context.Context, key []byte) ([]secret.Version, error) {
+	var versions []secret.Version
+	for _, v := range h.history[string(key)] {
+		versions = append(versions, v.Version)
+	}
+	if len(versions) == 0 {
+		return nil, secret.ErrNotFound
+	}
+	return versions, nil
+}
+
+func (h *historyDataSaver) ReadVersionCtx(_ context.Context, key []byte, version int) ([]byte, error) {
+	for _, v := range h.history[string(key)] {
+		if v.Number == version {
+			return v.Value, nil
+		}
+	}
+	return nil, secret.ErrNotFound
+}
+
+func (h *historyDataSaver) SaveHistoryCtx(_ context.Context, key []byte, history []secret.VersionedValue, _ time.Time) error {
+	h.history[string(key)] = append([]secret.VersionedValue(nil), history...)
+	h.data[string(key)] = history[len(history)-1].Value
+	return nil
+}
+
+func TestProvider_RotateVersions(t *testing.T) {
+	ctx := context.Background()
+	oldCr := crypto.NewCryptographer([]byte("old"), rand.Reader)
+	newCr := crypto.NewCryptographer([]byte("new"), rand.Reader)
+	key := []byte("key")
+
+	t.Run("versions are rotated", func(t *testing.T) {
+		ds := &historyDataSaver{mapDataSaver: newMapDataSaver(), history: map[string][]secret.VersionedValue{}}
+		oldP := NewProvider(oldCr, ds)
+		require.NoError(t, oldP.SetData(key, []byte("value 1")))
+		require.NoError(t, oldP.SetData(key, []byte("value 2")))
+		oldVersions, err := oldP.VersionsCtx(ctx, key)
+		require.NoError(t, err)
+
+		n, err := oldP.Rotate(newCr)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, n)
+
+		newP := NewProvider(newCr, ds)
+		versions, err := newP.VersionsCtx(ctx, key)
+		require.NoError(t, err)
+		require.EqualValues(t, oldVersions, versions)
+		got, err := newP.GetVersionCtx(ctx, key, 1)
+		require.NoError(t, err)
+		require.EqualValues(t, "value 1", string(got))
+		got, err = newP.GetData(key)
+		require.NoError(t, err)
+		require.EqualValues(t, "value 2", string(got))
+		require.Empty(t, listNames(t, oldP))
+	})
+	t.Run("error if versions can't be saved", func(t *testing.T) {
+		ds := &versionedDataSaver{mapDataSaver: newMapDataSaver(), history: map[string][][]byte{}}
+		require.NoError(t, NewProvider(oldCr, ds).SetData(key, []byte("value 1")))
+
+		_, err := NewProvider(oldCr, ds).Rotate(newCr)
+		require.EqualError(t, err, "provider, Rotate method: data saver keeps versions, but can't save them: rotation would lose versions")
+		require.EqualValues(t, []string{"key"}, listNames(t, NewProvider(oldCr, ds)))
+	})
+}
+
+func TestProvider_RotateKeyPrefix(t *testing.T) {
+	oldCr := crypto.NewCryptographer([]byte("old"), rand.Reader)
+	newCr := crypto.NewCryptographer([]byte("new"), rand.Reader)
+	ds := newMapDataSaver()
+	require.NoError(t, NewProvider(oldCr, ds, KeyPrefix("team-a/")).SetData([]byte("key"), []byte("value a")))
+	require.NoError(t, NewProvider(oldCr, ds, KeyPrefix("team-b/")).SetData([]byte("key"), []byte("value b")))
+
+	n, err := NewProvider(oldCr, ds, KeyPrefix("team-a/")).Rotate(newCr)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+
+	teamA := NewProvider(newCr, ds, KeyPrefix("team-a/"))
+	require.EqualValues(t, []string{"key"}, listNames(t, teamA))
+	got, err := teamA.GetData([]byte("key"))
+	require.NoError(t, err)
+	require.EqualValues(t, "value a", string(got))
+	require.EqualValues(t, []string{"team-a/key"}, listNames(t, NewProvider(newCr, ds)))
+	// entries of other prefixes are kept with the old cipher key
+	require.EqualValues(t, []string{"team-b/key"}, listNames(t, NewProvider(oldCr, ds)))
+}
+
+func TestRotate_Sealed(t *testing.T) {
+	fill := func(t *testing.T) *sealedDataSaver {
+		ds := &sealedDataSaver{mapDataSaver: newMapDataSaver(), passphrase: "old"}
+		p, err := Open(ds, []byte("old"), KDFCost(4))
+		require.NoError(t, err)
+		require.NoError(t, p.SetData([]byte("key"), []byte("value")))
+		return ds
+	}
+	check := func(t *testing.T, ds *sealedDataSaver) {
+		require.EqualValues(t, "new", ds.passphrase)
+		p, err := Open(ds, []byte("new"))
+		require.NoError(t, err)
+		got, err := p.GetData([]byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value", string(got))
+		_, found := ds.data[string(rotationKey)]
+		require.False(t, found)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ds := fill(t)
+		n, err := Rotate(ds, []byte("old"), []byte("new"))
+		require.NoError(t, err)
+		require.EqualValues(t, 1, n)
+		check(t, ds)
+	})
+	t.Run("resume after crash in reseal phase", func(t *testing.T) {
+		ds := fill(t)
+		ds.failOn = func(method string, key []byte) bool {
+			return method == "ResealCtx"
+		}
+		_, err := Rotate(ds, []byte("old"), []byte("new"))
+		require.EqualError(t, err, "provider, Rotate method: reseal error: crash")
+		require.EqualValues(t, "old", ds.passphrase)
+
+		ds.failOn = nil
+		n, err := Rotate(ds, []byte("old"), []byte("new"))
+		require.NoError(t, err)
+		require.EqualValues(t, 0, n)
+		check(t, ds)
+	})
+	t.Run("error without new passphrase", func(t *testing.T) {
+		ds := fill(t)
+		p, err := Open(ds, []byte("old"))
+		require.NoError(t, err)
+		_, err = p.Rotate(crypto.NewCryptographer([]byte("new"), rand.Reader))
+		require.EqualError(t, err, "provider, Rotate method: sealed data saver should be rotated with the new passphrase")
+		require.EqualValues(t, "old", ds.passphrase)
+	})
+}
+
+func TestRotate(t *testing.T) {
+	ds := newMapDataSaver()
+	p, err := Open(ds, []byte("old"), KDFCost(4))
+	require.NoError(t, err)
+	require.NoError(t, p.SetData([]byte("key"), []byte("value")))
+
+	n, err := Rotate(ds, []byte("old"), []byte("new"))
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+
+	p, err = Open(ds, []byte("new"))
+	require.NoError(t, err)
+	got, err := p.GetData([]byte("key"))
+	require.NoError(t, err)
+	require.EqualValues(t, "value", string(got))
+}
@@ -0,0 +1,186 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// headerKey is the storage key of the vault header.
+// Header is saved as is, so it can't be decrypted as a key name and is never listed by provider.
+var headerKey = []byte("go-secret:header")
+
+// header keeps vault settings which are needed to derive the cipher key again.
+type header struct {
+	KDF crypto.KDFParams `json:"kdf"`
+}
+
+type options struct {
+	legacyKDF       bool
+	kdfCost         int
+	randReader      io.Reader
+	encryptMetadata bool
+	keyPrefix       []byte
+}
+
+var defaultOptions = options{
+	kdfCost:    crypto.DefaultScryptCost,
+	randReader: rand.Reader,
+}
+
+type Option func(o *options)
+
+// LegacyKDF opens vaults created by previous versions,
+// where cipher key is unsalted sha256 of passphrase and vault has no header.
+func LegacyKDF() Option {
+	return func(o *options) {
+		o.legacyKDF = true
+	}
+}
+
+// KDFCost sets log2 of scrypt N parameter for new vaults.
+// It's ignored for existing vaults, because their params are read from the header.
+func KDFCost(cost int) Option {
+	return func(o *options) {
+		o.kdfCost = cost
+	}
+}
+
+// RandReader sets reader for salts and nonces. Default: crypto/rand.Reader.
+func RandReader(r io.Reader) Option {
+	return func(o *options) {
+		o.randReader = r
+	}
+}
+
+// EncryptMetadata makes provider encrypt metadata of values with the cipher key.
+// Metadata is saved as plain JSON by default, so it can be read without the cipher key.
+// Both forms are read regardless of the option.
+func EncryptMetadata() Option {
+	return func(o *options) {
+		o.encryptMetadata = true
+	}
+}
+
+// KeyPrefix makes provider put the prefix before every key name, so the prefix is encrypted with the name.
+// Providers with different prefixes can share the data saver and the cipher key, but don't see keys of each other.
+// Prefix isn't visible to the caller: keys are set, got and listed without it.
+func KeyPrefix(prefix string) Option {
+	return func(o *options) {
+		o.keyPrefix = []byte(prefix)
+	}
+}
+
+// Open creates provider for the data saver with the cipher key derived from passphrase.
+// Key derivation params are read from the vault header.
+// Header with new random salt is created if data saver is empty.
+// Data saver with data but without header can be opened only with LegacyKDF option.
+func Open(ds secret.DataSaver, passphrase []byte, opts ...Option) (*provider, error) {
+	options := defaultOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	params, err := kdfParams(ds, options)
+	if err != nil {
+		return nil, fmt.Errorf("provider, Open method: %w", err)
+	}
+	cr, err := crypto.NewCryptographerKDF(passphrase, params, options.randReader)
+	if err != nil {
+		return nil, fmt.Errorf("provider, Open method: %w", err)
+	}
+	return NewProvider(cr, ds, opts...), nil
+}
+
+// KDF returns key derivation params of the vault the same way as Open does.
+// It's useful for long-running processes, which derive keys for many passphrases.
+func KDF(ds secret.DataSaver, opts ...Option) (crypto.KDFParams, error) {
+	options := defaultOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	params, err := kdfParams(ds, options)
+	if err != nil {
+		return crypto.KDFParams{}, fmt.Errorf("provider, KDF method: %w", err)
+	}
+	return params, nil
+}
+
+func kdfParams(ds secret.DataSaver, options options) (crypto.KDFParams, error) {
+	h, found, err := readHeader(ds)
+	if err != nil {
+		return crypto.KDFParams{}, err
+	}
+	if found {
+		return h.KDF, nil
+	}
+	if options.legacyKDF {
+		return crypto.LegacyParams(), nil
+	}
+	keys, err := ds.ListKeys()
+	if err != nil {
+		return crypto.KDFParams{}, fmt.Errorf("can't list keys: %w", err)
+	}
+	if len(keys) > 0 {
+		return crypto.KDFParams{}, errors.New("vault has data but no header: use legacy kdf to open vault created by previous version")
+	}
+	params, err := crypto.NewScryptParams(options.randReader, options.kdfCost)
+	if err != nil {
+		return crypto.KDFParams{}, err
+	}
+	if err := writeHeader(ds, header{KDF: params}); err != nil {
+		return crypto.KDFParams{}, err
+	}
+	return params, nil
+}
+
+func readHeader(ds secret.DataSaver) (header, bool, error) {
+	var h header
+	data, found, err := readRecord(context.Background(), secret.DataSaverWithContext(ds), headerKey, "header")
+	if err != nil || !found {
+		return h, false, err
+	}
+	if err := json.Unmarshal(data, &h); err != nil {
+		return h, false, fmt.Errorf("can't decode header: %w", err)
+	}
+	return h, true, nil
+}
+
+// readRecord reads service record saved as is under reserved key.
+func readRecord(ctx context.Context, ds secret.DataSaverCtx, key []byte, name string) ([]byte, bool, error) {
+	data, err := ds.ReadDataCtx(ctx, key)
+	if errors.Is(err, secret.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil || len(data) == 0 {
+		// storages report missing keys differently, so make sure record really doesn't exist
+		keys, lErr := ds.ListKeysCtx(ctx)
+		if lErr != nil {
+			return nil, false, fmt.Errorf("can't list keys: %w", lErr)
+		}
+		for _, k := range keys {
+			if bytes.Equal(k, key) {
+				return nil, false, fmt.Errorf("can't read %s: %w", name, err)
+			}
+		}
+		return nil, false, nil
+	}
+	return data, true, nil
+}
+
+func writeHeader(ds secret.DataSaver, h header) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return fmt.Errorf("can't encode header: %w", err)
+	}
+	if err := ds.SaveData(headerKey, data); err != nil {
+		return fmt.Errorf("can't save header: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+)
+
+func TestOpen(t *testing.T) {
+	t.Run("create header for empty vault", func(t *testing.T) {
+		mockDs := new(MockDataSaver)
+		var saved []byte
+		mockDs.On("ReadData", headerKey).Return(nil, fmt.Errorf("not found"))
+		mockDs.On("ListKeys").Return([][]byte{}, nil)
+		mockDs.On("SaveData", headerKey, mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).([]byte)
+		}).Return(nil).Once()
+
+		p, err := Open(mockDs, []byte("password"), KDFCost(4))
+		require.NoError(t, err)
+		require.NotNil(t, p)
+
+		var h header
+		require.NoError(t, json.Unmarshal(saved, &h))
+		require.EqualValues(t, crypto.KDFScrypt, h.KDF.Name)
+		require.EqualValues(t, 16, h.KDF.N)
+		require.Len(t, h.KDF.Salt, 16)
+		mockDs.AssertExpectations(t)
+	})
+	t.Run("use params from header", func(t *testing.T) {
+		params, err := crypto.NewScryptParams(crypto.LoopReader([]byte{1, 2, 3}), 4)
+		require.NoError(t, err)
+		data, err := json.Marshal(header{KDF: params})
+		require.NoError(t, err)
+
+		mockDs := new(MockDataSaver)
+		mockDs.On("ReadData", headerKey).Return(data, nil)
+
+		got, err := KDF(mockDs)
+		require.NoError(t, err)
+		require.EqualValues(t, params, got)
+		mockDs.AssertNotCalled(t, "SaveData", mock.Anything, mock.Anything)
+	})
+	t.Run("legacy kdf if vault has no header", func(t *testing.T) {
+		mockDs := new(MockDataSaver)
+		mockDs.On("ReadData", headerKey).Return(nil, fmt.Errorf("not found"))
+		mockDs.On("ListKeys").Return([][]byte{{1, 2}}, nil)
+
+		got, err := KDF(mockDs, LegacyKDF())
+		require.NoError(t, err)
+		require.EqualValues(t, crypto.LegacyParams(), got)
+		mockDs.AssertNotCalled(t, "SaveData", mock.Anything, mock.Anything)
+	})
+	t.Run("error if vault has data without header", func(t *testing.T) {
+		mockDs := new(MockDataSaver)
+		mockDs.On("ReadData", headerKey).Return(nil, fmt.Errorf("not found"))
+		mockDs.On("ListKeys").Return([][]byte{{1, 2}}, nil)
+
+		_, err := Open(mockDs, []byte("password"))
+		require.Error(t, err)
+		require.EqualValues(t, "provider, Open method: vault has data but no header: use legacy kdf to open vault created by previous version", err.Error())
+	})
+	t.Run("error if header can't be read", func(t *testing.T) {
+		mockDs := new(MockDataSaver)
+		mockDs.On("ReadData", headerKey).Return(nil, fmt.Errorf("test"))
+		mockDs.On("ListKeys").Return([][]byte{headerKey}, nil)
+
+		_, err := Open(mockDs, []byte("password"))
+		require.Error(t, err)
+		require.EqualValues(t, "provider, Open method: can't read header: test", err.Error())
+	})
+}
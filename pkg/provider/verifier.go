@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// reservedPrefix is the prefix of service records of provider, like vault header or rotation journal.
+var reservedPrefix = []byte("go-secret:")
+
+// verifierPrefix is the storage key prefix of cipher key verifiers.
+// Verifier is saved as is, so it can't be decrypted as a key name and is never listed by provider.
+var verifierPrefix = []byte("go-secret:verifier:")
+
+// verifierCheck is encrypted by the cipher key to get the storage key of its verifier.
+var verifierCheck = []byte("go-secret:verifier")
+
+// notFound turns secret.ErrNotFound of the data saver into secret.ErrDecrypt if the data saver has data
+// of other cipher keys, but not of this one, so wrong cipher key can be told from missing key name.
+// Other errors are returned as is.
+func (p *provider) notFound(ctx context.Context, err error) error {
+	if !errors.Is(err, secret.ErrNotFound) {
+		return err
+	}
+	// missing key name is reported as is if the cipher key can't be checked
+	if wrong, kErr := p.wrongCipherKey(ctx); kErr != nil || !wrong {
+		return err
+	}
+	return fmt.Errorf("%w: no data is encrypted with the cipher key", secret.ErrDecrypt)
+}
+
+// wrongCipherKey reports whether all data of the data saver is encrypted with other cipher keys.
+// Verifier of the cipher key is saved when its data is found first, so keys are listed only until then.
+// Key prefix isn't checked: the cipher key is known if data of any prefix is encrypted with it.
+// Cipher key of empty data saver isn't wrong.
+func (p *provider) wrongCipherKey(ctx context.Context) (bool, error) {
+	if atomic.LoadInt32(&p.verified) == 1 {
+		return false, nil
+	}
+	cr := p.baseCryptographer()
+	key, err := verifierKey(cr)
+	if err != nil {
+		return false, err
+	}
+	ds := p.saverCtx()
+	_, found, err := readRecord(ctx, ds, key, "cipher key verifier")
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		encodedKeys, err := ds.ListKeysCtx(ctx)
+		if err != nil {
+			return false, fmt.Errorf("can't list keys: %w", err)
+		}
+		foreign := false
+		for _, encodedKey := range encodedKeys {
+			if bytes.HasPrefix(encodedKey, reservedPrefix) {
+				continue
+			}
+			if _, err := cr.DecodeKey(encodedKey); err != nil {
+				foreign = true
+				continue
+			}
+			found = true
+			break
+		}
+		if !found {
+			return foreign, nil
+		}
+		if err := ds.SaveDataCtx(ctx, key, verifierCheck); err != nil {
+			return false, fmt.Errorf("can't save cipher key verifier: %w", err)
+		}
+	}
+	atomic.StoreInt32(&p.verified, 1)
+	return false, nil
+}
+
+// deleteVerifier removes verifier of the cipher key, when its data is rotated to another one.
+func (p *provider) deleteVerifier(ctx context.Context) error {
+	key, err := verifierKey(p.baseCryptographer())
+	if err != nil {
+		return err
+	}
+	ds := p.saverCtx()
+	if _, found, err := readRecord(ctx, ds, key, "cipher key verifier"); err != nil || !found {
+		return err
+	}
+	if err := ds.DeleteDataCtx(ctx, key); err != nil {
+		return fmt.Errorf("can't delete cipher key verifier: %w", err)
+	}
+	atomic.StoreInt32(&p.verified, 0)
+	return nil
+}
+
+// baseCryptographer returns the cryptographer of provider without key prefix.
+func (p *provider) baseCryptographer() secret.Cryptographer {
+	if pc, ok := p.cryptographer.(*prefixCryptographer); ok {
+		return pc.Cryptographer
+	}
+	return p.cryptographer
+}
+
+func verifierKey(cr secret.Cryptographer) ([]byte, error) {
+	check, err := cr.EncodeKey(verifierCheck)
+	if err != nil {
+		return nil, fmt.Errorf("encode key error: %w", err)
+	}
+	key := make([]byte, 0, len(verifierPrefix)+len(check))
+	key = append(key, verifierPrefix...)
+	return append(key, check...), nil
+}
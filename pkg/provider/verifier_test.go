@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+func TestProvider_WrongCipherKey(t *testing.T) {
+	ctx := context.Background()
+	cr := crypto.NewCryptographer([]byte("key"), rand.Reader)
+	wrongCr := crypto.NewCryptographer([]byte("wrong"), rand.Reader)
+
+	t.Run("not found in empty data saver", func(t *testing.T) {
+		_, err := NewProvider(wrongCr, newMapDataSaver()).GetDataCtx(ctx, []byte("key"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+	})
+	t.Run("decrypt error if data is encrypted with another cipher key", func(t *testing.T) {
+		ds := newMapDataSaver()
+		require.NoError(t, NewProvider(cr, ds).SetData([]byte("key"), []byte("value")))
+
+		p := NewProvider(wrongCr, ds)
+		_, err := p.GetDataCtx(ctx, []byte("key"))
+		require.True(t, errors.Is(err, secret.ErrDecrypt))
+		require.False(t, errors.Is(err, secret.ErrNotFound))
+		err = p.DeleteDataCtx(ctx, []byte("key"))
+		require.True(t, errors.Is(err, secret.ErrDecrypt))
+	})
+	t.Run("not found if cipher key is known", func(t *testing.T) {
+		ds := newMapDataSaver()
+		require.NoError(t, NewProvider(cr, ds).SetData([]byte("key"), []byte("value")))
+		require.NoError(t, NewProvider(wrongCr, ds).SetData([]byte("other"), []byte("value")))
+
+		p := NewProvider(cr, ds)
+		_, err := p.GetDataCtx(ctx, []byte("missing"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		key, err := verifierKey(cr)
+		require.NoError(t, err)
+		require.Contains(t, ds.data, string(key))
+
+		// verifier keeps the cipher key known after its data is deleted
+		require.NoError(t, p.DeleteData([]byte("key")))
+		_, err = NewProvider(cr, ds).GetDataCtx(ctx, []byte("key"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+		require.EqualValues(t, []string{"other"}, listNames(t, NewProvider(wrongCr, ds)))
+	})
+	t.Run("cipher key is known to providers with any key prefix", func(t *testing.T) {
+		ds := newMapDataSaver()
+		require.NoError(t, NewProvider(cr, ds, KeyPrefix("team-a/")).SetData([]byte("key"), []byte("value")))
+
+		_, err := NewProvider(cr, ds, KeyPrefix("team-b/")).GetDataCtx(ctx, []byte("key"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+	})
+	t.Run("old cipher key is wrong after rotation", func(t *testing.T) {
+		ds := newMapDataSaver()
+		p := NewProvider(cr, ds)
+		require.NoError(t, p.SetData([]byte("key"), []byte("value")))
+		_, err := p.GetDataCtx(ctx, []byte("missing"))
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+
+		_, err = p.Rotate(wrongCr)
+		require.NoError(t, err)
+		_, err = NewProvider(cr, ds).GetDataCtx(ctx, []byte("key"))
+		require.True(t, errors.Is(err, secret.ErrDecrypt))
+	})
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// VersionsCtx returns versions of the key from the oldest to the latest.
+// Returns secret.ErrNotVersioned if data saver doesn't keep versions.
+func (p *provider) VersionsCtx(ctx context.Context, key []byte) ([]secret.Version, error) {
+	vs, encodedKey, err := p.versioned(key)
+	if err != nil {
+		return nil, fmt.Errorf("provider, Versions method: %w", err)
+	}
+	versions, err := vs.VersionsCtx(ctx, encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("provider, Versions method: read versions error: %w", p.notFound(ctx, err))
+	}
+	return versions, nil
+}
+
+// GetVersionCtx returns decrypted value of the version.
+func (p *provider) GetVersionCtx(ctx context.Context, key []byte, version int) ([]byte, error) {
+	vs, encodedKey, err := p.versioned(key)
+	if err != nil {
+		return nil, fmt.Errorf("provider, GetVersion method: %w", err)
+	}
+	data, err := vs.ReadVersionCtx(ctx, encodedKey, version)
+	if err != nil {
+		return nil, fmt.Errorf("provider, GetVersion method: read version error: %w", p.notFound(ctx, err))
+	}
+	value, err := p.cryptographer.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("provider, GetVersion method: decode error: %w", err)
+	}
+	return value, nil
+}
+
+// RollbackCtx saves value of the version as the new latest version.
+// Restored value expires at the same time as the latest one.
+func (p *provider) RollbackCtx(ctx context.Context, key []byte, version int) error {
+	value, err := p.GetVersionCtx(ctx, key, version)
+	if err != nil {
+		return fmt.Errorf("provider, Rollback method: %w", err)
+	}
+	encodedKey, err := p.cryptographer.EncodeKey(key)
+	if err != nil {
+		return fmt.Errorf("provider, Rollback method: encode key error: %w", err)
+	}
+	expiresAt, err := p.expiry(ctx, encodedKey)
+	if err != nil {
+		return fmt.Errorf("provider, Rollback method: read expiry error: %w", err)
+	}
+	if err := p.SetDataWithExpiryCtx(ctx, key, value, expiresAt); err != nil {
+		return fmt.Errorf("provider, Rollback method: %w", err)
+	}
+	return nil
+}
+
+// versioned returns the data saver with versions and encoded key.
+func (p *provider) versioned(key []byte) (secret.VersionedDataSaver, []byte, error) {
+	if len(key) == 0 {
+		return nil, nil, secret.ErrEmptyKey
+	}
+	vs, ok := p.dataSaver.(secret.VersionedDataSaver)
+	if !ok {
+		return nil, nil, secret.ErrNotVersioned
+	}
+	encodedKey, err := p.cryptographer.EncodeKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode key error: %w", err)
+	}
+	return vs, encodedKey, nil
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/crypto"
+	"github.com/go-itools-internship/go-secret/pkg/secret"
+)
+
+// versionedDataSaver keeps every saved value of the key in memory.
+type versionedDataSaver struct {
+	*mapDataSaver
+	history map[string][][]byte
+}
+
+func (v *versionedDataSaver) SaveData(key, encodedValue []byte) error {
+	v.history[string(key)] = append(v.history[string(key)], encodedValue)
+	return v.mapDataSaver.SaveData(key, encodedValue)
+}
+
+func (v *versionedDataSaver) VersionsCtx(_ context.Context, key []byte) ([]secret.Version, error) {
+	var versions []secret.Version
+	for i := range v.history[string(key)] {
+		versions = append(versions, secret.Version{Number: i + 1})
+	}
+	if len(versions) == 0 {
+		return nil, secret.ErrNotFound
+	}
+	return versions, nil
+}
+
+func (v *versionedDataSaver) ReadVersionCtx(_ context.Context, key []byte, version int) ([]byte, error) {
+	history := v.history[string(key)]
+	if version < 1 || version > len(history) {
+		return nil, secret.ErrNotFound
+	}
+	return history[version-1], nil
+}
+
+func TestProvider_Versions(t *testing.T) {
+	ctx := context.Background()
+	cr := crypto.NewCryptographer([]byte("key"), rand.Reader)
+	key := []byte("key")
+
+	t.Run("history and rollback", func(t *testing.T) {
+		ds := &versionedDataSaver{mapDataSaver: newMapDataSaver(), history: map[string][][]byte{}}
+		p := NewProvider(cr, ds)
+		require.NoError(t, p.SetData(key, []byte("value 1")))
+		require.NoError(t, p.SetData(key, []byte("value 2")))
+
+		versions, err := p.VersionsCtx(ctx, key)
+		require.NoError(t, err)
+		require.EqualValues(t, []secret.Version{{Number: 1}, {Number: 2}}, versions)
+		got, err := p.GetVersionCtx(ctx, key, 1)
+		require.NoError(t, err)
+		require.EqualValues(t, "value 1", string(got))
+
+		require.NoError(t, p.RollbackCtx(ctx, key, 1))
+		got, err = p.GetData(key)
+		require.NoError(t, err)
+		require.EqualValues(t, "value 1", string(got))
+		versions, err = p.VersionsCtx(ctx, key)
+		require.NoError(t, err)
+		require.Len(t, versions, 3)
+
+		err = p.RollbackCtx(ctx, key, 5)
+		require.True(t, errors.Is(err, secret.ErrNotFound))
+	})
+	t.Run("not versioned", func(t *testing.T) {
+		p := NewProvider(cr, newMapDataSaver())
+		_, err := p.VersionsCtx(ctx, key)
+		require.True(t, errors.Is(err, secret.ErrNotVersioned))
+		_, err = p.GetVersionCtx(ctx, key, 1)
+		require.True(t, errors.Is(err, secret.ErrNotVersioned))
+		err = p.RollbackCtx(ctx, key, 1)
+		require.True(t, errors.Is(err, secret.ErrNotVersioned))
+	})
+	t.Run("empty key", func(t *testing.T) {
+		p := NewProvider(cr, &versionedDataSaver{mapDataSaver: newMapDataSaver(), history: map[string][][]byte{}})
+		_, err := p.VersionsCtx(ctx, nil)
+		require.True(t, errors.Is(err, secret.ErrEmptyKey))
+	})
+}
@@ -0,0 +1,36 @@
+package secret
+
+import "context"
+
+// Entry is the value by key.
+type Entry struct {
+	Key   []byte
+	Value []byte
+}
+
+// Result is the result of batch operation for the key.
+type Result struct {
+	Value []byte
+	// Err is the error of the key, for example ErrNotFound.
+	Err error
+}
+
+// BatchDataSaver is the data saver, which reads and saves several values at once.
+type BatchDataSaver interface {
+	// ReadDataBatchCtx returns encoded values by keys in the same order.
+	// Value is nil if there is no such key.
+	ReadDataBatchCtx(ctx context.Context, keys [][]byte) ([][]byte, error)
+	// SaveDataBatchCtx saves encoded values by keys, which never expire.
+	// Either all values are saved or none of them.
+	SaveDataBatchCtx(ctx context.Context, entries []Entry) error
+}
+
+// BatchProvider is the provider, which gets and sets several values at once.
+type BatchProvider interface {
+	// GetDataBatchCtx returns results for keys in the same order.
+	// Error is returned only if data saver fails, errors of keys are kept in results.
+	GetDataBatchCtx(ctx context.Context, keys [][]byte) ([]Result, error)
+	// SetDataBatchCtx sets values by keys.
+	// Values are set at once if data saver supports it, otherwise they are set one by one until the first error.
+	SetDataBatchCtx(ctx context.Context, entries []Entry) error
+}
@@ -0,0 +1,110 @@
+package secret
+
+import "context"
+
+// ProviderCtx is Provider which passes context down to the storage,
+// so cancellation and deadlines of callers reach storage drivers.
+type ProviderCtx interface {
+	// SetDataCtx set by key and put value and return error
+	SetDataCtx(ctx context.Context, key, value []byte) error
+	// GetDataCtx get data by key and return array of bytes and error
+	GetDataCtx(ctx context.Context, key []byte) ([]byte, error)
+	// DeleteDataCtx delete data by key and return error
+	DeleteDataCtx(ctx context.Context, key []byte) error
+	// ListKeysCtx return all keys which can be decrypted with provider cipher key and error
+	ListKeysCtx(ctx context.Context) ([][]byte, error)
+}
+
+// DataSaverCtx is DataSaver which takes context for every storage call.
+type DataSaverCtx interface {
+	// SaveDataCtx save encoded value by key
+	SaveDataCtx(ctx context.Context, key, encodedValue []byte) error
+	// ReadDataCtx get encoded data by key
+	ReadDataCtx(ctx context.Context, key []byte) ([]byte, error)
+	// DeleteDataCtx remove encoded value by key
+	DeleteDataCtx(ctx context.Context, key []byte) error
+	// ListKeysCtx get all keys from the storage in the form they were saved
+	ListKeysCtx(ctx context.Context) ([][]byte, error)
+}
+
+// ProviderWithContext returns p if it supports context.
+// Otherwise p is wrapped into adapter, which checks context only before calling p.
+func ProviderWithContext(p Provider) ProviderCtx {
+	if pc, ok := p.(ProviderCtx); ok {
+		return pc
+	}
+	return providerAdapter{p: p}
+}
+
+// DataSaverWithContext returns ds if it supports context.
+// Otherwise ds is wrapped into adapter, which checks context only before calling ds.
+func DataSaverWithContext(ds DataSaver) DataSaverCtx {
+	if dc, ok := ds.(DataSaverCtx); ok {
+		return dc
+	}
+	return dataSaverAdapter{ds: ds}
+}
+
+type providerAdapter struct {
+	p Provider
+}
+
+func (a providerAdapter) SetDataCtx(ctx context.Context, key, value []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.p.SetData(key, value)
+}
+
+func (a providerAdapter) GetDataCtx(ctx context.Context, key []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.p.GetData(key)
+}
+
+func (a providerAdapter) DeleteDataCtx(ctx context.Context, key []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.p.DeleteData(key)
+}
+
+func (a providerAdapter) ListKeysCtx(ctx context.Context) ([][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.p.ListKeys()
+}
+
+type dataSaverAdapter struct {
+	ds DataSaver
+}
+
+func (a dataSaverAdapter) SaveDataCtx(ctx context.Context, key, encodedValue []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.ds.SaveData(key, encodedValue)
+}
+
+func (a dataSaverAdapter) ReadDataCtx(ctx context.Context, key []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.ds.ReadData(key)
+}
+
+func (a dataSaverAdapter) DeleteDataCtx(ctx context.Context, key []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.ds.DeleteData(key)
+}
+
+func (a dataSaverAdapter) ListKeysCtx(ctx context.Context) ([][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.ds.ListKeys()
+}
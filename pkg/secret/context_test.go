@@ -0,0 +1,80 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testDataSaver struct {
+	calls int
+}
+
+func (t *testDataSaver) SaveData(key, encodedValue []byte) error {
+	t.calls++
+	return nil
+}
+
+func (t *testDataSaver) ReadData(key []byte) ([]byte, error) {
+	t.calls++
+	return []byte("value"), nil
+}
+
+func (t *testDataSaver) DeleteData(key []byte) error {
+	t.calls++
+	return nil
+}
+
+func (t *testDataSaver) ListKeys() ([][]byte, error) {
+	t.calls++
+	return [][]byte{[]byte("key")}, nil
+}
+
+type testDataSaverCtx struct {
+	testDataSaver
+}
+
+func (t *testDataSaverCtx) SaveDataCtx(ctx context.Context, key, encodedValue []byte) error {
+	return nil
+}
+
+func (t *testDataSaverCtx) ReadDataCtx(ctx context.Context, key []byte) ([]byte, error) {
+	return nil, nil
+}
+
+func (t *testDataSaverCtx) DeleteDataCtx(ctx context.Context, key []byte) error {
+	return nil
+}
+
+func (t *testDataSaverCtx) ListKeysCtx(ctx context.Context) ([][]byte, error) {
+	return nil, nil
+}
+
+func TestDataSaverWithContext(t *testing.T) {
+	t.Run("return data saver with context support as is", func(t *testing.T) {
+		ds := &testDataSaverCtx{}
+		require.Equal(t, ds, DataSaverWithContext(ds))
+	})
+	t.Run("call data saver", func(t *testing.T) {
+		ds := &testDataSaver{}
+		got, err := DataSaverWithContext(ds).ReadDataCtx(context.Background(), []byte("key"))
+		require.NoError(t, err)
+		require.EqualValues(t, "value", string(got))
+		require.EqualValues(t, 1, ds.calls)
+	})
+	t.Run("error if context is canceled", func(t *testing.T) {
+		ds := &testDataSaver{}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		dc := DataSaverWithContext(ds)
+		require.True(t, errors.Is(dc.SaveDataCtx(ctx, []byte("key"), []byte("value")), context.Canceled))
+		_, err := dc.ReadDataCtx(ctx, []byte("key"))
+		require.True(t, errors.Is(err, context.Canceled))
+		require.True(t, errors.Is(dc.DeleteDataCtx(ctx, []byte("key")), context.Canceled))
+		_, err = dc.ListKeysCtx(ctx)
+		require.True(t, errors.Is(err, context.Canceled))
+		require.EqualValues(t, 0, ds.calls)
+	})
+}
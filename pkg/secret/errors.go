@@ -0,0 +1,14 @@
+package secret
+
+import "errors"
+
+// Errors returned by providers and data savers.
+// Implementations wrap them, so they should be checked with errors.Is.
+var (
+	// ErrNotFound is returned if there is no value by the key.
+	ErrNotFound = errors.New("key not found")
+	// ErrEmptyKey is returned if the key is empty.
+	ErrEmptyKey = errors.New("key can't be nil")
+	// ErrDecrypt is returned if data can't be decrypted, for example with wrong cipher key.
+	ErrDecrypt = errors.New("can't decrypt data")
+)
@@ -0,0 +1,35 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrExpiryNotSupported is returned when value with expiration time is saved to data saver, which can't expire values.
+var ErrExpiryNotSupported = errors.New("storage doesn't support expiration")
+
+// ExpiringDataSaver is the data saver, which removes values after expiration time.
+// Expired value isn't returned by ReadData and ListKeys even if it isn't removed from storage yet.
+type ExpiringDataSaver interface {
+	// SaveDataWithExpiryCtx save encoded value by key, which expires at the time.
+	// Value never expires if the time is zero, so SaveData is the same as saving with zero time.
+	SaveDataWithExpiryCtx(ctx context.Context, key, encodedValue []byte, expiresAt time.Time) error
+	// ExpiryCtx returns expiration time of the value by key.
+	// It returns zero time if value never expires and ErrNotFound if there is no such key.
+	ExpiryCtx(ctx context.Context, key []byte) (time.Time, error)
+}
+
+// Reaper is the data saver, which needs to remove expired values from time to time.
+// Storages, which remove expired values by themselves, don't implement it.
+type Reaper interface {
+	// ReapCtx removes expired values and returns the number of removed keys.
+	ReapCtx(ctx context.Context) (int, error)
+}
+
+// ExpiringProvider is the provider, which can set values with expiration time.
+type ExpiringProvider interface {
+	// SetDataWithExpiryCtx set by key value, which expires at the time.
+	// Value never expires if the time is zero.
+	SetDataWithExpiryCtx(ctx context.Context, key, value []byte, expiresAt time.Time) error
+}
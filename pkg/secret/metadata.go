@@ -0,0 +1,98 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrMetadataNotSupported is returned when metadata is requested from data saver, which doesn't keep it.
+var ErrMetadataNotSupported = errors.New("storage doesn't keep metadata")
+
+// Metadata describes the value.
+// Creation and update times are maintained by storage, they are zero if storage doesn't know them.
+type Metadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Owner       string            `json:"owner,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// MatchLabels reports whether metadata has all the labels.
+// Label with empty value matches any value of the label.
+func (m Metadata) MatchLabels(labels map[string]string) bool {
+	for k, v := range labels {
+		got, ok := m.Labels[k]
+		if !ok || (v != "" && got != v) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseLabels parses label filters from "name=value" strings to match the value or "name" strings to match any value.
+// It returns nil if there are no filters.
+func ParseLabels(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(values))
+	for _, v := range values {
+		name, value := v, ""
+		if i := strings.Index(v, "="); i >= 0 {
+			name, value = v[:i], v[i+1:]
+		}
+		if name == "" {
+			return nil, errors.New("label name can't be empty")
+		}
+		labels[name] = value
+	}
+	return labels, nil
+}
+
+// MetadataRecord is metadata of the value in the form it's kept by data saver.
+type MetadataRecord struct {
+	// Data is metadata encoded by provider, it's empty if metadata isn't set.
+	Data      []byte
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// MetadataDataSaver is the data saver, which keeps metadata of values.
+// Metadata is kept until the value is deleted or expired, saving the value doesn't change it.
+type MetadataDataSaver interface {
+	// SaveMetadataCtx saves encoded metadata of the value by key and updates its update time.
+	// It returns ErrNotFound if there is no such key.
+	SaveMetadataCtx(ctx context.Context, key, encodedMetadata []byte) error
+	// ReadMetadataCtx returns metadata of the value by key.
+	// It returns ErrNotFound if there is no such key.
+	ReadMetadataCtx(ctx context.Context, key []byte) (MetadataRecord, error)
+}
+
+// MetadataValueDataSaver is the metadata data saver, which saves the value with its metadata at once.
+type MetadataValueDataSaver interface {
+	// SaveDataWithMetadataCtx saves encoded value by key, which expires at the time, with encoded metadata
+	// and returns its new revision, see RevisionedDataSaver.SaveDataIfRevisionCtx.
+	// Either both value and metadata are saved or none of them.
+	SaveDataWithMetadataCtx(ctx context.Context, key, encodedValue, encodedMetadata []byte, expiresAt time.Time, revision int) (int, error)
+}
+
+// MetadataProvider is the provider, which gives access to metadata of values.
+type MetadataProvider interface {
+	// SetMetadataCtx replaces labels, description and owner of the value by key.
+	SetMetadataCtx(ctx context.Context, key []byte, md Metadata) error
+	// GetMetadataCtx returns metadata of the value by key.
+	GetMetadataCtx(ctx context.Context, key []byte) (Metadata, error)
+	// ListKeysByLabelsCtx returns keys, which metadata matches all the labels, see Metadata.MatchLabels.
+	ListKeysByLabelsCtx(ctx context.Context, labels map[string]string) ([][]byte, error)
+}
+
+// MetadataValueProvider is the provider, which sets the value with its metadata at once.
+type MetadataValueProvider interface {
+	// SetDataWithMetadataCtx sets by key value, which expires at the time, with labels, description and owner
+	// if value has the revision and returns its new revision. Value never expires if the time is zero.
+	// Either both value and metadata are set or none of them.
+	SetDataWithMetadataCtx(ctx context.Context, key, value []byte, md Metadata, expiresAt time.Time, revision int) (int, error)
+}
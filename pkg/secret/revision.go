@@ -0,0 +1,40 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// AnyRevision saves the value whatever its current revision is.
+const AnyRevision = -1
+
+var (
+	// ErrRevisionMismatch is returned when value is saved with revision, which isn't the current revision of the value.
+	ErrRevisionMismatch = errors.New("revision doesn't match")
+	// ErrRevisionNotSupported is returned when revision is requested from data saver, which doesn't keep it.
+	ErrRevisionNotSupported = errors.New("storage doesn't keep revisions")
+)
+
+// RevisionedDataSaver is the data saver, which keeps revision of every value.
+// Revision starts from 1 and is increased by every save of the value,
+// so the value can be saved only if nobody else has changed it since it was read.
+// Revision starts again if the value is deleted or expired.
+type RevisionedDataSaver interface {
+	// ReadDataWithRevisionCtx returns encoded value by key with its revision.
+	// It returns ErrNotFound if there is no such key.
+	ReadDataWithRevisionCtx(ctx context.Context, key []byte) ([]byte, int, error)
+	// SaveDataIfRevisionCtx saves encoded value by key, which expires at the time, and returns its new revision.
+	// Value is saved only if its current revision is the revision, 0 means there should be no such key.
+	// It returns ErrRevisionMismatch otherwise. Value is saved unconditionally with AnyRevision.
+	SaveDataIfRevisionCtx(ctx context.Context, key, encodedValue []byte, expiresAt time.Time, revision int) (int, error)
+}
+
+// RevisionedProvider is the provider, which gives access to revisions of values.
+type RevisionedProvider interface {
+	// GetDataWithRevisionCtx returns value by key with its revision.
+	GetDataWithRevisionCtx(ctx context.Context, key []byte) ([]byte, int, error)
+	// SetDataIfRevisionCtx set by key value, which expires at the time, if value has the revision and returns its new revision.
+	// Value never expires if the time is zero.
+	SetDataIfRevisionCtx(ctx context.Context, key, value []byte, expiresAt time.Time, revision int) (int, error)
+}
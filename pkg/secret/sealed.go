@@ -0,0 +1,13 @@
+package secret
+
+import "context"
+
+// SealedDataSaver is the data saver, which can be encrypted as a whole with the key derived from passphrase.
+// Sealed data saver is opened with the same passphrase as the cipher key of provider,
+// so rotation of the cipher key reseals it with the new one.
+type SealedDataSaver interface {
+	// Sealed reports whether the data saver is encrypted as a whole.
+	Sealed() bool
+	// ResealCtx encrypts sealed data saver with the new passphrase.
+	ResealCtx(ctx context.Context, passphrase []byte) error
+}
@@ -7,18 +7,29 @@ type Provider interface {
 	SetData(key, value []byte) error
 	// GetData get data by key and return array of bytes and error
 	GetData(key []byte) ([]byte, error)
+	// DeleteData delete data by key and return error
+	DeleteData(key []byte) error
+	// ListKeys return all keys which can be decrypted with provider cipher key and error
+	ListKeys() ([][]byte, error)
 }
 
 // Cryptographer describes the behavior for encrypting and decrypting data
 type Cryptographer interface {
 	// Encode takes value and return encode value and error
+	// Encoded value is different each time even for the same value.
 	Encode(value []byte) ([]byte, error)
 	// Decode takes encode value and return decode value and error
 	Decode(encodedValue []byte) ([]byte, error)
+	// EncodeKey takes key name and return encode key and error
+	// Encoded key is the same each time for the same key name, so it can be used for lookups.
+	EncodeKey(key []byte) ([]byte, error)
+	// DecodeKey takes encode key and return decode key name and error
+	DecodeKey(encodedKey []byte) ([]byte, error)
 }
 
 // DataSaver describes the behavior of storing and reading data in the storage
 // For implementation, we can use any type of storage (for example: cloud, file, local memory)
+// Errors for empty and missing keys wrap ErrEmptyKey and ErrNotFound, see storagetest.Run for the whole contract.
 type DataSaver interface {
 	// SaveData save encoded value by key and
 	// It return any write error encountered.
@@ -26,4 +37,10 @@ type DataSaver interface {
 	// ReadData get encoded data by key
 	// It returns the array of bytes and any write error encountered.
 	ReadData(key []byte) ([]byte, error)
+	// DeleteData remove encoded value by key
+	// It returns any write error encountered, including missing key.
+	DeleteData(key []byte) error
+	// ListKeys get all keys from the storage in the form they were saved
+	// It returns the slice of keys and any read error encountered.
+	ListKeys() ([][]byte, error)
 }
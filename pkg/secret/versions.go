@@ -0,0 +1,55 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotVersioned is returned when versions are requested from data saver, which doesn't keep them.
+var ErrNotVersioned = errors.New("storage doesn't keep versions")
+
+// Version describes one saved version of the value.
+// Creation time is zero for values saved before storage started to keep versions.
+type Version struct {
+	Number    int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// VersionedDataSaver is the data saver, which keeps previous versions of values.
+// Every save creates a new version with the next number, ReadData returns the latest one.
+// Deleting the key removes all its versions.
+type VersionedDataSaver interface {
+	// VersionsCtx returns versions of the key from the oldest to the latest.
+	// It returns ErrNotFound if there is no such key.
+	VersionsCtx(ctx context.Context, key []byte) ([]Version, error)
+	// ReadVersionCtx returns encoded value of the version.
+	// It returns ErrNotFound if there is no such version, for example when it's removed by retention.
+	ReadVersionCtx(ctx context.Context, key []byte, version int) ([]byte, error)
+}
+
+// VersionedValue is the encoded value of the version.
+type VersionedValue struct {
+	Version
+	Value []byte
+}
+
+// HistoryDataSaver is the versioned data saver, which saves all versions of the value at once,
+// so the value can be re-encrypted without losing its previous versions.
+type HistoryDataSaver interface {
+	VersionedDataSaver
+	// SaveHistoryCtx replaces the value by key and its versions with the history from the oldest to the latest version.
+	// Numbers and creation times of versions are kept, the latest one becomes the value, which expires at the time.
+	SaveHistoryCtx(ctx context.Context, key []byte, history []VersionedValue, expiresAt time.Time) error
+}
+
+// VersionedProvider is the provider, which gives access to previous versions of values.
+type VersionedProvider interface {
+	// VersionsCtx returns versions of the key from the oldest to the latest.
+	VersionsCtx(ctx context.Context, key []byte) ([]Version, error)
+	// GetVersionCtx returns decrypted value of the version.
+	GetVersionCtx(ctx context.Context, key []byte, version int) ([]byte, error)
+	// RollbackCtx saves value of the version as the new latest version.
+	// Versions are never rewritten, so rollback can be undone by another rollback.
+	RollbackCtx(ctx context.Context, key []byte, version int) error
+}
@@ -0,0 +1,112 @@
+/*
+Package tlsconfig loads TLS certificates for server and clients.
+Server certificates can be reloaded without restart, so rotated certificates are picked up by running server.
+*/
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync/atomic"
+)
+
+// Reloader keeps server TLS config loaded from files and reloads it on demand.
+type Reloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	config       atomic.Value // *tls.Config
+}
+
+// NewReloader loads server certificate and key.
+// If client CA file is set, clients are required to present certificate signed by one of its CAs.
+func NewReloader(certFile, keyFile, clientCAFile string) (*Reloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("tlsconfig: both certificate and key files should be set")
+	}
+	r := &Reloader{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads files again.
+// Previous config is kept if files can't be loaded, so server keeps working with old certificates.
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("tlsconfig: cannot load key pair: %w", err)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if r.clientCAFile != "" {
+		if config.ClientCAs, err = LoadCertPool(r.clientCAFile); err != nil {
+			return err
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	r.config.Store(config)
+	return nil
+}
+
+// Config returns server config, which always uses the latest loaded certificates.
+func (r *Reloader) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &r.current().Certificates[0], nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current(), nil
+		},
+	}
+}
+
+func (r *Reloader) current() *tls.Config {
+	return r.config.Load().(*tls.Config)
+}
+
+// LoadCertPool reads PEM encoded CA certificates from the file.
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("tlsconfig: cannot read ca file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("tlsconfig: ca file %s doesn't have certificates", path)
+	}
+	return pool, nil
+}
+
+// ClientConfig creates client config from files.
+// System CAs are used if CA file isn't set.
+// Client certificate is used if both certificate and key files are set.
+func ClientConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pool, err := LoadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	if (certFile == "") != (keyFile == "") {
+		return nil, errors.New("tlsconfig: both client certificate and key files should be set")
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tlsconfig: cannot load client key pair: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
@@ -0,0 +1,101 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-itools-internship/go-secret/pkg/tlsconfig/tlstest"
+)
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	ca := tlstest.NewCA(t, "test-ca")
+	caFile := tlstest.WriteFile(t, dir, "ca.pem", ca.PEM)
+	certPEM, keyPEM := ca.Issue(t, "server-1")
+	certFile := tlstest.WriteFile(t, dir, "server.pem", certPEM)
+	keyFile := tlstest.WriteFile(t, dir, "server-key.pem", keyPEM)
+	clientCertPEM, clientKeyPEM := ca.Issue(t, "team-a")
+	clientCertFile := tlstest.WriteFile(t, dir, "client.pem", clientCertPEM)
+	clientKeyFile := tlstest.WriteFile(t, dir, "client-key.pem", clientKeyPEM)
+
+	r, err := NewReloader(certFile, keyFile, caFile)
+	require.NoError(t, err)
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, err := w.Write([]byte(req.TLS.PeerCertificates[0].Subject.CommonName))
+		require.NoError(t, err)
+	}))
+	s.TLS = r.Config()
+	s.StartTLS()
+	defer s.Close()
+
+	serverName := func(config *tls.Config) (string, error) {
+		client := &http.Client{Timeout: time.Second, Transport: &http.Transport{TLSClientConfig: config}}
+		resp, err := client.Get(s.URL)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].Subject.CommonName, nil
+	}
+
+	t.Run("success with client certificate", func(t *testing.T) {
+		config, err := ClientConfig(caFile, clientCertFile, clientKeyFile)
+		require.NoError(t, err)
+		name, err := serverName(config)
+		require.NoError(t, err)
+		require.EqualValues(t, "server-1", name)
+	})
+	t.Run("error without client certificate", func(t *testing.T) {
+		config, err := ClientConfig(caFile, "", "")
+		require.NoError(t, err)
+		_, err = serverName(config)
+		require.Error(t, err)
+	})
+	t.Run("reload", func(t *testing.T) {
+		config, err := ClientConfig(caFile, clientCertFile, clientKeyFile)
+		require.NoError(t, err)
+
+		certPEM, keyPEM := ca.Issue(t, "server-2")
+		tlstest.WriteFile(t, dir, "server.pem", certPEM)
+		tlstest.WriteFile(t, dir, "server-key.pem", keyPEM)
+		require.NoError(t, r.Reload())
+		name, err := serverName(config)
+		require.NoError(t, err)
+		require.EqualValues(t, "server-2", name)
+
+		// broken files don't replace loaded certificates
+		tlstest.WriteFile(t, dir, "server.pem", []byte("broken"))
+		require.Error(t, r.Reload())
+		name, err = serverName(config)
+		require.NoError(t, err)
+		require.EqualValues(t, "server-2", name)
+	})
+}
+
+func TestNewReloader(t *testing.T) {
+	_, err := NewReloader("cert.pem", "", "")
+	require.EqualError(t, err, "tlsconfig: both certificate and key files should be set")
+
+	_, err = NewReloader("not-existing-cert.pem", "not-existing-key.pem", "")
+	require.Error(t, err)
+}
+
+func TestClientConfig(t *testing.T) {
+	_, err := ClientConfig("", "client.pem", "")
+	require.EqualError(t, err, "tlsconfig: both client certificate and key files should be set")
+
+	dir := t.TempDir()
+	_, err = ClientConfig(tlstest.WriteFile(t, dir, "ca.pem", []byte("not a certificate")), "", "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "doesn't have certificates")
+
+	config, err := ClientConfig("", "", "")
+	require.NoError(t, err)
+	require.Nil(t, config.RootCAs)
+	require.Empty(t, config.Certificates)
+}
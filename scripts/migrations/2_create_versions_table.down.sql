@@ -0,0 +1 @@
+DROP TABLE IF EXISTS versions;
\ No newline at end of file
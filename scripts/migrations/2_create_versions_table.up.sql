@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS versions
+(
+    key        text        NOT NULL,
+    version    integer     NOT NULL,
+    value      text        NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (key, version)
+);
+INSERT INTO versions (key, version, value)
+SELECT key, 1, value
+FROM postgres
+ON CONFLICT DO NOTHING;
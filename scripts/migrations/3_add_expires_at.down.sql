@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS postgres_expires_at_idx;
+ALTER TABLE postgres DROP COLUMN IF EXISTS expires_at;
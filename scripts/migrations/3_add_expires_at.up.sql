@@ -0,0 +1,2 @@
+ALTER TABLE postgres ADD COLUMN IF NOT EXISTS expires_at timestamptz;
+CREATE INDEX IF NOT EXISTS postgres_expires_at_idx ON postgres (expires_at) WHERE expires_at IS NOT NULL;
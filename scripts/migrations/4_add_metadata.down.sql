@@ -0,0 +1,3 @@
+ALTER TABLE postgres DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE postgres DROP COLUMN IF EXISTS created_at;
+ALTER TABLE postgres DROP COLUMN IF EXISTS metadata;
@@ -0,0 +1,3 @@
+ALTER TABLE postgres ADD COLUMN IF NOT EXISTS metadata text;
+ALTER TABLE postgres ADD COLUMN IF NOT EXISTS created_at timestamptz;
+ALTER TABLE postgres ADD COLUMN IF NOT EXISTS updated_at timestamptz;
@@ -0,0 +1 @@
+ALTER TABLE postgres DROP COLUMN IF EXISTS revision;
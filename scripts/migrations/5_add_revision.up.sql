@@ -0,0 +1,5 @@
+ALTER TABLE postgres ADD COLUMN IF NOT EXISTS revision integer NOT NULL DEFAULT 1;
+UPDATE postgres p
+SET revision = v.version
+FROM (SELECT key, MAX(version) AS version FROM versions GROUP BY key) v
+WHERE v.key = p.key;